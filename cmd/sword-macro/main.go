@@ -7,10 +7,13 @@ import (
 	"runtime"
 	"syscall"
 
+	"github.com/StopDragon/sword-macro-ai/internal/analysis"
+	"github.com/StopDragon/sword-macro-ai/internal/api"
 	"github.com/StopDragon/sword-macro-ai/internal/config"
 	"github.com/StopDragon/sword-macro-ai/internal/console"
 	"github.com/StopDragon/sword-macro-ai/internal/game"
 	"github.com/StopDragon/sword-macro-ai/internal/logger"
+	"github.com/StopDragon/sword-macro-ai/internal/server"
 	"github.com/StopDragon/sword-macro-ai/internal/telemetry"
 )
 
@@ -35,10 +38,6 @@ func main() {
 	logger.Init()
 	defer logger.Close()
 
-	// 텔레메트리 초기화
-	telem := telemetry.New(VERSION)
-	defer telem.Flush()
-
 	// 설정 로드
 	cfg, err := config.Load()
 	if err != nil {
@@ -46,9 +45,39 @@ func main() {
 		cfg = config.Default()
 	}
 
+	// 서버 연결 설정 (텔레메트리/게임 데이터 공용)
+	if cfg.IsLocalMode() {
+		local, err := server.OpenLocal(cfg.ResolvedLocalDBPath())
+		if err != nil {
+			logger.Error("로컬 모드 초기화 실패: %v", err)
+			fmt.Println("⚠️ 로컬 모드 초기화 실패 - 통계가 저장되지 않습니다")
+		} else {
+			logger.Info("로컬 모드: 네트워크 호출 없음 (DB: %s)", cfg.ResolvedLocalDBPath())
+			fmt.Println("📦 로컬 모드: 서버와 통신하지 않습니다")
+		}
+		api.UseLocal(local)
+	} else {
+		api.SetBaseURL(cfg.ResolvedAPIURL())
+	}
+	defer api.Close()
+
+	// 텔레메트리 초기화
+	telem := telemetry.New(VERSION)
+	defer telem.Flush()
+
 	// 게임 엔진 생성
 	engine := game.NewEngine(cfg, telem)
 
+	// 강화 중단 정책에 리스크 분석 연결
+	game.SetEnhanceRiskFunc(func(level, gold, target int) (float64, int) {
+		r := analysis.CalcRisk(level, gold, target)
+		return r.SuccessProb, r.ExpectedGold
+	})
+
+	// 아이템 카탈로그 동기화 (서버에 연결할 수 없으면 로컬 카탈로그 사용)
+	go game.SyncItemCatalog()
+	defer game.SaveItemCatalog()
+
 	// 시그널 핸들링 (Ctrl+C)
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -56,6 +85,11 @@ func main() {
 		<-sigChan
 		fmt.Println("\n\n프로그램을 종료합니다...")
 		engine.Stop()
+		// os.Exit는 defer를 실행하지 않으므로 종료 처리를 직접 수행 (텔레메트리 → 로컬 DB 순서)
+		telem.Flush()
+		game.SaveItemCatalog()
+		api.Close()
+		logger.Close()
 		os.Exit(0)
 	}()
 
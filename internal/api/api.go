@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/logger"
+)
+
+// DefaultBaseURL 기본 sword-api 서버 주소
+const DefaultBaseURL = "https://sword-ai.stopdragon.kr"
+
+// Backend 로컬 모드 데이터 소스 (네트워크 대신 직접 호출)
+type Backend interface {
+	// Ingest 텔레메트리 페이로드(JSON) 저장
+	Ingest(payload []byte) error
+	// Query 조회 경로(/api/...)의 응답 JSON
+	Query(path string, params url.Values) ([]byte, error)
+	// Close 저장소 정리
+	Close() error
+}
+
+var (
+	mu      sync.RWMutex
+	baseURL = DefaultBaseURL
+	local   Backend // nil이면 온라인 모드
+)
+
+// SetBaseURL 서버 주소 설정 (온라인 모드)
+func SetBaseURL(url string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if url == "" {
+		url = DefaultBaseURL
+	}
+	baseURL = strings.TrimRight(url, "/")
+}
+
+// UseLocal 로컬 모드 활성화 (이후 텔레메트리와 게임 데이터는 b를 직접 호출)
+func UseLocal(b Backend) {
+	mu.Lock()
+	defer mu.Unlock()
+	local = b
+}
+
+// Local 로컬 모드 데이터 소스 (온라인 모드면 nil)
+func Local() Backend {
+	mu.RLock()
+	defer mu.RUnlock()
+	return local
+}
+
+// Close 로컬 모드 저장소 정리 (프로그램 종료 시, 텔레메트리 Flush 이후 호출)
+func Close() {
+	mu.Lock()
+	b := local
+	local = nil
+	mu.Unlock()
+	if b == nil {
+		return
+	}
+	if err := b.Close(); err != nil {
+		logger.Error("로컬 DB 닫기 실패: %v", err)
+	}
+}
+
+// IsLocal 로컬 모드 여부
+func IsLocal() bool {
+	return Local() != nil
+}
+
+// URL API 경로의 전체 주소 (예: URL("/api/game-data"))
+func URL(path string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return baseURL + path
+}
+
+// Client 서버 요청용 HTTP 클라이언트
+func Client(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout}
+}
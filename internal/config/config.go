@@ -4,10 +4,26 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const ConfigFile = "sword_config.json"
 
+// 네트워크 모드
+const (
+	NetworkOnline = "online" // sword-api 서버 사용 (기본)
+	NetworkLocal  = "local"  // 네트워크 호출 없음, 로컬 SQLite 사용
+)
+
+// 환경변수 (설정 파일보다 우선)
+const (
+	apiURLEnvVar      = "SWORD_API_URL"
+	networkModeEnvVar = "SWORD_NETWORK_MODE"
+	localDBEnvVar     = "SWORD_LOCAL_DB"
+)
+
+const defaultLocalDB = "sword-local.db"
+
 // Config 매크로 설정
 type Config struct {
 	// 좌표 설정
@@ -26,6 +42,15 @@ type Config struct {
 	BattleLevelDiff int     `json:"battle_level_diff"` // 역배 레벨 차이 (1-20)
 	BattleCooldown  float64 `json:"battle_cooldown"`   // 배틀 간 쿨다운 (초)
 
+	BattleRotate             bool     `json:"battle_rotate"`                // 타겟 순환 (false면 기대값이 가장 높은 타겟 반복)
+	BattleMaxLossesPerTarget int      `json:"battle_max_losses_per_target"` // 세션 내 타겟별 최대 패배 (0=무제한)
+	BattleBlacklist          []string `json:"battle_blacklist,omitempty"`   // 배틀하지 않을 유저 (수동 지정, 자동 블랙리스트는 opponents.json)
+	OpponentProfileTTL       int      `json:"opponent_profile_ttl"`         // 저장된 상대 레벨 재사용 기간 (분, 0이면 매번 조회)
+
+	// 특수 뽑기 설정 (이름 부분 일치)
+	SpecialWanted []string `json:"special_wanted,omitempty"` // 찾을 특수 이름 (비우면 모든 특수)
+	SpecialSell   []string `json:"special_sell,omitempty"`   // 보관하지 않고 일반처럼 판매할 특수 이름
+
 	// 클립보드 텍스트 읽기
 	ChatOffsetY int `json:"chat_offset_y"` // 입력창에서 채팅 영역까지 거리 (픽셀)
 
@@ -37,6 +62,18 @@ type Config struct {
 
 	// 연속 실패 경고 임계값 (hold 연속 N회 시 경고, 기본 5)
 	ConsecutiveFailWarn int `json:"consecutive_fail_warn"`
+
+	// 강화 중단 정책 (EnhanceToTarget, 매 /강화 전 확인)
+	EnhanceMinSuccess       float64 `json:"enhance_min_success"`         // 목표 도달 확률이 이 값(%) 미만이면 중단 (0=사용 안 함)
+	EnhanceStopIfSellBetter bool    `json:"enhance_stop_if_sell_better"` // 지금 판매가 계속 강화 기대값보다 나으면 중단
+
+	// /프로필 재동기화 주기 (강화 N회마다 실제 레벨/검/골드 확인, 0이면 이상 감지 시에만)
+	ResyncEvery int `json:"resync_every"`
+
+	// 서버 설정
+	APIURL      string `json:"api_url,omitempty"`       // sword-api 주소 (비우면 기본 서버)
+	NetworkMode string `json:"network_mode,omitempty"`  // "online"(기본) / "local"
+	LocalDBPath string `json:"local_db_path,omitempty"` // 로컬 모드 DB 파일 (비우면 실행 파일 옆 sword-local.db)
 }
 
 // Default 기본 설정 반환
@@ -60,6 +97,11 @@ func Default() *Config {
 		OverlayInputHeight: 50,
 		// 연속 실패 경고
 		ConsecutiveFailWarn: 5,
+		// 배틀 타겟
+		BattleMaxLossesPerTarget: 3,
+		OpponentProfileTTL:       60,
+		// 상태 재동기화
+		ResyncEvery: 30,
 	}
 }
 
@@ -95,6 +137,38 @@ func (c *Config) Save() error {
 	return os.WriteFile(configPath, data, 0644)
 }
 
+// ResolvedAPIURL 사용할 서버 주소 (환경변수 > 설정 파일, 비어있으면 기본 서버)
+func (c *Config) ResolvedAPIURL() string {
+	if url := os.Getenv(apiURLEnvVar); url != "" {
+		return url
+	}
+	return c.APIURL
+}
+
+// IsLocalMode 로컬 모드 여부 (환경변수 > 설정 파일)
+func (c *Config) IsLocalMode() bool {
+	mode := c.NetworkMode
+	if env := os.Getenv(networkModeEnvVar); env != "" {
+		mode = env
+	}
+	return strings.EqualFold(mode, NetworkLocal)
+}
+
+// ResolvedLocalDBPath 로컬 모드 DB 경로 (환경변수 > 설정 파일 > 실행 파일 옆)
+func (c *Config) ResolvedLocalDBPath() string {
+	if path := os.Getenv(localDBEnvVar); path != "" {
+		return path
+	}
+	if c.LocalDBPath != "" {
+		return c.LocalDBPath
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return defaultLocalDB
+	}
+	return filepath.Join(filepath.Dir(exe), defaultLocalDB)
+}
+
 func getConfigPath() string {
 	exe, err := os.Executable()
 	if err != nil {
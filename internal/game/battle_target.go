@@ -0,0 +1,245 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ========================
+// 배틀 타겟 선택 (기대 골드 기준)
+// ========================
+//
+// 레벨 조합별 승률/보상(서버 승률 모델)에 내 검과 상대의 프로필 전적, 상대 DB의 나와의 전적을 반영해
+// 타겟별 기대 골드를 계산하고 높은 순으로 배틀 (순환 / 패배 한도는 이번 세션 전적 기준)
+
+const (
+	recordPriorWeight = 10 // 프로필 승률을 50%로 당기는 가상 배틀 수 (전적이 적을 때 과신 방지)
+	h2hPriorWeight    = 5  // 상대 전적 반영 시 추정 승률에 주는 가상 배틀 수
+
+	minBattleWinProb = 0.001
+	maxBattleWinProb = 0.95
+
+	blacklistMinBattles = 5    // 자동 블랙리스트 판정 최소 배틀 수
+	blacklistPValue     = 0.05 // 추정 승률로 이 전적 이하가 나올 확률이 이보다 낮으면 블랙리스트
+)
+
+// headToHead 특정 상대와의 전적
+type headToHead struct {
+	Battles int `json:"battles"`
+	Wins    int `json:"wins"`   // 내가 이긴 횟수
+	Losses  int `json:"losses"` // 내가 진 횟수
+}
+
+// battleTarget 배틀 후보와 기대값
+type battleTarget struct {
+	Username string
+	Level    int
+	Wins     int // 상대 프로필 승리 수
+	Losses   int // 상대 프로필 패배 수
+
+	BaseWinProb float64 // 레벨 조합 기준 승률 (0이면 통계 없음)
+	WinProb     float64 // 전적을 반영한 추정 승률
+	AvgReward   int     // 승리 시 평균 보상
+	Expected    float64 // 배틀 1회 기대 골드
+}
+
+// smoothedWinRate 가상 전적을 더해 50%로 당긴 승률
+func smoothedWinRate(wins, losses int) float64 {
+	return (float64(wins) + recordPriorWeight/2) / (float64(wins+losses) + recordPriorWeight)
+}
+
+func clampWinProb(p float64) float64 {
+	return math.Max(minBattleWinProb, math.Min(maxBattleWinProb, p))
+}
+
+// estimateBattle 타겟의 추정 승률과 기대 골드 계산
+// 서버 승률 모델(내 레벨, 상대 레벨, 검 타입)을 우선 사용하고 없으면 레벨차별 게임 데이터 사용
+// lossCost: 패배 시 예상 손실 (세션 평균, 모르면 0 - 서버 평균 손실 사용)
+func estimateBattle(my *Profile, t *battleTarget, h2h *headToHead, lossCost int) {
+	if m := FetchMatchup(my.Level, t.Level, battleSwordType(my.SwordName)); m != nil && m.WinRate > 0 {
+		t.BaseWinProb = m.WinRate / 100.0
+		t.AvgReward = m.AvgReward
+		if lossCost == 0 {
+			lossCost = m.AvgLoss
+		}
+	} else if reward := GetBattleReward(t.Level - my.Level); reward != nil && reward.WinRate > 0 {
+		t.BaseWinProb = reward.WinRate / 100.0
+		t.AvgReward = reward.AvgReward
+	} else {
+		t.BaseWinProb, t.WinProb, t.AvgReward, t.Expected = 0, 0, 0, 0
+		return
+	}
+
+	// 내 검과 상대 검의 전체 승률 차이를 승산비로 반영 (프로필 전적은 다른 상대 포함이므로 절반 강도)
+	p := clampWinProb(t.BaseWinProb)
+	mine := smoothedWinRate(my.Wins, my.Losses)
+	theirs := smoothedWinRate(t.Wins, t.Losses)
+	odds := p / (1 - p) * math.Sqrt((mine/(1-mine))/(theirs/(1-theirs)))
+	p = odds / (1 + odds)
+
+	// 이 상대와 직접 싸운 결과
+	if h2h != nil && h2h.Battles > 0 {
+		p = (p*h2hPriorWeight + float64(h2h.Wins)) / (h2hPriorWeight + float64(h2h.Battles))
+	}
+
+	t.WinProb = clampWinProb(p)
+	t.Expected = t.WinProb*float64(t.AvgReward) - (1-t.WinProb)*float64(lossCost)
+}
+
+// rankBattleTargets 기대 골드 내림차순 정렬 (통계가 없으면 낮은 레벨 우선)
+func rankBattleTargets(targets []*battleTarget, my *Profile, db *OpponentDB, lossCost int) {
+	for _, t := range targets {
+		estimateBattle(my, t, db.HeadToHead(t.Username), lossCost)
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		if targets[i].Expected != targets[j].Expected {
+			return targets[i].Expected > targets[j].Expected
+		}
+		return targets[i].Level < targets[j].Level
+	})
+}
+
+// pickBattleTarget 정렬된 후보 중 배틀할 타겟 선택
+// rotate면 이번 세션에 덜 싸운 타겟 우선 (같으면 기대값 순)
+func pickBattleTarget(ranked []*battleTarget, h2h map[string]*headToHead, rotate bool) *battleTarget {
+	if len(ranked) == 0 {
+		return nil
+	}
+	if !rotate {
+		return ranked[0]
+	}
+	battles := func(t *battleTarget) int {
+		if r := h2h[t.Username]; r != nil {
+			return r.Battles
+		}
+		return 0
+	}
+	best := ranked[0]
+	for _, t := range ranked[1:] {
+		if battles(t) < battles(best) {
+			best = t
+		}
+	}
+	return best
+}
+
+// removeBattleTarget 후보 목록에서 유저 제거
+func removeBattleTarget(targets []*battleTarget, username string) []*battleTarget {
+	for i, t := range targets {
+		if t.Username == username {
+			return append(targets[:i], targets[i+1:]...)
+		}
+	}
+	return targets
+}
+
+// consistentlyBeatsUs 추정 승률에 비해 너무 많이 졌는지 (이항분포 하단 꼬리 확률로 판정)
+func consistentlyBeatsUs(h2h *headToHead, baseWinProb float64) bool {
+	if h2h == nil || h2h.Battles < blacklistMinBattles || baseWinProb <= 0 {
+		return false
+	}
+	return binomialCDF(h2h.Wins, h2h.Battles, clampWinProb(baseWinProb)) < blacklistPValue
+}
+
+// binomialCDF P(X <= k), X ~ B(n, p)
+func binomialCDF(k, n int, p float64) float64 {
+	term := math.Pow(1-p, float64(n)) // P(X = 0)
+	sum := term
+	for i := 0; i < k && i < n; i++ {
+		term *= float64(n-i) / float64(i+1) * p / (1 - p)
+		sum += term
+	}
+	return sum
+}
+
+// printBattleTargets 후보별 기대값 출력 (정렬된 목록)
+func printBattleTargets(ranked []*battleTarget) {
+	for i, t := range ranked {
+		if t.BaseWinProb <= 0 {
+			fmt.Printf("   %d. %s +%d (통계 없음)\n", i+1, t.Username, t.Level)
+			continue
+		}
+		fmt.Printf("   %d. %s +%d | 승률 %.1f%% | 보상 %sG | 기대 %+.0fG\n",
+			i+1, t.Username, t.Level, t.WinProb*100, FormatGold(t.AvgReward), t.Expected)
+	}
+}
+
+// avgBattleLoss 이번 세션 패배당 평균 손실 (패배가 없으면 0)
+func (e *Engine) avgBattleLoss() int {
+	if e.battleLosses == 0 {
+		return 0
+	}
+	return e.battleLossGold / e.battleLosses
+}
+
+// isBattleBlacklisted 블랙리스트 유저인지 (설정의 수동 목록 + 상대 DB의 자동 블랙리스트)
+func (e *Engine) isBattleBlacklisted(username string) bool {
+	for _, name := range e.cfg.BattleBlacklist {
+		if name == username {
+			return true
+		}
+	}
+	return e.opponents.IsBlacklisted(username)
+}
+
+// battleLossCapReached 이번 세션에서 이 상대에게 패배 한도만큼 졌는지
+func (e *Engine) battleLossCapReached(username string) bool {
+	limit := e.cfg.BattleMaxLossesPerTarget
+	r := e.battleH2H[username]
+	return limit > 0 && r != nil && r.Losses >= limit
+}
+
+// recordBattleAgainst 이번 세션 상대 전적 갱신 (반환: 후보에서 제외해야 하면 true)
+// 상대 DB 전적으로 볼 때 추정 승률에 비해 계속 지는 상대는 상대 DB에 블랙리스트로 표시 (설정 파일은 건드리지 않음)
+func (e *Engine) recordBattleAgainst(t *battleTarget, won bool) bool {
+	r := e.battleH2H[t.Username]
+	if r == nil {
+		r = &headToHead{}
+		e.battleH2H[t.Username] = r
+	}
+	r.Battles++
+	if won {
+		r.Wins++
+	} else {
+		r.Losses++
+	}
+
+	if all := e.opponents.HeadToHead(t.Username); consistentlyBeatsUs(all, t.BaseWinProb) {
+		fmt.Printf("   🚫 %s: %d전 %d승 (기준 승률 %.1f%%) → 블랙리스트 추가\n",
+			t.Username, all.Battles, all.Wins, t.BaseWinProb*100)
+		e.opponents.Blacklist(t.Username)
+		e.opponents.Save()
+		return true
+	}
+	if e.battleLossCapReached(t.Username) {
+		fmt.Printf("   ⛔ %s: 이번 세션 %d패 → 타겟에서 제외\n", t.Username, r.Losses)
+		return true
+	}
+	return false
+}
+
+// opponentTTL 저장된 상대 레벨을 재사용할 기간
+func (e *Engine) opponentTTL() time.Duration {
+	return time.Duration(e.cfg.OpponentProfileTTL) * time.Minute
+}
+
+// observeOwnBattle 내 배틀 결과를 상대 DB에 반영
+// 결과 텍스트에서 참가자를 못 읽었으면 승패와 타겟으로 채움
+func (e *Engine) observeOwnBattle(result *BattleResult, t *battleTarget) {
+	me, opp := e.myProfile.Name, t.Username
+	myLevel, oppLevel := e.myProfile.Level, t.Level
+	if result.Won {
+		if result.Loser == opp && result.LoserLevel >= 0 {
+			oppLevel = result.LoserLevel
+		}
+		e.opponents.ObserveBattle(me, opp, myLevel, oppLevel, me)
+	} else {
+		if result.Winner == opp && result.WinnerLevel >= 0 {
+			oppLevel = result.WinnerLevel
+		}
+		e.opponents.ObserveBattle(opp, me, oppLevel, myLevel, me)
+	}
+	e.opponents.Save()
+}
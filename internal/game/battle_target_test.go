@@ -0,0 +1,136 @@
+package game
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func testBattleData(t *testing.T) {
+	setTestGameData(t, &GameData{BattleRewards: []BattleReward{
+		{LevelDiff: 1, WinRate: 40, AvgReward: 500},
+		{LevelDiff: 2, WinRate: 30, AvgReward: 1000},
+		{LevelDiff: 3, WinRate: 10, AvgReward: 2000},
+	}})
+	// 승률 모델 예측이 없는 조합은 레벨차 통계 사용
+	for _, opp := range []int{11, 12, 13, 14, 20} {
+		setTestMatchup(t, 10, opp, "", nil)
+	}
+}
+
+func TestEstimateBattle(t *testing.T) {
+	testBattleData(t)
+	my := &Profile{Level: 10}
+
+	tgt := &battleTarget{Username: "@상대", Level: 12}
+	estimateBattle(my, tgt, nil, 100)
+	if math.Abs(tgt.WinProb-0.3) > 1e-9 || math.Abs(tgt.Expected-230) > 1e-9 {
+		t.Errorf("no record: win %.3f expected %.1f, want 0.300 / 230", tgt.WinProb, tgt.Expected)
+	}
+
+	// 나와의 전적 5전 5승 → (0.3*5 + 5) / 10
+	estimateBattle(my, tgt, &headToHead{Battles: 5, Wins: 5}, 100)
+	if math.Abs(tgt.WinProb-0.65) > 1e-9 {
+		t.Errorf("with h2h: win %.3f, want 0.650", tgt.WinProb)
+	}
+
+	// 상대 전적이 좋으면 승률이 내려감
+	strong := &battleTarget{Username: "@강자", Level: 12, Wins: 90, Losses: 10}
+	estimateBattle(my, strong, nil, 100)
+	if strong.WinProb >= 0.3 {
+		t.Errorf("strong opponent win %.3f, want < 0.300", strong.WinProb)
+	}
+
+	// 승률 모델 예측이 있으면 우선 사용, 패배 손실을 모르면 모델의 평균 손실
+	setTestMatchup(t, 10, 14, "", &BattleMatchup{WinRate: 50, AvgReward: 800, AvgLoss: 200, Model: "logistic"})
+	model := &battleTarget{Username: "@모델", Level: 14}
+	estimateBattle(my, model, nil, 0)
+	if math.Abs(model.BaseWinProb-0.5) > 1e-9 || math.Abs(model.Expected-300) > 1e-9 {
+		t.Errorf("matchup model: base %.3f expected %.1f, want 0.500 / 300", model.BaseWinProb, model.Expected)
+	}
+	estimateBattle(my, model, nil, 100)
+	if math.Abs(model.Expected-350) > 1e-9 {
+		t.Errorf("matchup model with session loss: expected %.1f, want 350", model.Expected)
+	}
+
+	// 통계 없는 레벨차
+	none := &battleTarget{Username: "@없음", Level: 20}
+	estimateBattle(my, none, nil, 100)
+	if none.BaseWinProb != 0 || none.Expected != 0 {
+		t.Errorf("no stats: %+v, want zero estimate", none)
+	}
+}
+
+func TestRankAndPickBattleTargets(t *testing.T) {
+	testBattleData(t)
+	my := &Profile{Level: 10}
+	targets := []*battleTarget{
+		{Username: "@c", Level: 20}, // 통계 없음
+		{Username: "@a", Level: 11}, // 0.4*500 = 200
+		{Username: "@b", Level: 12}, // 0.3*1000 = 300
+		{Username: "@d", Level: 13}, // 0.1*2000 = 200 (a와 같으면 낮은 레벨 우선)
+	}
+	rankBattleTargets(targets, my, &OpponentDB{opponents: make(map[string]*Opponent)}, 0)
+	var order []string
+	for _, tgt := range targets {
+		order = append(order, tgt.Username)
+	}
+	if want := []string{"@b", "@a", "@d", "@c"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("rank order = %v, want %v", order, want)
+	}
+
+	if got := pickBattleTarget(nil, nil, true); got != nil {
+		t.Errorf("pick from empty = %v, want nil", got)
+	}
+	h2h := map[string]*headToHead{"@b": {Battles: 2}, "@a": {Battles: 1}}
+	if got := pickBattleTarget(targets, h2h, false); got.Username != "@b" {
+		t.Errorf("pick without rotation = %s, want @b", got.Username)
+	}
+	// 순환: 이번 세션에 덜 싸운 타겟, 같으면 기대값 순
+	if got := pickBattleTarget(targets, h2h, true); got.Username != "@d" {
+		t.Errorf("pick with rotation = %s, want @d", got.Username)
+	}
+}
+
+func TestBinomialCDF(t *testing.T) {
+	cases := []struct {
+		k, n int
+		p    float64
+		want float64
+	}{
+		{0, 5, 0.5, 1.0 / 32},
+		{1, 5, 0.5, 6.0 / 32},
+		{5, 5, 0.5, 1},
+		{0, 10, 0.3, math.Pow(0.7, 10)},
+		{2, 4, 0.25, 0.31640625 + 0.421875 + 0.2109375},
+		{7, 5, 0.5, 1}, // k > n
+	}
+	for _, c := range cases {
+		if got := binomialCDF(c.k, c.n, c.p); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("binomialCDF(%d, %d, %.2f) = %.6f, want %.6f", c.k, c.n, c.p, got, c.want)
+		}
+	}
+}
+
+func TestConsistentlyBeatsUs(t *testing.T) {
+	cases := []struct {
+		name string
+		h2h  *headToHead
+		p    float64
+		want bool
+	}{
+		{"no record", nil, 0.5, false},
+		{"too few battles", &headToHead{Battles: 4, Losses: 4}, 0.9, false},
+		{"no win rate stats", &headToHead{Battles: 10, Losses: 10}, 0, false},
+		{"0/5 at 50%", &headToHead{Battles: 5, Losses: 5}, 0.5, true},           // 1/32 ≈ 0.031
+		{"1/5 at 50%", &headToHead{Battles: 5, Wins: 1, Losses: 4}, 0.5, false}, // 6/32 ≈ 0.19
+		{"0/5 at 30%", &headToHead{Battles: 5, Losses: 5}, 0.3, false},          // 0.7^5 ≈ 0.17
+		{"2/10 at 60%", &headToHead{Battles: 10, Wins: 2, Losses: 8}, 0.6, true},
+		{"expected losses", &headToHead{Battles: 10, Wins: 1, Losses: 9}, 0.1, false},
+	}
+	for _, c := range cases {
+		if got := consistentlyBeatsUs(c.h2h, c.p); got != c.want {
+			t.Errorf("%s: consistentlyBeatsUs = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -1,29 +1,55 @@
 package game
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/api"
 )
 
 // 캐시 (매 호출마다 HTTP 요청 방지)
 var (
 	gameDataCache     *GameData
 	gameDataCacheTime time.Time
+	gameDataETag      string // TTL 만료 후 If-None-Match로 보내 변경이 없으면 304로 재사용
 	gameDataMu        sync.Mutex
 	gameDataTTL       = 5 * time.Minute
 
 	optimalSellCache     *OptimalSellData
 	optimalSellCacheTime time.Time
+	optimalSellETag      string
 	optimalSellMu        sync.Mutex
 	optimalSellTTL       = 10 * time.Minute
+
+	specialStatsCache     *SpecialStats
+	specialStatsCacheTime time.Time
+	specialStatsETag      string
+	specialStatsMu        sync.Mutex
+	specialStatsTTL       = 10 * time.Minute
+
+	saleStatsCache     *SaleStats
+	saleStatsCacheTime time.Time
+	saleStatsETag      string
+	saleStatsMu        sync.Mutex
+	saleStatsTTL       = 10 * time.Minute
+
+	matchupCache = make(map[string]*matchupEntry) // "normal_10_12" → 예측
+	matchupMu    sync.Mutex
+	matchupTTL   = 10 * time.Minute
 )
 
 const (
-	gameDataEndpoint    = "https://sword-ai.stopdragon.kr/api/game-data"
-	optimalSellEndpoint = "https://sword-ai.stopdragon.kr/api/strategy/optimal-sell-point"
+	gameDataPath    = "/api/game-data"
+	optimalSellPath = "/api/strategy/optimal-sell-point"
+	matchupPath     = "/api/battle/matchup"
+	specialPath     = "/api/stats/special"
+	salesPath       = "/api/stats/sales"
 )
 
 // EnhanceRate 강화 확률 데이터 (레벨별)
@@ -112,8 +138,7 @@ func FetchGameData() (*GameData, error) {
 		return gameDataCache, nil
 	}
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(gameDataEndpoint)
+	resp, err := conditionalGet(gameDataPath, gameDataETag, gameDataCache != nil)
 	if err != nil {
 		if gameDataCache != nil {
 			return gameDataCache, nil // 실패 시 이전 캐시 반환
@@ -122,6 +147,10 @@ func FetchGameData() (*GameData, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && gameDataCache != nil {
+		gameDataCacheTime = time.Now()
+		return gameDataCache, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		if gameDataCache != nil {
 			return gameDataCache, nil
@@ -139,6 +168,7 @@ func FetchGameData() (*GameData, error) {
 
 	gameDataCache = &data
 	gameDataCacheTime = time.Now()
+	gameDataETag = resp.Header.Get("ETag")
 	return &data, nil
 }
 
@@ -326,8 +356,7 @@ func FetchOptimalSellData() (*OptimalSellData, error) {
 		return optimalSellCache, nil
 	}
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(optimalSellEndpoint)
+	resp, err := conditionalGet(optimalSellPath, optimalSellETag, optimalSellCache != nil)
 	if err != nil {
 		if optimalSellCache != nil {
 			return optimalSellCache, nil
@@ -336,6 +365,10 @@ func FetchOptimalSellData() (*OptimalSellData, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && optimalSellCache != nil {
+		optimalSellCacheTime = time.Now()
+		return optimalSellCache, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		if optimalSellCache != nil {
 			return optimalSellCache, nil
@@ -353,9 +386,240 @@ func FetchOptimalSellData() (*OptimalSellData, error) {
 
 	optimalSellCache = &data
 	optimalSellCacheTime = time.Now()
+	optimalSellETag = resp.Header.Get("ETag")
+	return &data, nil
+}
+
+// SpecialEntry 특수 아이템 이름별 출현 통계
+type SpecialEntry struct {
+	Name  string  `json:"name"`
+	Count int     `json:"count"`
+	Rate  float64 `json:"rate"` // 파밍 1회당 출현율 (%)
+}
+
+// SpecialStats 커뮤니티 특수 아이템 출현 통계
+type SpecialStats struct {
+	TotalFarming int            `json:"total_farming"`
+	Special      []SpecialEntry `json:"special"`
+}
+
+// FetchSpecialStats 서버에서 특수 아이템 이름별 출현 통계 가져오기 (TTL 캐시 적용)
+func FetchSpecialStats() (*SpecialStats, error) {
+	specialStatsMu.Lock()
+	defer specialStatsMu.Unlock()
+
+	if specialStatsCache != nil && time.Since(specialStatsCacheTime) < specialStatsTTL {
+		return specialStatsCache, nil
+	}
+
+	resp, err := conditionalGet(specialPath, specialStatsETag, specialStatsCache != nil)
+	if err != nil {
+		if specialStatsCache != nil {
+			return specialStatsCache, nil
+		}
+		return nil, fmt.Errorf("서버 연결 실패: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && specialStatsCache != nil {
+		specialStatsCacheTime = time.Now()
+		return specialStatsCache, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		if specialStatsCache != nil {
+			return specialStatsCache, nil
+		}
+		return nil, fmt.Errorf("서버 오류: %d", resp.StatusCode)
+	}
+
+	var data SpecialStats
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		if specialStatsCache != nil {
+			return specialStatsCache, nil
+		}
+		return nil, fmt.Errorf("데이터 파싱 실패: %v", err)
+	}
+
+	specialStatsCache = &data
+	specialStatsCacheTime = time.Now()
+	specialStatsETag = resp.Header.Get("ETag")
+	return &data, nil
+}
+
+// SaleEntry 타입+레벨별 판매 통계
+type SaleEntry struct {
+	Key      string `json:"key"` // "special_10"
+	Count    int    `json:"count"`
+	AvgPrice int    `json:"avg_price"`
+}
+
+// SaleStats 커뮤니티 판매 통계
+type SaleStats struct {
+	TotalSales int         `json:"total_sales"`
+	Sales      []SaleEntry `json:"sales"`
+}
+
+// AvgPrice 타입+레벨별 평균 판매가 (기록이 없으면 0)
+func (s *SaleStats) AvgPrice(itemType string, level int) (avg, count int) {
+	key := fmt.Sprintf("%s_%d", itemType, level)
+	for _, entry := range s.Sales {
+		if entry.Key == key {
+			return entry.AvgPrice, entry.Count
+		}
+	}
+	return 0, 0
+}
+
+// FetchSaleStats 서버에서 타입+레벨별 판매 통계 가져오기 (TTL 캐시 적용)
+func FetchSaleStats() (*SaleStats, error) {
+	saleStatsMu.Lock()
+	defer saleStatsMu.Unlock()
+
+	if saleStatsCache != nil && time.Since(saleStatsCacheTime) < saleStatsTTL {
+		return saleStatsCache, nil
+	}
+
+	resp, err := conditionalGet(salesPath, saleStatsETag, saleStatsCache != nil)
+	if err != nil {
+		if saleStatsCache != nil {
+			return saleStatsCache, nil
+		}
+		return nil, fmt.Errorf("서버 연결 실패: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && saleStatsCache != nil {
+		saleStatsCacheTime = time.Now()
+		return saleStatsCache, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		if saleStatsCache != nil {
+			return saleStatsCache, nil
+		}
+		return nil, fmt.Errorf("서버 오류: %d", resp.StatusCode)
+	}
+
+	var data SaleStats
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		if saleStatsCache != nil {
+			return saleStatsCache, nil
+		}
+		return nil, fmt.Errorf("데이터 파싱 실패: %v", err)
+	}
+
+	saleStatsCache = &data
+	saleStatsCacheTime = time.Now()
+	saleStatsETag = resp.Header.Get("ETag")
 	return &data, nil
 }
 
+// BattleMatchup 서버 배틀 승률 모델의 레벨 조합별 예측
+type BattleMatchup struct {
+	MyLevel        int     `json:"my_level"`
+	OppLevel       int     `json:"opp_level"`
+	SwordType      string  `json:"sword_type,omitempty"`
+	WinRate        float64 `json:"win_rate"`        // 예측 승률 (%)
+	AvgReward      int     `json:"avg_reward"`      // 승리 시 평균 보상
+	AvgLoss        int     `json:"avg_loss"`        // 패배 시 평균 손실
+	ExpectedReward float64 `json:"expected_reward"` // 배틀 1회 기대 골드
+	Samples        int     `json:"samples"`         // 이 조합의 실측 배틀 수
+	ModelSamples   int     `json:"model_samples"`   // 모델 학습 배틀 수
+	Model          string  `json:"model"`           // logistic, level_diff, none
+}
+
+// matchupEntry 조합별 캐시 (data가 nil이면 조회 실패 - TTL 동안 다시 묻지 않음)
+type matchupEntry struct {
+	data *BattleMatchup
+	etag string
+	at   time.Time
+}
+
+// FetchMatchup 서버에서 레벨 조합별 배틀 승률 / 기대 골드 조회 (조합별 TTL 캐시)
+// swordType: 내 검 타입 (normal/special, 모르면 빈 문자열)
+// 서버에 연결할 수 없거나 예측할 데이터가 없으면 nil
+// 요청 중에는 잠금을 풀어 다른 조합 조회가 느린 응답을 기다리지 않게 함
+func FetchMatchup(myLevel, oppLevel int, swordType string) *BattleMatchup {
+	key := fmt.Sprintf("%s_%d_%d", swordType, myLevel, oppLevel)
+
+	matchupMu.Lock()
+	cached := matchupCache[key]
+	if cached != nil && time.Since(cached.at) < matchupTTL {
+		matchupMu.Unlock()
+		return cached.data
+	}
+	// 요청 전에 시각을 갱신해 같은 조합의 동시 조회는 이전 값을 쓰게 함
+	entry := &matchupEntry{at: time.Now()}
+	if cached != nil {
+		entry.data, entry.etag = cached.data, cached.etag
+	}
+	matchupCache[key] = entry
+	prevData, prevETag := entry.data, entry.etag
+	matchupMu.Unlock()
+
+	path := fmt.Sprintf("%s?my_level=%d&opp_level=%d&sword_type=%s", matchupPath, myLevel, oppLevel, swordType)
+	data, etag, ok := requestMatchup(path, prevETag, prevData != nil)
+	if !ok {
+		return prevData // 실패 / 변경 없음이면 이전 캐시 반환
+	}
+
+	matchupMu.Lock()
+	defer matchupMu.Unlock()
+	entry.data, entry.etag = data, etag
+	return data
+}
+
+// requestMatchup 조합 예측 요청 (ok가 false면 실패했거나 변경 없음)
+// 예측할 데이터가 없으면 (model "none") data는 nil
+func requestMatchup(path, etag string, cached bool) (data *BattleMatchup, newETag string, ok bool) {
+	resp, err := conditionalGet(path, etag, cached)
+	if err != nil {
+		return nil, "", false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", false
+	}
+
+	var m BattleMatchup
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, "", false
+	}
+	if m.Model == "none" {
+		return nil, resp.Header.Get("ETag"), true
+	}
+	return &m, resp.Header.Get("ETag"), true
+}
+
+// conditionalGet GET 요청 (캐시가 있으면 If-None-Match로 변경 여부만 확인)
+// 로컬 모드면 로컬 저장소 집계를 직접 조회 (항상 200)
+func conditionalGet(path, etag string, cached bool) (*http.Response, error) {
+	if local := api.Local(); local != nil {
+		u, err := url.Parse(path)
+		if err != nil {
+			return nil, err
+		}
+		body, err := local.Query(u.Path, u.Query())
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(bytes.NewReader(body)),
+		}, nil
+	}
+
+	req, err := http.NewRequest("GET", api.URL(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached && etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	return api.Client(5 * time.Second).Do(req)
+}
+
 // GetOptimalSellLevel 서버 통계 기반 최적 판매 레벨 조회
 // 서버에서 커뮤니티 데이터 기반 추천값이 있으면 사용, 없으면 로컬 계산
 func GetOptimalSellLevel(currentGold int) (level int, source string) {
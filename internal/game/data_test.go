@@ -0,0 +1,188 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/api"
+)
+
+// setTestGameData 서버 대신 고정 게임 데이터 사용 (테스트가 끝나면 캐시 비움)
+func setTestGameData(t *testing.T, data *GameData) {
+	t.Helper()
+	gameDataMu.Lock()
+	gameDataCache, gameDataCacheTime = data, time.Now()
+	gameDataMu.Unlock()
+	t.Cleanup(func() {
+		gameDataMu.Lock()
+		gameDataCache, gameDataCacheTime = nil, time.Time{}
+		gameDataMu.Unlock()
+	})
+}
+
+// setTestMatchup 서버 대신 고정 승률 모델 예측 사용 (m이 nil이면 예측 없음)
+func setTestMatchup(t *testing.T, myLevel, oppLevel int, swordType string, m *BattleMatchup) {
+	t.Helper()
+	key := fmt.Sprintf("%s_%d_%d", swordType, myLevel, oppLevel)
+	matchupMu.Lock()
+	matchupCache[key] = &matchupEntry{data: m, at: time.Now()}
+	matchupMu.Unlock()
+	t.Cleanup(func() {
+		matchupMu.Lock()
+		delete(matchupCache, key)
+		matchupMu.Unlock()
+	})
+}
+
+// matchupBackend 조합별 고정 예측을 돌려주는 로컬 모드 저장소
+type matchupBackend struct {
+	matchups map[string]*BattleMatchup // "normal_10_12" → 응답 (없으면 model "none")
+	queries  []url.Values
+	fail     bool
+}
+
+func (b *matchupBackend) Ingest([]byte) error { return nil }
+func (b *matchupBackend) Close() error        { return nil }
+
+func (b *matchupBackend) Query(path string, params url.Values) ([]byte, error) {
+	if path != matchupPath {
+		return nil, fmt.Errorf("unexpected path %s", path)
+	}
+	b.queries = append(b.queries, params)
+	if b.fail {
+		return nil, errors.New("backend down")
+	}
+	key := params.Get("sword_type") + "_" + params.Get("my_level") + "_" + params.Get("opp_level")
+	if m := b.matchups[key]; m != nil {
+		return json.Marshal(m)
+	}
+	return json.Marshal(BattleMatchup{Model: "none"})
+}
+
+// useMatchupBackend 로컬 모드로 승률 모델 조회 (조합 캐시를 비우고 시작)
+func useMatchupBackend(t *testing.T, b *matchupBackend) {
+	t.Helper()
+	reset := func() {
+		matchupMu.Lock()
+		matchupCache = make(map[string]*matchupEntry)
+		matchupMu.Unlock()
+	}
+	reset()
+	api.UseLocal(b)
+	t.Cleanup(func() {
+		api.UseLocal(nil)
+		reset()
+	})
+}
+
+// expireMatchup 조합 캐시 만료 처리
+func expireMatchup(key string) {
+	matchupMu.Lock()
+	matchupCache[key].at = time.Now().Add(-matchupTTL - time.Second)
+	matchupMu.Unlock()
+}
+
+func TestFetchMatchupLocalBackend(t *testing.T) {
+	backend := &matchupBackend{matchups: map[string]*BattleMatchup{
+		"normal_10_12": {WinRate: 35, AvgReward: 1200, AvgLoss: 300, Samples: 40, Model: "logistic"},
+	}}
+	useMatchupBackend(t, backend)
+
+	m := FetchMatchup(10, 12, "normal")
+	if m == nil || m.WinRate != 35 || m.AvgReward != 1200 {
+		t.Fatalf("matchup = %+v, want backend prediction", m)
+	}
+	if q := backend.queries[0]; q.Get("my_level") != "10" || q.Get("opp_level") != "12" || q.Get("sword_type") != "normal" {
+		t.Errorf("query params = %v", q)
+	}
+
+	// TTL 안에서는 다시 묻지 않음, 예측 없음(model none)도 캐시
+	FetchMatchup(10, 12, "normal")
+	if m := FetchMatchup(10, 13, "normal"); m != nil {
+		t.Errorf("model none = %+v, want nil", m)
+	}
+	FetchMatchup(10, 13, "normal")
+	if len(backend.queries) != 2 {
+		t.Errorf("queries = %d, want 2 (one per combination)", len(backend.queries))
+	}
+
+	// 만료 후 조회 실패하면 이전 예측 유지
+	backend.fail = true
+	expireMatchup("normal_10_12")
+	if m := FetchMatchup(10, 12, "normal"); m == nil || m.WinRate != 35 {
+		t.Errorf("after failed refresh = %+v, want previous prediction", m)
+	}
+	if len(backend.queries) != 3 {
+		t.Errorf("queries = %d, want 3 (expired entry refreshed)", len(backend.queries))
+	}
+
+	// 만료 후 새 예측으로 교체
+	backend.fail = false
+	backend.matchups["normal_10_12"] = &BattleMatchup{WinRate: 40, AvgReward: 1200, Model: "logistic"}
+	expireMatchup("normal_10_12")
+	if m := FetchMatchup(10, 12, "normal"); m == nil || m.WinRate != 40 {
+		t.Errorf("after refresh = %+v, want updated prediction", m)
+	}
+}
+
+func TestEstimateBattleLocalBackend(t *testing.T) {
+	setTestGameData(t, &GameData{BattleRewards: []BattleReward{{LevelDiff: 3, WinRate: 10, AvgReward: 2000}}})
+	useMatchupBackend(t, &matchupBackend{matchups: map[string]*BattleMatchup{
+		"_10_12": {WinRate: 50, AvgReward: 800, AvgLoss: 200, Model: "logistic"},
+	}})
+	my := &Profile{Level: 10}
+
+	model := &battleTarget{Username: "@모델", Level: 12}
+	estimateBattle(my, model, nil, 0)
+	if math.Abs(model.BaseWinProb-0.5) > 1e-9 || math.Abs(model.Expected-300) > 1e-9 {
+		t.Errorf("matchup model: base %.3f expected %.1f, want 0.500 / 300", model.BaseWinProb, model.Expected)
+	}
+
+	// 모델 예측이 없으면 레벨차 통계
+	fallback := &battleTarget{Username: "@통계", Level: 13}
+	estimateBattle(my, fallback, nil, 100)
+	if math.Abs(fallback.BaseWinProb-0.1) > 1e-9 || math.Abs(fallback.Expected-110) > 1e-9 {
+		t.Errorf("level diff stats: base %.3f expected %.1f, want 0.100 / 110", fallback.BaseWinProb, fallback.Expected)
+	}
+}
+
+func TestUpsetRowLocalBackend(t *testing.T) {
+	setTestGameData(t, &GameData{BattleRewards: []BattleReward{
+		{LevelDiff: 1, WinRate: 40, AvgReward: 500},
+		{LevelDiff: 2, WinRate: 30, AvgReward: 1000},
+	}})
+	useMatchupBackend(t, &matchupBackend{matchups: map[string]*BattleMatchup{
+		"normal_10_11": {WinRate: 45, AvgReward: 600, AvgLoss: 200, Model: "logistic"},
+		"normal_10_12": {WinRate: 25, AvgReward: 900, Model: "logistic"},
+		"normal_10_13": {WinRate: 20, AvgReward: 900, Model: "level_diff"},
+	}})
+
+	cases := []struct {
+		diff   int
+		ok     bool
+		model  bool
+		winPct float64
+		ev     float64
+	}{
+		{1, true, true, 45, 0.45*600 - 0.55*200},  // 모델, 실측 평균 손실
+		{2, true, true, 25, 0.25*900 - 0.75*1000}, // 모델, 손실을 모르면 배팅 금액
+		{3, false, false, 0, 0},                   // 로지스틱이 아닌 예측은 무시, 레벨차 통계도 없음
+	}
+	for _, c := range cases {
+		row, ok := upsetRow(10, c.diff, 1000, "normal")
+		if ok != c.ok || row.model != c.model || row.winRate != c.winPct || math.Abs(row.ev-c.ev) > 1e-9 {
+			t.Errorf("diff %d: row %+v ok %v, want model %v win %.0f ev %.1f ok %v", c.diff, row, ok, c.model, c.winPct, c.ev, c.ok)
+		}
+	}
+
+	// 모델 예측이 없으면 레벨차 통계 (패배 시 배팅 금액 손실)
+	row, ok := upsetRow(10, 1, 1000, "special")
+	if !ok || row.model || row.winRate != 40 || math.Abs(row.ev-(0.4*500-0.6*1000)) > 1e-9 {
+		t.Errorf("fallback row %+v ok %v, want level diff stats", row, ok)
+	}
+}
@@ -52,9 +52,20 @@ type Engine struct {
 	stopTimer *time.Timer
 
 	// 배틀 상태
-	myProfile    *Profile
-	battleWins   int
-	battleLosses int
+	myProfile      *Profile
+	battleWins     int
+	battleLosses   int
+	battleLossGold int                    // 패배로 잃은 골드 합계 (기대값 계산용)
+	battleH2H      map[string]*headToHead // 이번 세션 상대별 전적
+	opponents      *OpponentDB            // 다른 유저 정보 (실행 간 유지)
+	battleQuota    *BattleQuota           // 오늘 사용한 배틀 수 (실행 간 유지)
+	inventory      *SpecialInventory      // 보관한 특수 아이템 (실행 간 유지)
+
+	// /프로필 재동기화 (resync.go)
+	resyncReason       string // 다음 /강화 전에 재동기화할 이유 (비어 있으면 요청 없음)
+	actionsSinceResync int    // 마지막 재동기화 이후 /강화 횟수
+
+	chatWatch chatWatchdog // 채팅 읽기 장애 감시 (watchdog.go)
 
 	// 핫키
 	hotkeyMgr *input.HotkeyManager
@@ -81,14 +92,22 @@ type Engine struct {
 		cycleGoldSum    int     // 사이클 수익 합계
 		consecutiveFails    int // 현재 연속 실패(유지) 횟수
 		maxConsecutiveFails int // 세션 최대 연속 실패 횟수
+		keptCount           int // 이번 세션에 보관한 특수 수
+		keptValue           int // 이번 세션에 보관한 특수 추정 가치
+		resyncs             int // /프로필 재동기화 횟수
+		drifts              int // 재동기화에서 내부 상태가 실제와 달랐던 횟수
+		chatIncidents       int // 채팅 읽기 장애 (복구 단계 진입) 횟수
 	}
 }
 
 // NewEngine 엔진 생성
 func NewEngine(cfg *config.Config, telem *telemetry.Telemetry) *Engine {
 	e := &Engine{
-		cfg:   cfg,
-		telem: telem,
+		cfg:         cfg,
+		telem:       telem,
+		opponents:   LoadOpponentDB(),
+		battleQuota: LoadBattleQuota(),
+		inventory:   LoadSpecialInventory(),
 	}
 
 	// 핫키 설정
@@ -218,6 +237,14 @@ func (e *Engine) runSpecialMode(reader *bufio.Reader) {
 		}
 	}
 
+	// 찾을 특수 이름 / 판매할 특수 이름 (설정에 저장)
+	fmt.Println()
+	e.cfg.SpecialWanted = promptNameList(reader, "찾을 특수 이름 (예: 광선검, 오페라)", e.cfg.SpecialWanted, "모든 특수")
+	e.cfg.SpecialSell = promptNameList(reader, "보관하지 않고 판매할 특수 이름", e.cfg.SpecialSell, "없음")
+	e.cfg.Save()
+	fmt.Println()
+	e.printSpecialHuntPlan()
+
 	e.targetLevel = targetLevel
 	e.mode = ModeSpecial
 	e.setupAndRun()
@@ -406,6 +433,16 @@ func (e *Engine) runGoldMineMode() {
 func (e *Engine) runBattleMode(reader *bufio.Reader) {
 	fmt.Println()
 	fmt.Println("=== 자동 배틀 설정 ===")
+
+	now := time.Now()
+	remaining := e.battleQuota.Remaining(now)
+	if remaining == 0 {
+		fmt.Printf("⏰ 오늘 배틀 횟수를 모두 사용했습니다 (%d회/일)\n", dailyBattleLimit)
+		fmt.Printf("   다음 초기화까지 %s\n", formatDuration(untilBattleReset(now)))
+		return
+	}
+	fmt.Printf("남은 배틀: %d/%d회 (초기화까지 %s)\n",
+		remaining, dailyBattleLimit, formatDuration(untilBattleReset(now)))
 	fmt.Printf("현재 역배 레벨 차이: %d (내 레벨 +1 ~ +%d 상대와 대결)\n",
 		e.cfg.BattleLevelDiff, e.cfg.BattleLevelDiff)
 
@@ -497,6 +534,7 @@ func (e *Engine) setupAndRun() {
 
 			// 텔레메트리에 프로필 정보 전송
 			e.telem.RecordProfile(e.sessionProfile.Name, e.sessionProfile.Level, e.sessionProfile.Gold)
+			e.reconcileKeptSpecials(e.sessionProfile.SwordName, e.sessionProfile.Level)
 		}
 	}
 
@@ -528,6 +566,14 @@ func (e *Engine) setupAndRun() {
 	e.sessionStats.enhanceDestroy = 0
 	e.sessionStats.cycleTimeSum = 0
 	e.sessionStats.cycleGoldSum = 0
+	e.sessionStats.keptCount = 0
+	e.sessionStats.keptValue = 0
+	e.sessionStats.resyncs = 0
+	e.sessionStats.drifts = 0
+	e.resyncReason = ""
+	e.actionsSinceResync = 0
+	e.sessionStats.chatIncidents = 0
+	e.chatWatch = chatWatchdog{}
 
 	// 타이머 설정 (시간 제한이 있는 경우)
 	if e.duration > 0 {
@@ -586,6 +632,9 @@ func (e *Engine) setupAndRun() {
 	// 상세 통계 출력
 	e.printSessionStats()
 
+	// 아직 저장하지 않은 아이템 카탈로그 관찰 기록
+	SaveItemCatalog()
+
 	// 텔레메트리 전송
 	fmt.Println("📤 통계 전송 중...")
 	e.telem.Flush()
@@ -617,10 +666,13 @@ func (e *Engine) printSessionStats() {
 		goldDiff = e.totalGold // 시작 골드를 못 읽었으면 누적 수익 사용
 	}
 
-	// 시간당 골드 계산
+	// 순자산 변화 = 골드 변화 + 이번 세션에 보관한 특수 추정 가치
+	netWorthDiff := goldDiff + e.sessionStats.keptValue
+
+	// 시간당 골드 계산 (보관한 특수 포함)
 	goldPerHour := 0
 	if elapsedSec > 0 {
-		goldPerHour = int(float64(goldDiff) / elapsedSec * 3600)
+		goldPerHour = int(float64(netWorthDiff) / elapsedSec * 3600)
 	}
 
 	// 사이클 평균 계산
@@ -662,6 +714,12 @@ func (e *Engine) printSessionStats() {
 			fmt.Printf("  🔥 최대 연속유지: %d회\n", e.sessionStats.maxConsecutiveFails)
 		}
 	}
+	if e.sessionStats.resyncs > 0 {
+		fmt.Printf("  🔁 상태 재동기화: %d회 (보정 %d회)\n", e.sessionStats.resyncs, e.sessionStats.drifts)
+	}
+	if e.sessionStats.chatIncidents > 0 {
+		fmt.Printf("  🩺 채팅 읽기 장애: %d회\n", e.sessionStats.chatIncidents)
+	}
 
 	// 배틀 통계
 	if e.battleWins > 0 || e.battleLosses > 0 {
@@ -683,6 +741,14 @@ func (e *Engine) printSessionStats() {
 	} else if e.totalGold != 0 {
 		fmt.Printf("  💰 총 수익:     %s%sG\n", goldSign, FormatGold(goldDiff))
 	}
+	if e.sessionStats.keptCount > 0 {
+		fmt.Printf("  📦 보관 특수:   %d개 (추정 +%sG)\n", e.sessionStats.keptCount, FormatGold(e.sessionStats.keptValue))
+		netSign := "+"
+		if netWorthDiff < 0 {
+			netSign = ""
+		}
+		fmt.Printf("  💎 순자산 변화: %s%sG\n", netSign, FormatGold(netWorthDiff))
+	}
 
 	fmt.Printf("  📈 시간당 골드: %s%sG/h\n", gphSign, FormatGold(goldPerHour))
 
@@ -741,10 +807,19 @@ func (e *Engine) loopEnhance() {
 	e.ResetLastChatText()
 
 	for e.running {
+		e.chatWatchdogTick()
 		if e.checkStop() {
 			return
 		}
 
+		// 상태 재동기화 (주기 / 불확실한 결과 / 레벨 점프 후)
+		if profile, _ := e.resyncIfNeeded(currentLevel, swordName); profile.OK {
+			currentLevel = profile.Level
+			if profile.SwordName != "" {
+				swordName = profile.SwordName
+			}
+		}
+
 		// 목표 달성 확인
 		if e.IsTargetReached(currentLevel) {
 			fmt.Printf("\n🎉 목표 달성! +%d\n", currentLevel)
@@ -757,6 +832,7 @@ func (e *Engine) loopEnhance() {
 		// 강화 명령
 		overlay.UpdateStatus("⚔️ 강화 중\n현재: +%d → 목표: +%d\n\n📋 판단: /강화 실행", currentLevel, e.targetLevel)
 		e.sendCommand("/강화")
+		e.countEnhanceAction()
 		delay := e.getDelayForLevel(currentLevel)
 		time.Sleep(delay)
 
@@ -775,6 +851,7 @@ func (e *Engine) loopEnhance() {
 		}
 
 		if text == "" {
+			e.requestResync("응답 없음")
 			continue
 		}
 
@@ -802,6 +879,7 @@ func (e *Engine) loopEnhance() {
 			e.sessionStats.consecutiveFails = 0
 			e.enhanceConsecutiveFails = 0
 			e.telem.RecordEnhanceWithType(itemType, currentLevel, "destroy")
+			e.dropKeptSpecial(swordName, "파괴")
 			fmt.Printf("  💥 +%d에서 파괴!\n", currentLevel)
 			overlay.UpdateStatus("⚔️ 강화 중\n💥 +%d 파괴!\n\n📋 판단: 새 검으로 재시작", currentLevel)
 
@@ -822,10 +900,12 @@ func (e *Engine) loopEnhance() {
 				currentLevel = state.ResultLevel
 			} else {
 				currentLevel++
+				e.requestResync("결과 레벨 없음")
 			}
 			// 이중 강화 감지: 레벨이 2 이상 점프하면 의심스러움
 			if currentLevel > prevLevel+1 {
 				logger.Error("의심스러운 레벨 점프: +%d → +%d (예상: +%d)", prevLevel, currentLevel, prevLevel+1)
+				e.requestResync("레벨 점프")
 			}
 			e.telem.RecordEnhanceWithType(itemType, currentLevel-1, "success")
 			fmt.Printf("  ⚔️ 강화 성공! +%d\n", currentLevel)
@@ -856,6 +936,7 @@ func (e *Engine) loopEnhance() {
 			if state.ResultLevel > 0 && state.ResultLevel != currentLevel {
 				currentLevel = state.ResultLevel
 			}
+			e.requestResync("결과 불명확")
 		}
 	}
 }
@@ -866,6 +947,9 @@ func (e *Engine) loopSpecial() {
 	if e.targetLevel > 0 {
 		targetStr = fmt.Sprintf("+%d까지 강화", e.targetLevel)
 	}
+	if len(e.cfg.SpecialWanted) > 0 {
+		targetStr = nameListLabel(e.cfg.SpecialWanted, "") + " " + targetStr
+	}
 	overlay.UpdateStatus("⭐ 특수 아이템 뽑기\n목표: %s\n\n📋 프로필 확인 중...", targetStr)
 
 	// 시작 시 프로필 정보 표시 (Run()에서 이미 조회한 sessionProfile 사용)
@@ -881,6 +965,7 @@ func (e *Engine) loopSpecial() {
 	const maxRetries = 3
 
 	for e.running {
+		e.chatWatchdogTick()
 		if e.checkStop() {
 			return
 		}
@@ -962,7 +1047,7 @@ func (e *Engine) loopSpecial() {
 
 		// 2. 강화 결과 확인 - 파괴되었으면 새 아이템 받음, 다음 루프
 		if state.LastResult == "destroy" {
-			e.telem.RecordFarmingWithItem(itemName, state.ItemType)
+			e.recordFarming(itemName, state.ItemType, state.Announced)
 			e.sessionStats.trashCount++
 			fmt.Printf("  💥 파괴됨 [%s] → 새 아이템 대기\n", itemName)
 			overlay.UpdateStatus("⭐ 특수 아이템 뽑기\n쓰레기: %d회\n💥 파괴 → 새 아이템", e.sessionStats.trashCount)
@@ -970,14 +1055,20 @@ func (e *Engine) loopSpecial() {
 			continue
 		}
 
+		// 찾는 특수가 아니면 (판매 목록 / 찾는 목록 밖) 일반처럼 판매하고 계속 뽑기
+		sellSpecial := state.ItemType == "special" && !e.keepSpecial(itemName)
+		if sellSpecial {
+			fmt.Printf("  ⭐ 특수 [%s] - 찾는 아이템 아님\n", itemName)
+		}
+
 		// 3. 특수면 성공!
-		if state.ItemType == "special" {
+		if state.ItemType == "special" && !sellSpecial {
 			overlay.UpdateStatus("⭐ 특수 아이템 뽑기\n🎉 특수 발견!\n[%s]\n\n📋 판단: 특수 → 보관/강화", itemName)
 			fmt.Printf("\n🎉 특수 아이템 발견! [%s]\n", itemName)
 			logger.Info("특수 아이템 발견: %s", itemName)
 
 			// 텔레메트리: 특수 아이템 발견 즉시 전송
-			e.telem.RecordFarmingWithItem(itemName, "special")
+			e.recordFarming(itemName, "special", state.Announced)
 			e.telem.RecordSword()
 			e.telem.TrySend()
 			e.sessionStats.specialCount++
@@ -991,6 +1082,7 @@ func (e *Engine) loopSpecial() {
 				if e.IsTargetReached(currentLevel) {
 					fmt.Printf("✅ 이미 목표 달성! [%s] +%d\n", itemName, currentLevel)
 					overlay.UpdateStatus("⭐ 특수 강화 완료!\n[%s] +%d", itemName, currentLevel)
+					e.recordKeptSpecial(itemName, currentLevel, "special", true)
 					e.telem.TrySend()
 					return
 				}
@@ -1010,6 +1102,7 @@ func (e *Engine) loopSpecial() {
 				if result.Success {
 					fmt.Printf("✅ 강화 완료! [%s] +%d\n", itemName, result.FinalLevel)
 					overlay.UpdateStatus("⭐ 특수 강화 완료!\n[%s] +%d", itemName, result.FinalLevel)
+					e.recordKeptSpecial(itemName, result.FinalLevel, "special", true)
 					e.telem.TrySend()
 					return // 목표 달성 → 종료
 				} else if result.Destroyed {
@@ -1019,6 +1112,13 @@ func (e *Engine) loopSpecial() {
 					e.telem.TrySend()
 					time.Sleep(time.Duration(e.cfg.TrashDelay * float64(time.Second)))
 					continue // 루프 계속 → 특수 아이템 다시 찾기
+				} else if result.StopReason != "" {
+					// 강화 중단 정책 → 현재 레벨로 보관하고 종료
+					fmt.Printf("🛑 강화 중단 (%s) → [%s] +%d 보관\n", result.StopReason, itemName, result.FinalLevel)
+					overlay.UpdateStatus("🛑 강화 중단\n[%s] +%d\n%s", itemName, result.FinalLevel, result.StopReason)
+					e.recordKeptSpecial(itemName, result.FinalLevel, "special", true)
+					e.telem.TrySend()
+					return
 				} else {
 					// 골드 부족 또는 사용자 중지 → 종료 (무한 루프 방지)
 					fmt.Printf("⚠️ 강화 중단됨 (레벨: +%d) → 종료\n", result.FinalLevel)
@@ -1030,14 +1130,15 @@ func (e *Engine) loopSpecial() {
 				// 강화 목표 없으면 (보관만) 바로 종료
 				fmt.Printf("✅ 특수 아이템 보관 완료! [%s]\n", itemName)
 				overlay.UpdateStatus("⭐ 특수 보관 완료!\n[%s]", itemName)
+				e.recordKeptSpecial(itemName, e.ExtractCurrentLevel(state), "special", true)
 				e.telem.TrySend()
 				return
 			}
 		}
 
-		// 4. 쓰레기/일반/미판별이면 /판매로 새 아이템 받기 (v3 변경점)
+		// 4. 쓰레기/일반/미판별/찾지 않는 특수면 /판매로 새 아이템 받기 (v3 변경점)
 		// "none"도 포함: 타입 판별 실패 시 계속 강화하면 안되므로 판매 처리
-		if state.ItemType == "trash" || state.ItemType == "normal" || state.ItemType == "unknown" || state.ItemType == "none" {
+		if sellSpecial || state.ItemType == "trash" || state.ItemType == "normal" || state.ItemType == "unknown" || state.ItemType == "none" {
 			// 현재 레벨 추출
 			saleLevel := e.ExtractCurrentLevel(state)
 
@@ -1049,7 +1150,7 @@ func (e *Engine) loopSpecial() {
 				continue
 			}
 
-			e.telem.RecordFarmingWithItem(itemName, state.ItemType)
+			e.recordFarming(itemName, state.ItemType, state.Announced)
 			e.sessionStats.trashCount++
 			displayName := itemName
 			if displayName == "" {
@@ -1065,6 +1166,8 @@ func (e *Engine) loopSpecial() {
 			// 판매 통계 기록 (타입+레벨별)
 			if saleResult := ExtractSaleResult(saleText); saleResult != nil && saleResult.SaleGold > 0 {
 				e.telem.RecordSaleWithType(state.ItemType, saleLevel, saleResult.SaleGold)
+				catalog().ObserveSale(itemName, saleLevel, saleResult.SaleGold)
+				e.dropKeptSpecial(itemName, "판매")
 			}
 			time.Sleep(time.Duration(e.cfg.TrashDelay * float64(time.Second)))
 			continue
@@ -1141,6 +1244,8 @@ func (e *Engine) loopGoldMine() {
 			if itemType == "special" {
 				fmt.Printf("✅ 목표 달성! 특수 아이템 [%s] +%d (목표 +%d) → 보관\n", e.sessionProfile.SwordName, e.sessionProfile.Level, specialTarget)
 				overlay.UpdateStatus("💰 골드 채굴\n✅ 특수 +%d 보관!", e.sessionProfile.Level)
+				// 세션 시작 전부터 보유한 검이므로 세션 순자산에는 넣지 않음
+				e.recordKeptSpecial(e.sessionProfile.SwordName, e.sessionProfile.Level, "goldmine", false)
 				e.telem.TrySend()
 				return // 특수 아이템은 판매하지 않음
 			}
@@ -1195,6 +1300,7 @@ func (e *Engine) loopGoldMine() {
 	}
 
 	for e.running {
+		e.chatWatchdogTick()
 		if e.checkStop() {
 			return
 		}
@@ -1276,7 +1382,13 @@ func (e *Engine) loopGoldMine() {
 			result := e.EnhanceToTarget(itemName, itemLevel)
 			e.targetLevel = originalTarget // 원래 목표 레벨 복원
 
-			if !result.Success {
+			// 강화 중단 정책으로 멈췄으면 현재 레벨로 바로 판매
+			policyStop := result.StopReason != "" && result.FinalLevel > 0
+			if policyStop {
+				fmt.Printf("🛑 목표 전 판매: %s +%d (%s)\n", itemName, result.FinalLevel, result.StopReason)
+			}
+
+			if !result.Success && !policyStop {
 				if result.Destroyed {
 					fmt.Printf("💥 강화 중 파괴: %s (최종 +%d)\n", itemName, result.FinalLevel)
 
@@ -1358,6 +1470,8 @@ func (e *Engine) loopGoldMine() {
 		// v3 텔레메트리 기록 (공통 헬퍼 사용) - 서버에는 판매 수익 보고
 		// itemType으로 전달 (타입별 가격 통계: "normal_10", "special_10" 등)
 		e.ReportGoldMineCycle(itemType, finalLevel, saleGold, currentGold, enhanceCost, cycleTime.Seconds())
+		catalog().ObserveSale(itemName, finalLevel, saleGold)
+		e.dropKeptSpecial(itemName, "판매")
 
 		// 세션 통계 업데이트 - 순수익 기준
 		e.sessionStats.cycleTimeSum += cycleTime.Seconds()
@@ -1397,14 +1511,23 @@ func (e *Engine) loopBattle() {
 	e.telem.InitSession(startGold)
 
 	// 적합한 타겟 목록 (배틀 루프 밖에서 유지, 소진되면 다시 조회)
-	var candidates []*RankingEntry
+	var candidates []*battleTarget
+	e.battleH2H = make(map[string]*headToHead)
+	e.battleLossGold = 0
 
 	// 배틀 루프
 	for e.running {
+		e.chatWatchdogTick()
 		if e.checkStop() {
 			return
 		}
 
+		// 오늘 횟수를 다 썼으면 배틀을 보내지 않고 종료
+		if e.battleQuota.Remaining(time.Now()) == 0 {
+			e.finishBattleQuota()
+			return
+		}
+
 		e.cycleCount++
 
 		// 타겟 목록이 비었으면 새로 조회
@@ -1418,6 +1541,7 @@ func (e *Engine) loopBattle() {
 			rankingText := e.waitForResponseRaw(5 * time.Second)
 			entries := ParseRanking(rankingText)
 			usernames := ExtractUsernamesFromRanking(entries)
+			e.opponents.ObserveRanking(entries, e.myProfile.Name)
 
 			if len(usernames) == 0 {
 				fmt.Println("⏳ 랭킹에서 유저를 찾을 수 없음, 30초 후 재시도...")
@@ -1442,26 +1566,43 @@ func (e *Engine) loopBattle() {
 				if username == e.myProfile.Name {
 					continue
 				}
+				if e.isBattleBlacklisted(username) {
+					fmt.Printf("   🚫 %s: 블랙리스트\n", username)
+					continue
+				}
+				if e.battleLossCapReached(username) {
+					fmt.Printf("   🚫 %s: 이번 세션 패배 한도 도달\n", username)
+					continue
+				}
 
-				profile := e.CheckOtherProfile(username)
+				// 최근에 확인한 유저는 저장된 정보 사용 (오래된 유저만 /프로필 조회)
+				cacheNote := " (저장된 정보)"
+				var profile *Profile
+				if o := e.opponents.Fresh(username, e.opponentTTL()); o != nil {
+					profile = o.profile()
+				} else {
+					cacheNote = ""
+					profile = e.CheckOtherProfile(username)
+					time.Sleep(1 * time.Second) // 프로필 조회 간격
+				}
 				if profile == nil || profile.Level <= 0 {
-					fmt.Printf("   ⚠️ %s: 프로필 조회 실패 또는 0레벨\n", username)
-					time.Sleep(1 * time.Second)
+					fmt.Printf("   ⚠️ %s: 프로필 조회 실패 또는 0레벨%s\n", username, cacheNote)
 					continue
 				}
 
 				if profile.Level >= minTarget && profile.Level <= maxTarget {
-					candidates = append(candidates, &RankingEntry{
+					candidates = append(candidates, &battleTarget{
 						Username: username,
 						Level:    profile.Level,
+						Wins:     profile.Wins,
+						Losses:   profile.Losses,
 					})
-					fmt.Printf("   ✅ %s: +%d (적합!)\n", username, profile.Level)
+					fmt.Printf("   ✅ %s: +%d (적합!)%s\n", username, profile.Level, cacheNote)
 				} else {
-					fmt.Printf("   ❌ %s: +%d (범위 외)\n", username, profile.Level)
+					fmt.Printf("   ❌ %s: +%d (범위 외)%s\n", username, profile.Level, cacheNote)
 				}
-
-				time.Sleep(1 * time.Second) // 프로필 조회 간격
 			}
+			e.opponents.Save()
 
 			if len(candidates) == 0 {
 				fmt.Println("⏳ 적합한 타겟 없음, 30초 후 재시도...")
@@ -1472,17 +1613,13 @@ func (e *Engine) loopBattle() {
 			}
 
 			fmt.Printf("📋 적합한 타겟 %d명 발견\n", len(candidates))
+			rankBattleTargets(candidates, e.myProfile, e.opponents, e.avgBattleLoss())
+			printBattleTargets(candidates)
 		}
 
-		// 적합한 타겟 중 가장 레벨이 낮은 타겟 선택 (역배 확률 최대화)
-		// 같은 타겟을 계속 사용 (제거하지 않음)
-		var target *RankingEntry
-		target = candidates[0]
-		for _, c := range candidates[1:] {
-			if c.Level < target.Level {
-				target = c
-			}
-		}
+		// 기대 골드가 가장 높은 타겟 선택 (순환 설정 시 덜 싸운 타겟 우선)
+		rankBattleTargets(candidates, e.myProfile, e.opponents, e.avgBattleLoss())
+		target := pickBattleTarget(candidates, e.battleH2H, e.cfg.BattleRotate)
 
 		// 4. 타겟과 배틀
 		// 승률 계산
@@ -1493,9 +1630,13 @@ func (e *Engine) loopBattle() {
 
 		fmt.Printf("⚔️ #%d: %s (+%d) vs 나 (+%d) [%s]\n",
 			e.cycleCount, target.Username, target.Level, e.myProfile.Level, e.myProfile.SwordName)
-		overlay.UpdateStatus("⚔️ 자동 배틀 #%d\n타겟: %s +%d\n내 레벨: +%d\n\n💰 수익: %sG\n📊 승률: %.1f%% (%d승 %d패)",
+		if target.BaseWinProb > 0 {
+			fmt.Printf("   📈 추정 승률 %.1f%% | 기대 %+.0fG\n", target.WinProb*100, target.Expected)
+		}
+		overlay.UpdateStatus("⚔️ 자동 배틀 #%d\n타겟: %s +%d\n내 레벨: +%d\n\n💰 수익: %sG\n📊 승률: %.1f%% (%d승 %d패)\n🎫 남은 배틀: %d/%d",
 			e.cycleCount, target.Username, target.Level, e.myProfile.Level,
-			FormatGold(e.totalGold), winRate, e.battleWins, e.battleLosses)
+			FormatGold(e.totalGold), winRate, e.battleWins, e.battleLosses,
+			e.battleQuota.Remaining(time.Now()), dailyBattleLimit)
 
 		e.SaveLastChatText()
 		// 배틀 명령어는 다단계로 전송 (카카오톡 인식 안정성)
@@ -1527,44 +1668,28 @@ func (e *Engine) loopBattle() {
 		// 상대방 0강 감지 → 해당 타겟 제거 후 다음 타겟으로
 		if DetectBattleZeroLevel(resultText) {
 			fmt.Printf("   ⚠️ %s: 상대 검이 0강 → 타겟에서 제거\n", target.Username)
-			// candidates에서 해당 타겟 제거
-			for i, c := range candidates {
-				if c.Username == target.Username {
-					candidates = append(candidates[:i], candidates[i+1:]...)
-					break
-				}
-			}
+			candidates = removeBattleTarget(candidates, target.Username)
 			time.Sleep(1 * time.Second)
 			continue
 		}
 
-		// 배틀 횟수 제한 확인 (하루 10회 제한)
+		// 배틀 횟수 제한 확인 (하루 10회 제한, 저장된 횟수가 실제와 달랐으면 여기서 맞춰짐)
 		if DetectBattleLimit(resultText) {
-			// 최종 승률 계산
-			finalWinRate := 0.0
-			if e.battleWins+e.battleLosses > 0 {
-				finalWinRate = float64(e.battleWins) / float64(e.battleWins+e.battleLosses) * 100
-			}
-
-			fmt.Println()
-			fmt.Println("════════════════════════════════════════")
-			fmt.Println("⏰ 오늘 배틀 횟수를 모두 사용했습니다 (10회/일)")
-			fmt.Println("════════════════════════════════════════")
-			fmt.Printf("📊 최종 전적: %d승 %d패 (승률 %.1f%%)\n", e.battleWins, e.battleLosses, finalWinRate)
-			fmt.Printf("💰 총 수익: %sG\n", FormatGold(e.totalGold))
-			fmt.Println("════════════════════════════════════════")
-			fmt.Println()
-			fmt.Println("엔터를 누르면 메뉴로 돌아갑니다...")
-
-			overlay.UpdateStatus("⚔️ 자동 배틀 완료\n⏰ 일일 배틀 제한 도달\n\n📊 전적: %d승 %d패\n📈 승률: %.1f%%\n💰 총 수익: %sG",
-				e.battleWins, e.battleLosses, finalWinRate, FormatGold(e.totalGold))
-
-			// 사용자 입력 대기 후 메뉴 복귀
-			fmt.Scanln()
+			e.battleQuota.Exhaust(time.Now())
+			e.finishBattleQuota()
 			return
 		}
 
 		result := ParseBattleResult(resultText, e.myProfile.Name)
+		// 승자와 패자를 모두 읽은 결과만 실제 배틀로 인정 (다른 응답을 잘못 읽은 경우 횟수 / 전적 / 텔레메트리 / 상대 DB에 반영하지 않음)
+		if result.Winner == "" || result.Loser == "" {
+			fmt.Println("   ⚠️ 배틀 결과를 해석할 수 없음, 스킵")
+			logger.Info("[배틀] 결과 해석 실패 (@%s), 기록 생략", target.Username)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+		e.battleQuota.Use(time.Now())
+		quotaLeft := e.battleQuota.Remaining(time.Now())
 
 		goldChange := 0
 		if result.Won {
@@ -1576,8 +1701,9 @@ func (e *Engine) loopBattle() {
 			winRate = float64(e.battleWins) / float64(e.battleWins+e.battleLosses) * 100
 
 			fmt.Printf("   → 🏆 승리! +%sG (역배 성공!)\n", FormatGold(goldChange))
-			overlay.UpdateStatus("⚔️ 자동 배틀\n🏆 승리! +%sG\n\n💰 수익: %sG\n📊 승률: %.1f%% (%d승 %d패)",
-				FormatGold(goldChange), FormatGold(e.totalGold), winRate, e.battleWins, e.battleLosses)
+			overlay.UpdateStatus("⚔️ 자동 배틀\n🏆 승리! +%sG\n\n💰 수익: %sG\n📊 승률: %.1f%% (%d승 %d패)\n🎫 남은 배틀: %d/%d",
+				FormatGold(goldChange), FormatGold(e.totalGold), winRate, e.battleWins, e.battleLosses,
+				quotaLeft, dailyBattleLimit)
 		} else {
 			e.battleLosses++
 
@@ -1585,6 +1711,7 @@ func (e *Engine) loopBattle() {
 			if result.GoldEarned > 0 {
 				goldChange = -result.GoldEarned
 				e.totalGold -= result.GoldEarned
+				e.battleLossGold += result.GoldEarned
 			}
 
 			// 승률 업데이트
@@ -1595,8 +1722,9 @@ func (e *Engine) loopBattle() {
 			} else {
 				fmt.Println("   → 💔 패배...")
 			}
-			overlay.UpdateStatus("⚔️ 자동 배틀\n💔 패배...\n\n💰 수익: %sG\n📊 승률: %.1f%% (%d승 %d패)",
-				FormatGold(e.totalGold), winRate, e.battleWins, e.battleLosses)
+			overlay.UpdateStatus("⚔️ 자동 배틀\n💔 패배...\n\n💰 수익: %sG\n📊 승률: %.1f%% (%d승 %d패)\n🎫 남은 배틀: %d/%d",
+				FormatGold(e.totalGold), winRate, e.battleWins, e.battleLosses,
+				quotaLeft, dailyBattleLimit)
 		}
 
 		// 5. v3 텔레메트리 기록 (공통 헬퍼 사용) - goldChange는 승리 시 양수, 패배 시 음수
@@ -1606,13 +1734,45 @@ func (e *Engine) loopBattle() {
 		// 6. 현재 통계 출력 (공통 헬퍼 사용)
 		PrintBattleStats(e.battleWins, e.battleLosses, e.totalGold)
 
-		// 7. 프로필 갱신은 생략 (같은 타겟 계속 사용하므로 불필요)
+		// 7. 상대 DB / 세션 전적 반영 (패배 한도 / 블랙리스트 판정)
+		e.observeOwnBattle(result, target)
+		if e.recordBattleAgainst(target, result.Won) {
+			candidates = removeBattleTarget(candidates, target.Username)
+		}
+
+		// 프로필 갱신은 생략 (같은 타겟 계속 사용하므로 불필요)
 
 		// 8. 쿨다운
 		time.Sleep(time.Duration(e.cfg.BattleCooldown * float64(time.Second)))
 	}
 }
 
+// finishBattleQuota 일일 배틀 제한 도달 시 최종 전적 출력 후 입력 대기 (메뉴 복귀 전)
+func (e *Engine) finishBattleQuota() {
+	finalWinRate := 0.0
+	if e.battleWins+e.battleLosses > 0 {
+		finalWinRate = float64(e.battleWins) / float64(e.battleWins+e.battleLosses) * 100
+	}
+	untilReset := formatDuration(untilBattleReset(time.Now()))
+
+	fmt.Println()
+	fmt.Println("════════════════════════════════════════")
+	fmt.Printf("⏰ 오늘 배틀 횟수를 모두 사용했습니다 (%d회/일)\n", dailyBattleLimit)
+	fmt.Printf("   다음 초기화까지 %s\n", untilReset)
+	fmt.Println("════════════════════════════════════════")
+	fmt.Printf("📊 최종 전적: %d승 %d패 (승률 %.1f%%)\n", e.battleWins, e.battleLosses, finalWinRate)
+	fmt.Printf("💰 총 수익: %sG\n", FormatGold(e.totalGold))
+	fmt.Println("════════════════════════════════════════")
+	fmt.Println()
+	fmt.Println("엔터를 누르면 메뉴로 돌아갑니다...")
+
+	overlay.UpdateStatus("⚔️ 자동 배틀 완료\n⏰ 일일 배틀 제한 도달\n(초기화까지 %s)\n\n📊 전적: %d승 %d패\n📈 승률: %.1f%%\n💰 총 수익: %sG",
+		untilReset, e.battleWins, e.battleLosses, finalWinRate, FormatGold(e.totalGold))
+
+	// 사용자 입력 대기 후 메뉴 복귀
+	fmt.Scanln()
+}
+
 // ResetLastChatText 마지막 채팅 텍스트 초기화 (새 응답 감지를 위해)
 // 중요한 명령어 전송 전에 호출하여 응답 대기가 제대로 작동하도록 함
 func (e *Engine) ResetLastChatText() {
@@ -1660,12 +1820,16 @@ func (e *Engine) readChatClipboard() string {
 	// 간헐적으로 실패하면 이전 명령어가 클립보드에 남아있게 됨.
 	// 실제 카카오톡 채팅 텍스트는 날짜 헤더("2026년 X월 X일") + 메시지로 항상 50자 이상.
 	if text != "" && len(text) < 50 {
+		e.chatReadResult(text, false)
 		return ""
 	}
 
 	if text == "" {
 		fmt.Println("  ⚠️ 클립보드 텍스트 비어있음")
+		e.chatReadResult(text, false)
+		return ""
 	}
+	e.chatReadResult(text, true)
 
 	logger.ChatText(text) // 새로운 채팅만 로깅
 	return text
@@ -1704,6 +1868,7 @@ func (e *Engine) readChatTextWaitForChange(maxWait time.Duration) string {
 			filtered := e.filterMyMessages(rawText)
 			// 내 메시지가 실제로 변경된 경우에만 반환
 			if filtered != lastFiltered {
+				e.chatResponseResult(true)
 				return filtered
 			}
 			// 다른 유저 메시지로 인한 변경 → 계속 대기
@@ -1712,6 +1877,7 @@ func (e *Engine) readChatTextWaitForChange(maxWait time.Duration) string {
 		time.Sleep(pollInterval)
 	}
 
+	e.chatResponseResult(false)
 	return ""
 }
 
@@ -1756,10 +1922,12 @@ func (e *Engine) waitForResponseInternal(maxWait time.Duration, raw bool) string
 		if rawText != e.lastRawChatText {
 			e.lastRawChatText = rawText
 			if raw {
+				e.chatResponseResult(true)
 				return rawText
 			}
 			filtered := e.filterMyMessages(rawText)
 			if filtered != lastFiltered {
+				e.chatResponseResult(true)
 				return filtered
 			}
 			// 다른 유저 메시지로 인한 변경 → 계속 대기
@@ -1768,6 +1936,7 @@ func (e *Engine) waitForResponseInternal(maxWait time.Duration, raw bool) string
 		time.Sleep(pollInterval)
 	}
 
+	e.chatResponseResult(false)
 	return ""
 }
 
@@ -1833,6 +2002,7 @@ func (e *Engine) farmForGoldMine() (string, string, int, bool) {
 	const maxRetries = 3
 
 	for e.running {
+		e.chatWatchdogTick()
 		if e.checkStop() {
 			return "", "", 0, false
 		}
@@ -1875,7 +2045,7 @@ func (e *Engine) farmForGoldMine() (string, string, int, bool) {
 						itemName = ExtractItemName(enhanceText)
 					}
 					itemType := enhanceState.ItemType
-					e.telem.RecordFarmingWithItem(itemName, itemType)
+					e.recordFarming(itemName, itemType, enhanceState.Announced)
 
 					// 파괴되었으면 다시 파밍
 					if enhanceState.LastResult == "destroy" {
@@ -1929,7 +2099,7 @@ func (e *Engine) farmForGoldMine() (string, string, int, bool) {
 		currentLevel := e.ExtractCurrentLevel(state)
 
 		// 텔레메트리 기록
-		e.telem.RecordFarmingWithItem(itemName, itemType)
+		e.recordFarming(itemName, itemType, state.Announced)
 
 		// 아이템 타입별 통계 기록
 		if itemType == "special" {
@@ -1952,6 +2122,7 @@ func (e *Engine) farmUntilSpecialWithName() (string, bool) {
 	const maxRetries = 3
 
 	for e.running {
+		e.chatWatchdogTick()
 		if e.checkStop() {
 			return "", false
 		}
@@ -2020,18 +2191,21 @@ func (e *Engine) farmUntilSpecialWithName() (string, bool) {
 			itemName = ExtractItemName(text)
 		}
 
+		// 찾는 특수가 아니면 (판매 목록 / 찾는 목록 밖) 쓰레기처럼 파괴하고 계속 파밍
+		discardSpecial := state.ItemType == "special" && !e.keepSpecial(itemName)
+
 		// 4. 특수 아이템이면 반환 (강화 모드로 전환)
-		if state.ItemType == "special" {
-			e.telem.RecordFarmingWithItem(itemName, "special")
+		if state.ItemType == "special" && !discardSpecial {
+			e.recordFarming(itemName, "special", state.Announced)
 			e.sessionStats.specialCount++
 			fmt.Printf("🎉 특수 발견! [%s]\n", itemName)
 			overlay.UpdateStatus("💰 골드 채굴 #%d\n🎉 특수 발견!\n[%s]\n\n📋 판단: 특수 → 강화", e.cycleCount, itemName)
 			return itemName, true
 		}
 
-		// 5. 쓰레기/일반 아이템이면 /강화로 파괴하고 반복
-		if state.ItemType == "trash" || state.ItemType == "normal" || state.ItemType == "unknown" {
-			e.telem.RecordFarmingWithItem(itemName, state.ItemType)
+		// 5. 쓰레기/일반/찾지 않는 특수 아이템이면 /강화로 파괴하고 반복
+		if discardSpecial || state.ItemType == "trash" || state.ItemType == "normal" || state.ItemType == "unknown" {
+			e.recordFarming(itemName, state.ItemType, state.Announced)
 			e.sessionStats.trashCount++
 			displayName := itemName
 			if displayName == "" {
@@ -2061,6 +2235,7 @@ func (e *Engine) enhanceToTargetWithLevel(swordName string) (int, bool) {
 	currentLevel := 0
 
 	for currentLevel < e.targetLevel && e.running {
+		e.chatWatchdogTick()
 		if e.checkStop() {
 			return currentLevel, false
 		}
@@ -2105,6 +2280,7 @@ func (e *Engine) enhanceToTargetWithLevel(swordName string) (int, bool) {
 		case "destroy":
 			fmt.Println("  💥 파괴!")
 			e.telem.RecordEnhanceWithType(itemType, currentLevel, "destroy")
+			e.dropKeptSpecial(swordName, "파괴")
 			e.sessionStats.enhanceDestroy++
 			overlay.UpdateStatus("⚔️ 강화 중\n💥 파괴!\n\n📋 판단: 파괴 → 새 아이템")
 			return currentLevel, false
@@ -2211,12 +2387,14 @@ func (e *Engine) waitForResult(prevLevel int) {
 }
 
 func (e *Engine) sendCommand(cmd string) {
+	e.noteCommand(cmd)
 	input.SendCommand(e.cfg.ClickX, e.cfg.ClickY, cmd)
 }
 
 // sendCommandOnce 엔터 1번만 누르는 명령어 전송
 // 입력창 클리어 후 텍스트 입력, 엔터 1번 (줄바꿈만, 전송 안됨)
 func (e *Engine) sendCommandOnce(cmd string) {
+	e.noteCommand("") // 이어서 입력하는 명령은 재전송하지 않음
 	input.SendCommandOnce(e.cfg.ClickX, e.cfg.ClickY, cmd)
 }
 
@@ -2284,6 +2462,12 @@ func (e *Engine) showSettings(reader *bufio.Reader) {
 		fmt.Printf("4. 좌표 고정: %v\n", e.cfg.LockXY)
 		fmt.Printf("5. 배틀 역배 레벨차: %d\n", e.cfg.BattleLevelDiff)
 		fmt.Printf("6. 배틀 쿨다운: %.1f초\n", e.cfg.BattleCooldown)
+		fmt.Printf("7. 배틀 타겟 순환: %v\n", e.cfg.BattleRotate)
+		fmt.Printf("8. 타겟별 최대 패배: %d (0=무제한)\n", e.cfg.BattleMaxLossesPerTarget)
+		fmt.Printf("9. 배틀 블랙리스트 초기화 (수동 %d명, 자동 %d명)\n", len(e.cfg.BattleBlacklist), e.opponents.BlacklistCount())
+		fmt.Printf("10. 강화 중단 - 최소 목표 도달 확률: %.1f%% (0=사용 안 함)\n", e.cfg.EnhanceMinSuccess)
+		fmt.Printf("11. 강화 중단 - 판매가 유리하면 중단: %v\n", e.cfg.EnhanceStopIfSellBetter)
+		fmt.Printf("12. 프로필 재동기화 주기: %d회 (0=이상 감지 시만)\n", e.cfg.ResyncEvery)
 		fmt.Println("0. 돌아가기")
 		fmt.Print("선택: ")
 
@@ -2324,6 +2508,35 @@ func (e *Engine) showSettings(reader *bufio.Reader) {
 			if v, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil && v > 0 {
 				e.cfg.BattleCooldown = v
 			}
+		case "7":
+			e.cfg.BattleRotate = !e.cfg.BattleRotate
+			fmt.Printf("배틀 타겟 순환: %v\n", e.cfg.BattleRotate)
+		case "8":
+			fmt.Print("타겟별 최대 패배 (0=무제한): ")
+			val, _ := reader.ReadString('\n')
+			if v, err := strconv.Atoi(strings.TrimSpace(val)); err == nil && v >= 0 {
+				e.cfg.BattleMaxLossesPerTarget = v
+			}
+		case "9":
+			e.cfg.BattleBlacklist = nil
+			e.opponents.ClearBlacklist()
+			e.opponents.Save()
+			fmt.Println("배틀 블랙리스트를 비웠습니다")
+		case "10":
+			fmt.Print("최소 목표 도달 확률 (%, 0=사용 안 함): ")
+			val, _ := reader.ReadString('\n')
+			if v, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil && v >= 0 && v <= 100 {
+				e.cfg.EnhanceMinSuccess = v
+			}
+		case "11":
+			e.cfg.EnhanceStopIfSellBetter = !e.cfg.EnhanceStopIfSellBetter
+			fmt.Printf("판매가 유리하면 강화 중단: %v\n", e.cfg.EnhanceStopIfSellBetter)
+		case "12":
+			fmt.Print("프로필 재동기화 주기 (강화 횟수, 0=이상 감지 시만): ")
+			val, _ := reader.ReadString('\n')
+			if v, err := strconv.Atoi(strings.TrimSpace(val)); err == nil && v >= 0 {
+				e.cfg.ResyncEvery = v
+			}
 		case "0":
 			e.cfg.Save()
 			return
@@ -2507,11 +2720,16 @@ func (e *Engine) showMyProfile() {
 	PrintTargetSuccessChance(profile.Level)
 
 	// 7. 역배 기대값
-	PrintUpsetAnalysis(profile.Level, profile.Gold)
+	PrintUpsetAnalysis(profile.Level, profile.Gold, itemType)
 
 	// 8. 추천 액션 요약
 	PrintRecommendedActions(profile.Level, profile.Gold, itemType, typeOptLevel)
 
+	// 9. 보관한 특수 아이템 (보유 검 기준으로 정리 후 표시)
+	fmt.Println()
+	e.reconcileKeptSpecials(profile.SwordName, profile.Level)
+	e.printKeptSpecials(profile)
+
 	fmt.Println()
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println()
@@ -2712,6 +2930,14 @@ func (e *Engine) loopMonitor() {
 					// 텔레메트리
 					e.telem.RecordMonitoredBattle(event.WinnerLevel, event.LoserLevel, levelDiff, event.GoldEarned)
 
+					// 상대 DB (내가 참가한 배틀이면 나와의 전적도)
+					myName := ""
+					if e.sessionProfile != nil {
+						myName = e.sessionProfile.Name
+					}
+					e.opponents.ObserveBattle(event.Winner, event.Loser, event.WinnerLevel, event.LoserLevel, myName)
+					e.opponents.Save()
+
 				case "sale":
 					monitorStats.saleTotal++
 					monitorStats.saleGold += event.GoldEarned
@@ -2725,6 +2951,7 @@ func (e *Engine) loopMonitor() {
 
 					// 텔레메트리
 					e.telem.RecordSaleWithType(event.ItemType, event.Level, event.GoldEarned)
+					catalog().ObserveSale(event.ItemName, event.Level, event.GoldEarned)
 
 				case "special":
 					monitorStats.specialTotal++
@@ -2735,6 +2962,7 @@ func (e *Engine) loopMonitor() {
 
 					// 텔레메트리
 					e.telem.RecordSpecialWithName(event.ItemName)
+					catalog().ObserveAnnounced(event.ItemName)
 				}
 			}
 
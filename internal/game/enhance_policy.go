@@ -0,0 +1,75 @@
+package game
+
+import "fmt"
+
+// ========================
+// 강화 중단 정책 (EnhanceToTarget)
+// ========================
+//
+// 매 /강화 전에 남은 목표 도달 확률과 기대값을 계산해 계속할지 판단 (옵션, 기본 꺼짐)
+// 첫 /강화 전과 +0에서는 판단하지 않음
+// - enhance_min_success: 목표 도달 확률이 이 값(%) 미만이면 중단
+// - enhance_stop_if_sell_better: 지금 판매한 골드가 계속 강화할 때의 기대 골드보다 많으면 중단
+// 도달 확률은 CalcEnhanceSuccessChance, 기대 골드는 analysis.CalcRisk를 사용 (analysis가 game을 import하므로 main에서 연결)
+
+// enhanceRiskFunc 목표 도달 확률(%)과 계속 강화 시 기대 최종 골드 (연결 안 됐으면 nil)
+// 확률 출처를 하나로 맞추기 위해 기대 골드만 사용
+var enhanceRiskFunc func(level, gold, target int) (successProb float64, expectedGold int)
+
+// SetEnhanceRiskFunc 강화 중단 정책에 쓸 리스크 계산 함수 연결 (analysis.CalcRisk)
+func SetEnhanceRiskFunc(f func(level, gold, target int) (successProb float64, expectedGold int)) {
+	enhanceRiskFunc = f
+}
+
+// enhancePolicyEnabled 강화 중단 정책 사용 여부
+func (e *Engine) enhancePolicyEnabled() bool {
+	return e.cfg.EnhanceMinSuccess > 0 || e.cfg.EnhanceStopIfSellBetter
+}
+
+// enhanceStopCheck 강화 중단 판단에 쓰는 현재 상태
+type enhanceStopCheck struct {
+	Level     int
+	Target    int
+	Attempted bool // 이번 강화에서 /강화를 한 번 이상 보냈는지
+
+	SuccessProb  float64 // 목표 도달 확률 (%, CalcEnhanceSuccessChance)
+	SellNow      int     // 지금 판매하면 보유할 골드 (모르면 0 - 판매 비교 생략)
+	ExpectedGold int     // 계속 강화할 때 기대 최종 골드 (모르면 0 - 판매 비교 생략)
+}
+
+// decideEnhanceStop 중단해야 하면 이유 반환 (계속하면 빈 문자열)
+// +0이거나 아직 한 번도 강화하지 않았으면 판단하지 않음
+// (+0은 판매할 수 없어 멈추면 골드 채굴이 같은 검으로 계속 되돌아오고, 시작 레벨은 호출부가 이미 강화하기로 정한 것)
+func decideEnhanceStop(minSuccess float64, stopIfSellBetter bool, c enhanceStopCheck) string {
+	if c.Level <= 0 || !c.Attempted || c.Level >= c.Target {
+		return ""
+	}
+	if minSuccess > 0 && c.SuccessProb < minSuccess {
+		return fmt.Sprintf("+%d 도달 확률 %.1f%% < 기준 %.1f%%", c.Target, c.SuccessProb, minSuccess)
+	}
+	if stopIfSellBetter && c.SellNow > 0 && c.ExpectedGold > 0 && c.SellNow > c.ExpectedGold {
+		return fmt.Sprintf("지금 판매 %sG > 계속 강화 기대 %sG", FormatGold(c.SellNow), FormatGold(c.ExpectedGold))
+	}
+	return ""
+}
+
+// enhanceStopReason 다음 /강화 전에 중단해야 하면 이유 반환 (계속하면 빈 문자열)
+// gold: 현재 보유 골드 (모르면 0 - 판매 비교 생략), attempted: 이번 강화에서 /강화를 보냈는지
+func (e *Engine) enhanceStopReason(level, gold int, attempted bool) string {
+	if !e.enhancePolicyEnabled() || GetAllEnhanceRates() == nil {
+		return "" // 확률 데이터가 없으면 판단하지 않음
+	}
+	c := enhanceStopCheck{
+		Level:       level,
+		Target:      e.targetLevel,
+		Attempted:   attempted,
+		SuccessProb: CalcEnhanceSuccessChance(level, e.targetLevel),
+	}
+	if e.cfg.EnhanceStopIfSellBetter && enhanceRiskFunc != nil && gold > 0 && level > 0 {
+		if price := GetSwordPrice(level); price != nil && price.AvgPrice > 0 {
+			c.SellNow = gold + price.AvgPrice
+			_, c.ExpectedGold = enhanceRiskFunc(level, gold, e.targetLevel)
+		}
+	}
+	return decideEnhanceStop(e.cfg.EnhanceMinSuccess, e.cfg.EnhanceStopIfSellBetter, c)
+}
@@ -0,0 +1,75 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/StopDragon/sword-macro-ai/internal/config"
+)
+
+func TestEnhanceStopReason(t *testing.T) {
+	rates := make([]EnhanceRate, 5)
+	prices := make([]SwordPrice, 5)
+	for i := range rates {
+		rates[i] = EnhanceRate{Level: i, SuccessRate: 50, KeepRate: 30, DestroyRate: 20}
+		prices[i] = SwordPrice{Level: i, AvgPrice: 500 * i}
+	}
+	setTestGameData(t, &GameData{EnhanceRates: rates, SwordPrices: prices})
+	enhanceRiskFunc = func(level, gold, target int) (float64, int) {
+		return CalcEnhanceSuccessChance(level, target), 1000
+	}
+	t.Cleanup(func() { enhanceRiskFunc = nil })
+
+	cases := []struct {
+		name       string
+		minSuccess float64
+		sellBetter bool
+		level      int
+		gold       int
+		stop       bool
+	}{
+		{"policy off", 0, false, 1, 800, false},
+		{"below threshold", 30, false, 1, 0, true}, // +1 → +3: 25%
+		{"above threshold", 20, false, 1, 0, false},
+		{"target reached", 30, false, 3, 0, false},
+		{"sell better", 0, true, 1, 800, true}, // 800 + 500 > 1000
+		{"keep enhancing better", 0, true, 1, 400, false},
+		{"gold unknown", 0, true, 1, 0, false},
+	}
+	for _, c := range cases {
+		e := &Engine{
+			cfg:         &config.Config{EnhanceMinSuccess: c.minSuccess, EnhanceStopIfSellBetter: c.sellBetter},
+			targetLevel: 3,
+		}
+		reason := e.enhanceStopReason(c.level, c.gold, true)
+		if (reason != "") != c.stop {
+			t.Errorf("%s: reason = %q, want stop=%v", c.name, reason, c.stop)
+		}
+	}
+}
+
+func TestDecideEnhanceStop(t *testing.T) {
+	cases := []struct {
+		name       string
+		minSuccess float64
+		sellBetter bool
+		check      enhanceStopCheck
+		stop       bool
+	}{
+		{"before first attempt", 50, false, enhanceStopCheck{Level: 5, Target: 10, SuccessProb: 10}, false},
+		{"+0 after destroy", 50, false, enhanceStopCheck{Level: 0, Target: 10, Attempted: true, SuccessProb: 1}, false},
+		{"below threshold", 50, false, enhanceStopCheck{Level: 5, Target: 10, Attempted: true, SuccessProb: 10}, true},
+		{"above threshold", 50, false, enhanceStopCheck{Level: 5, Target: 10, Attempted: true, SuccessProb: 60}, false},
+		{"target reached", 50, false, enhanceStopCheck{Level: 10, Target: 10, Attempted: true}, false},
+		{"threshold off", 0, false, enhanceStopCheck{Level: 5, Target: 10, Attempted: true, SuccessProb: 1}, false},
+		{"sell better", 0, true, enhanceStopCheck{Level: 5, Target: 10, Attempted: true, SellNow: 2000, ExpectedGold: 1500}, true},
+		{"keep enhancing better", 0, true, enhanceStopCheck{Level: 5, Target: 10, Attempted: true, SellNow: 1500, ExpectedGold: 2000}, false},
+		{"gold unknown", 0, true, enhanceStopCheck{Level: 5, Target: 10, Attempted: true, SellNow: 0, ExpectedGold: 0}, false},
+		{"sell check off", 0, false, enhanceStopCheck{Level: 5, Target: 10, Attempted: true, SellNow: 2000, ExpectedGold: 1500}, false},
+	}
+	for _, c := range cases {
+		reason := decideEnhanceStop(c.minSuccess, c.sellBetter, c.check)
+		if (reason != "") != c.stop {
+			t.Errorf("%s: reason = %q, want stop=%v", c.name, reason, c.stop)
+		}
+	}
+}
@@ -94,9 +94,10 @@ type EnhanceResult struct {
 	FinalLevel          int
 	Success             bool   // 목표 도달 여부
 	Destroyed           bool   // 파괴 여부
-	NewSwordName        string // 파괴 시 새로 받은 검 이름
-	NewSwordType        string // 파괴 시 새로 받은 검 타입
+	NewSwordName        string // 파괴 시 새로 받은 검 이름 (검이 바뀌어 종료했으면 실제 보유 검)
+	NewSwordType        string // 파괴 시 새로 받은 검 타입 (검이 바뀌어 종료했으면 실제 보유 검)
 	MaxConsecutiveFails int    // 이 강화의 최대 연속 실패(유지) 횟수
+	StopReason          string // 강화 중단 정책으로 멈춘 이유 (비어 있으면 정책 중단 아님)
 }
 
 // EnhanceToTarget 목표 레벨까지 강화 진행 (시작 레벨 지정 가능)
@@ -110,13 +111,62 @@ func (e *Engine) EnhanceToTarget(itemName string, startLevel int) EnhanceResult
 	consecutiveFails := 0
 	maxConsecutiveFails := 0
 
+	// 강화 중단 정책용 보유 골드 (강화 응답의 남은 골드로 갱신)
+	gold := 0
+	attempted := false // 첫 /강화 전에는 중단 정책을 적용하지 않음
+	if e.cfg.EnhanceStopIfSellBetter {
+		gold = e.readCurrentGold()
+	}
+
 	for currentLevel < e.targetLevel && e.running {
+		e.chatWatchdogTick()
 		if e.checkStop() {
 			return EnhanceResult{FinalLevel: currentLevel, Success: false, Destroyed: false, MaxConsecutiveFails: maxConsecutiveFails}
 		}
 
+		// 상태 재동기화 (주기 / 불확실한 결과 / 레벨 점프 후)
+		if profile, drift := e.resyncIfNeeded(currentLevel, itemName); profile.OK {
+			if profile.Gold > 0 {
+				gold = profile.Gold
+			}
+			// 모르는 사이 +0 새 검으로 바뀌었으면 파괴된 것으로 처리
+			if drift && profile.Level == 0 && profile.SwordName != "" && profile.SwordName != itemName {
+				return EnhanceResult{
+					FinalLevel:          currentLevel,
+					Destroyed:           true,
+					NewSwordName:        profile.SwordName,
+					NewSwordType:        DetermineItemType(profile.SwordName),
+					MaxConsecutiveFails: maxConsecutiveFails,
+				}
+			}
+			// 다른 검을 들고 있으면 이 검의 강화로 기록하지 않도록 시도 종료 (호출한 쪽이 실제 검 기준으로 다시 판단)
+			if drift && profile.SwordName != "" && profile.SwordName != itemName {
+				fmt.Printf("  ⚠️ 보유 검이 바뀜: %s → [+%d] %s, 강화 종료\n", itemName, profile.Level, profile.SwordName)
+				logger.Info("강화 종료: 보유 검이 [%s]에서 [+%d] %s(으)로 바뀜", itemName, profile.Level, profile.SwordName)
+				return EnhanceResult{
+					FinalLevel:          profile.Level,
+					NewSwordName:        profile.SwordName,
+					NewSwordType:        DetermineItemType(profile.SwordName),
+					MaxConsecutiveFails: maxConsecutiveFails,
+				}
+			}
+			currentLevel = profile.Level
+			if currentLevel >= e.targetLevel {
+				continue
+			}
+		}
+
+		// 강화 중단 정책 (목표 도달 확률 / 판매 vs 계속 강화 기대값)
+		if reason := e.enhanceStopReason(currentLevel, gold, attempted); reason != "" {
+			fmt.Printf("  🛑 강화 중단: %s\n", reason)
+			logger.Info("강화 중단 정책: [%s] +%d (목표 +%d) - %s", itemName, currentLevel, e.targetLevel, reason)
+			return EnhanceResult{FinalLevel: currentLevel, MaxConsecutiveFails: maxConsecutiveFails, StopReason: reason}
+		}
+
 		// 강화 시도
 		e.sendCommand("/강화")
+		e.countEnhanceAction()
+		attempted = true
 		delay := e.getDelayForLevel(currentLevel)
 		time.Sleep(delay)
 
@@ -132,13 +182,18 @@ func (e *Engine) EnhanceToTarget(itemName string, startLevel int) EnhanceResult
 		}
 
 		if state == nil {
+			e.requestResync("응답 없음")
 			continue
 		}
+		if state.Gold > 0 {
+			gold = state.Gold
+		}
 
 		// 파괴 확인
 		if state.LastResult == "destroy" {
 			// 타입+레벨별 강화 통계 기록
 			e.telem.RecordEnhanceWithType(itemType, currentLevel, "destroy")
+			e.dropKeptSpecial(itemName, "파괴")
 			e.enhanceConsecutiveFails = 0
 
 			result := EnhanceResult{FinalLevel: currentLevel, Success: false, Destroyed: true, MaxConsecutiveFails: maxConsecutiveFails}
@@ -175,10 +230,12 @@ func (e *Engine) EnhanceToTarget(itemName string, startLevel int) EnhanceResult
 				// ResultLevel 파싱 실패 시 fallback으로 +1
 				currentLevel++
 				fmt.Printf("  ⚔️ 강화 성공! +%d 도달 (계산값)\n", currentLevel)
+				e.requestResync("결과 레벨 없음")
 			}
 			// 이중 강화 감지: 레벨이 2 이상 점프하면 의심스러움
 			if currentLevel > prevLevel+1 {
 				logger.Error("의심스러운 레벨 점프 (EnhanceToTarget): +%d → +%d (예상: +%d)", prevLevel, currentLevel, prevLevel+1)
+				e.requestResync("레벨 점프")
 			}
 			// 타입+레벨별 강화 통계 기록 (강화 전 레벨 기준)
 			e.telem.RecordEnhanceWithType(itemType, currentLevel-1, "success")
@@ -211,6 +268,7 @@ func (e *Engine) EnhanceToTarget(itemName string, startLevel int) EnhanceResult
 				currentLevel = state.ResultLevel
 			}
 			fmt.Printf("  ❓ 결과 불명확 (LastResult='%s') - 재시도\n", state.LastResult)
+			e.requestResync("결과 불명확")
 		}
 
 		// 골드 부족 체크
@@ -375,7 +433,12 @@ func (e *Engine) CheckOtherProfile(username string) *Profile {
 	}
 
 	// 해당 유저의 프로필 섹션만 파싱 (다른 유저/본인 프로필 무시)
-	return ParseProfileForUser(profileText, username)
+	profile := ParseProfileForUser(profileText, username)
+	if profile != nil && profile.Name == "" {
+		profile.Name = username
+	}
+	e.opponents.ObserveProfile(profile)
+	return profile
 }
 
 // PrintEnhanceRateTable 강화 확률표 출력
@@ -436,9 +499,34 @@ func PrintTargetSuccessChance(currentLevel int) {
 	fmt.Println()
 }
 
+// upsetAnalysisRow 역배 분석 한 줄 (model: 서버 승률 모델 사용 여부)
+type upsetAnalysisRow struct {
+	ev, winRate float64
+	avgReward   int
+	model       bool
+}
+
+// upsetRow 레벨차 diff 역배의 승률 / 기대값 (통계가 없으면 ok가 false)
+func upsetRow(level, diff, betAmount int, itemType string) (row upsetAnalysisRow, ok bool) {
+	if m := FetchMatchup(level, level+diff, itemType); m != nil && m.Model == "logistic" {
+		// 서버 모델: 내 레벨까지 반영한 승률, 패배 손실은 실측 평균 (없으면 배팅 금액)
+		loss := m.AvgLoss
+		if loss == 0 {
+			loss = betAmount
+		}
+		p := m.WinRate / 100.0
+		return upsetAnalysisRow{p*float64(m.AvgReward) - (1-p)*float64(loss), m.WinRate, m.AvgReward, true}, true
+	}
+	if GetBattleReward(diff) == nil {
+		return upsetAnalysisRow{}, false
+	}
+	ev, winRate, avgReward := CalcUpsetExpectedValue(level, level+diff, betAmount)
+	return upsetAnalysisRow{ev: ev, winRate: winRate, avgReward: avgReward}, true
+}
+
 // PrintUpsetAnalysis 역배 기대값 분석 출력
-// level: 내 레벨, gold: 보유 골드 (배팅 금액 계산용)
-func PrintUpsetAnalysis(level, gold int) {
+// level: 내 레벨, gold: 보유 골드 (배팅 금액 계산용), itemType: 내 검 타입 (서버 승률 모델 조회용)
+func PrintUpsetAnalysis(level, gold int, itemType string) {
 	fmt.Printf("⚡ 역배 분석 (내 레벨: +%d)\n", level)
 	fmt.Println("   레벨차 | 승률  | 평균보상 | 기대값")
 	fmt.Println("   ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -451,13 +539,14 @@ func PrintUpsetAnalysis(level, gold int) {
 		}
 	}
 
+	modelUsed := false
 	for diff := 1; diff <= 3; diff++ {
-		reward := GetBattleReward(diff)
-		if reward == nil {
+		row, ok := upsetRow(level, diff, betAmount, itemType)
+		if !ok {
 			continue
 		}
-
-		ev, winRate, avgReward := CalcUpsetExpectedValue(level, level+diff, betAmount)
+		ev, winRate, avgReward := row.ev, row.winRate, row.avgReward
+		modelUsed = modelUsed || row.model
 
 		evStr := fmt.Sprintf("%+.0fG", ev)
 		if ev > 0 {
@@ -470,7 +559,11 @@ func PrintUpsetAnalysis(level, gold int) {
 			diff, winRate, FormatGold(avgReward), evStr)
 	}
 	fmt.Println()
-	fmt.Printf("   💡 배팅 기준: %sG (보유 골드의 10%%)\n", FormatGold(betAmount))
+	if modelUsed {
+		fmt.Printf("   💡 승률: 서버 배틀 모델 (+%d 기준), 손실: 실측 평균\n", level)
+	} else {
+		fmt.Printf("   💡 배팅 기준: %sG (보유 골드의 10%%)\n", FormatGold(betAmount))
+	}
 }
 
 // PrintLevelEfficiencyTable 레벨별 효율 분석 테이블 출력 (GPM 포함)
@@ -619,10 +712,18 @@ func PrintRecommendedActions(level, gold int, itemType string, optimalLevel int)
 // 배틀 관련 헬퍼
 // =============================================================================
 
+// battleSwordType 배틀 통계 / 승률 모델용 내 검 타입 (검 이름을 모르면 빈 문자열)
+func battleSwordType(swordName string) string {
+	if itemType := DetermineItemType(swordName); itemType != "unknown" {
+		return itemType
+	}
+	return ""
+}
+
 // ReportBattleCycle 배틀 사이클 완료 보고
 // goldChange: 승리 시 양수(보상), 패배 시 음수(손실) 또는 0
 func (e *Engine) ReportBattleCycle(swordName string, myLevel, targetLevel int, won bool, goldChange, currentGold int) {
-	e.telem.RecordBattleWithSword(swordName, myLevel, targetLevel, won, goldChange)
+	e.telem.RecordBattleWithSword(swordName, battleSwordType(swordName), myLevel, targetLevel, won, goldChange)
 	e.telem.RecordGoldChange(currentGold)
 	e.telem.TrySend()
 }
@@ -0,0 +1,213 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/logger"
+)
+
+// ========================
+// 보관한 특수 아이템 (kept_specials.json)
+// ========================
+//
+// 특수 뽑기 / 골드 채굴에서 판매하지 않고 보관한 특수 아이템을 기록
+// 커뮤니티 판매 통계(/api/stats/sales)로 가치를 매겨 프로필 화면과 세션 순자산에 반영
+// 판매 / 파괴하면 목록에서 빼고, /프로필을 읽을 때마다 실제 보유 검과 맞춤 (게임은 검을 하나만 보유)
+
+const keptSpecialsFile = "kept_specials.json"
+
+// KeptSpecial 보관한 특수 아이템
+type KeptSpecial struct {
+	Name   string `json:"name"`
+	Level  int    `json:"level"`
+	KeptAt int64  `json:"kept_at"` // 보관한 시각 (Unix)
+	Mode   string `json:"mode"`    // special, goldmine
+}
+
+// SpecialInventory 보관한 특수 아이템 목록 (오래된 순)
+type SpecialInventory struct {
+	path  string
+	Items []*KeptSpecial `json:"items"`
+}
+
+// LoadSpecialInventory 실행 파일 옆 kept_specials.json 로드 (없거나 손상되면 빈 목록)
+func LoadSpecialInventory() *SpecialInventory {
+	path := keptSpecialsFile
+	if exe, err := os.Executable(); err == nil {
+		path = filepath.Join(filepath.Dir(exe), keptSpecialsFile)
+	}
+	inv := &SpecialInventory{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return inv
+	}
+	if err := json.Unmarshal(data, inv); err != nil {
+		logger.Error("[보관 특수] 파일 손상: %v", err)
+		inv.Items = nil
+	}
+	return inv
+}
+
+// Add 보관 기록 추가 (반환: 새로 추가됐으면 true)
+// 이름 / 레벨이 같은 기록이 있으면 같은 검을 다시 보관한 것으로 보고 시각만 갱신해 맨 뒤로 이동
+func (inv *SpecialInventory) Add(name string, level int, mode string, now time.Time) bool {
+	item := &KeptSpecial{Name: name, Level: level}
+	added := true
+	for i, it := range inv.Items {
+		if it.Name == name && it.Level == level {
+			item = it
+			inv.Items = append(inv.Items[:i], inv.Items[i+1:]...)
+			added = false
+			break
+		}
+	}
+	item.KeptAt = now.Unix()
+	item.Mode = mode
+	inv.Items = append(inv.Items, item)
+	inv.save()
+	return added
+}
+
+// Remove 판매 / 파괴한 검의 기록 삭제 (반환: 삭제한 기록이 있으면 true)
+func (inv *SpecialInventory) Remove(name string) bool {
+	kept := inv.Items[:0]
+	for _, it := range inv.Items {
+		if it.Name != name {
+			kept = append(kept, it)
+		}
+	}
+	removed := len(kept) != len(inv.Items)
+	inv.Items = kept
+	if removed {
+		inv.save()
+	}
+	return removed
+}
+
+// Reconcile 프로필의 보유 검으로 목록 보정 (반환: 빠진 기록 수)
+// 보유 검이 아닌 기록은 이미 판매 / 파괴된 것이므로 삭제하고, 보유 검 기록은 현재 레벨로 갱신
+func (inv *SpecialInventory) Reconcile(swordName string, level int) int {
+	var held *KeptSpecial
+	removed, changed := 0, false
+	for _, it := range inv.Items {
+		switch {
+		case it.Name != swordName:
+			removed++
+		case held == nil || it.KeptAt > held.KeptAt:
+			if held != nil {
+				removed++
+			}
+			held = it
+		default:
+			removed++
+		}
+	}
+	inv.Items = inv.Items[:0]
+	if held != nil {
+		if held.Level != level {
+			held.Level = level
+			changed = true
+		}
+		inv.Items = append(inv.Items, held)
+	}
+	if removed > 0 || changed {
+		inv.save()
+	}
+	return removed
+}
+
+func (inv *SpecialInventory) save() {
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return
+	}
+	tmp := inv.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		logger.Error("[보관 특수] 저장 실패: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, inv.path); err != nil {
+		logger.Error("[보관 특수] 저장 실패: %v", err)
+	}
+}
+
+// specialValue 특수 아이템 추정 가치
+// 1순위: 커뮤니티 특수 판매 평균 (/api/stats/sales), 2순위: 게임 데이터 레벨별 평균 판매가
+func specialValue(level int) (value int, source string) {
+	if stats, err := FetchSaleStats(); err == nil {
+		if avg, count := stats.AvgPrice("special", level); avg > 0 {
+			return avg, fmt.Sprintf("특수 판매 %d건", count)
+		}
+	}
+	if price := GetSwordPrice(level); price != nil && price.AvgPrice > 0 {
+		return price.AvgPrice, "레벨 평균"
+	}
+	return 0, "데이터 없음"
+}
+
+// recordKeptSpecial 보관한 특수 기록 (sessionGain: 이번 세션에서 얻은 검이면 세션 순자산에 반영)
+func (e *Engine) recordKeptSpecial(name string, level int, mode string, sessionGain bool) {
+	if name == "" {
+		return
+	}
+	added := e.inventory.Add(name, level, mode, time.Now())
+	value, source := specialValue(level)
+	logger.Info("[보관 특수] %s +%d (%s) 추정 %dG (%s)", name, level, mode, value, source)
+	if added && sessionGain {
+		e.sessionStats.keptCount++
+		e.sessionStats.keptValue += value
+	}
+	if value > 0 {
+		fmt.Printf("📦 보관 기록: [+%d] %s (추정 가치 %sG, %s)\n", level, name, FormatGold(value), source)
+	} else {
+		fmt.Printf("📦 보관 기록: [+%d] %s (추정 가치 알 수 없음)\n", level, name)
+	}
+}
+
+// dropKeptSpecial 판매 / 파괴한 검이 보관 기록에 있으면 삭제
+func (e *Engine) dropKeptSpecial(name, reason string) {
+	if name != "" && e.inventory.Remove(name) {
+		logger.Info("[보관 특수] %s 기록 삭제 (%s)", name, reason)
+	}
+}
+
+// reconcileKeptSpecials /프로필로 확인한 보유 검에 보관 기록을 맞춤 (검 이름을 못 읽었으면 무시)
+func (e *Engine) reconcileKeptSpecials(swordName string, level int) {
+	if swordName == "" || level < 0 {
+		return
+	}
+	if removed := e.inventory.Reconcile(swordName, level); removed > 0 {
+		logger.Info("[보관 특수] 보유 검 [+%d] %s 기준으로 기록 %d개 정리", level, swordName, removed)
+	}
+}
+
+// printKeptSpecials 보관한 특수 목록과 추정 가치 (프로필 화면용)
+// 현재 보유 검과 이름 / 레벨이 같은 기록은 보유 중으로 표시
+func (e *Engine) printKeptSpecials(profile *Profile) {
+	fmt.Println("📦 보관한 특수 아이템")
+	if len(e.inventory.Items) == 0 {
+		fmt.Println("   기록 없음")
+		fmt.Println()
+		return
+	}
+	total := 0
+	for i := len(e.inventory.Items) - 1; i >= 0; i-- {
+		item := e.inventory.Items[i]
+		value, source := specialValue(item.Level)
+		total += value
+		held := ""
+		if profile != nil && profile.SwordName == item.Name && profile.Level == item.Level {
+			held = " ← 보유 중"
+		}
+		fmt.Printf("   [+%d] %s | %s | %s | %sG (%s)%s\n",
+			item.Level, item.Name, time.Unix(item.KeptAt, 0).Format("01-02 15:04"),
+			item.Mode, FormatGold(value), source, held)
+	}
+	fmt.Printf("   합계: %d개, 추정 %sG\n", len(e.inventory.Items), FormatGold(total))
+	fmt.Println()
+}
@@ -0,0 +1,89 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func keptNames(inv *SpecialInventory) []string {
+	var names []string
+	for _, it := range inv.Items {
+		names = append(names, fmt.Sprintf("%s+%d", it.Name, it.Level))
+	}
+	return names
+}
+
+func TestSpecialInventoryAdd(t *testing.T) {
+	inv := &SpecialInventory{path: filepath.Join(t.TempDir(), keptSpecialsFile)}
+	now := time.Now()
+
+	if !inv.Add("붉은 눈동자", 10, "special", now) {
+		t.Error("first add should be new")
+	}
+	// 같은 검을 다시 보관하면 시각 / 모드만 갱신
+	if inv.Add("붉은 눈동자", 10, "goldmine", now.Add(time.Minute)) {
+		t.Error("re-keeping the same sword should not add a new item")
+	}
+	if got := inv.Items[0]; got.Mode != "goldmine" || got.KeptAt != now.Add(time.Minute).Unix() {
+		t.Errorf("re-kept item = %+v, want refreshed mode and time", got)
+	}
+	if !inv.Add("붉은 눈동자", 11, "special", now) || !inv.Add("용의 이빨", 8, "special", now) {
+		t.Error("different level or name should be added")
+	}
+	if got := keptNames(inv); len(got) != 3 || got[0] != "붉은 눈동자+10" || got[2] != "용의 이빨+8" {
+		t.Errorf("items = %v", got)
+	}
+	if _, err := os.Stat(inv.path); err != nil {
+		t.Errorf("Add should save: %v", err)
+	}
+}
+
+func TestSaleStatsAvgPrice(t *testing.T) {
+	stats := &SaleStats{Sales: []SaleEntry{
+		{Key: "special_10", Count: 3, AvgPrice: 50000},
+		{Key: "normal_10", Count: 20, AvgPrice: 12000},
+	}}
+	if avg, count := stats.AvgPrice("special", 10); avg != 50000 || count != 3 {
+		t.Errorf("special +10 = (%d, %d), want (50000, 3)", avg, count)
+	}
+	if avg, count := stats.AvgPrice("special", 11); avg != 0 || count != 0 {
+		t.Errorf("missing key = (%d, %d), want (0, 0)", avg, count)
+	}
+}
+
+func TestSpecialInventory(t *testing.T) {
+	inv := &SpecialInventory{path: filepath.Join(t.TempDir(), keptSpecialsFile)}
+	now := time.Now()
+
+	if !inv.Add("붉은 눈동자", 10, "special", now) {
+		t.Error("first add should be new")
+	}
+	inv.Add("용의 이빨", 8, "goldmine", now.Add(time.Minute))
+	// 같은 이름+레벨은 마지막이 아니어도 중복 없이 갱신
+	if inv.Add("붉은 눈동자", 10, "goldmine", now.Add(2*time.Minute)) {
+		t.Error("same name+level should not be added again")
+	}
+	if got := keptNames(inv); len(got) != 2 || got[0] != "용의 이빨+8" || got[1] != "붉은 눈동자+10" {
+		t.Fatalf("items = %v", got)
+	}
+
+	// 판매 / 파괴하면 삭제
+	if !inv.Remove("용의 이빨") || inv.Remove("용의 이빨") {
+		t.Error("Remove should delete once")
+	}
+
+	// 프로필 보정: 보유 검이 아닌 기록 삭제, 보유 검은 현재 레벨로 갱신
+	inv.Add("푸른 비늘", 5, "special", now.Add(3*time.Minute))
+	if removed := inv.Reconcile("붉은 눈동자", 12); removed != 1 {
+		t.Errorf("Reconcile removed %d, want 1", removed)
+	}
+	if got := keptNames(inv); len(got) != 1 || got[0] != "붉은 눈동자+12" {
+		t.Errorf("items after reconcile = %v", got)
+	}
+	if removed := inv.Reconcile("낡은 검", 0); removed != 1 || len(inv.Items) != 0 {
+		t.Errorf("Reconcile with other sword removed %d, left %d", removed, len(inv.Items))
+	}
+}
@@ -0,0 +1,342 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/logger"
+)
+
+// ========================
+// 아이템 카탈로그 (item_catalog.json)
+// ========================
+//
+// 아이템 이름별로 관찰한 타입 신호를 실행 간에 유지하고 DetermineItemType이 정규식보다 먼저 참고
+// 신호 우선순위:
+//  1. 봇이 특수/히든이라고 알려준 적이 있음 → 특수
+//  2. 내가 본 판매가가 레벨별 일반/특수 평균 중 한쪽에 일관되게 가까움
+//  3. 서버 카탈로그 (커뮤니티 파밍 보고 다수결, /api/items/catalog)
+//  4. 내 파밍에서 자주 나옴 → 일반 (특수는 드물게 나오므로)
+// 어느 신호도 없으면 기존 접미사 정규식으로 판별
+// 쓰레기 판별은 호출부의 이름 패턴(낡은 등)이 담당하므로 카탈로그는 특수/일반만 결정
+// 관찰은 엔진이 기록하고, 파일은 saveInterval마다 한 번 + 종료 시(SaveItemCatalog) 저장
+
+const (
+	itemCatalogFile  = "item_catalog.json"
+	itemCatalogPath  = "/api/items/catalog"
+	minSaleVotes     = 2    // 판매가 신호 사용 최소 판매 수
+	saleVoteMinShare = 0.8  // 판매가 신호가 한쪽으로 이 비율 이상 쏠려야 사용
+	salePriceGap     = 1.3  // 일반 / 특수 평균가가 이 배수 이상 차이 나야 판매가로 구분
+	minFarmVotes     = 10   // 파밍 빈도 신호 사용 최소 파밍 수
+	commonFarmShare  = 0.02 // 전체 파밍 중 이 비율 이상 나온 이름은 일반으로 판단
+
+	saveInterval = time.Minute // 관찰 후 파일 저장 최소 간격
+)
+
+// CatalogEntry 아이템 이름별 타입 신호
+type CatalogEntry struct {
+	Name string `json:"name"`
+
+	Announced   bool `json:"announced,omitempty"`    // 봇이 특수/히든으로 알려줌
+	SaleNormal  int  `json:"sale_normal,omitempty"`  // 판매가가 일반 평균에 가까웠던 횟수
+	SaleSpecial int  `json:"sale_special,omitempty"` // 판매가가 특수 평균에 가까웠던 횟수
+	Farmed      int  `json:"farmed,omitempty"`       // 내가 파밍에서 얻은 횟수
+
+	Community        string `json:"community,omitempty"`         // 서버 카탈로그 확정 타입
+	CommunitySamples int    `json:"community_samples,omitempty"` // 서버 카탈로그 파밍 보고 수
+}
+
+// itemType 신호로 정한 타입 (정할 수 없으면 빈 문자열)
+// farmTotal: 카탈로그 전체 파밍 수 (파밍 빈도 신호용)
+func (c *CatalogEntry) itemType(farmTotal int) string {
+	if c.Announced {
+		return "special"
+	}
+	if votes := c.SaleNormal + c.SaleSpecial; votes >= minSaleVotes {
+		switch {
+		case float64(c.SaleSpecial) >= saleVoteMinShare*float64(votes):
+			return "special"
+		case float64(c.SaleNormal) >= saleVoteMinShare*float64(votes):
+			return "normal"
+		}
+	}
+	if c.Community == "special" || c.Community == "normal" {
+		return c.Community
+	}
+	if c.Farmed >= minFarmVotes && float64(c.Farmed) >= commonFarmShare*float64(farmTotal) {
+		return "normal"
+	}
+	return ""
+}
+
+// ItemCatalog 아이템 이름 → 타입 신호
+type ItemCatalog struct {
+	mu        sync.Mutex
+	path      string
+	entries   map[string]*CatalogEntry
+	farmTotal int // 전체 파밍 수 (항목별 Farmed 합계)
+	dirty     bool
+	lastSave  time.Time
+}
+
+var (
+	itemCatalog     *ItemCatalog
+	itemCatalogOnce sync.Once
+)
+
+// catalog 실행 파일 옆 item_catalog.json (처음 호출 시 로드)
+func catalog() *ItemCatalog {
+	itemCatalogOnce.Do(func() {
+		itemCatalog = loadItemCatalog()
+	})
+	return itemCatalog
+}
+
+// loadItemCatalog 카탈로그 파일 로드 (없거나 손상되면 빈 카탈로그)
+func loadItemCatalog() *ItemCatalog {
+	path := itemCatalogFile
+	if exe, err := os.Executable(); err == nil {
+		path = filepath.Join(filepath.Dir(exe), itemCatalogFile)
+	}
+	c := &ItemCatalog{path: path, entries: make(map[string]*CatalogEntry)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	var list []*CatalogEntry
+	if err := json.Unmarshal(data, &list); err != nil {
+		logger.Error("[아이템 카탈로그] 파일 손상: %v", err)
+		return c
+	}
+	for _, entry := range list {
+		if entry.Name != "" {
+			c.entries[entry.Name] = entry
+			c.farmTotal += entry.Farmed
+		}
+	}
+	return c
+}
+
+// Save 변경 사항이 있으면 디스크에 기록 (임시 파일 → rename)
+func (c *ItemCatalog) Save() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return
+	}
+	list := make([]*CatalogEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		list = append(list, entry)
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		logger.Error("[아이템 카탈로그] 저장 실패: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		logger.Error("[아이템 카탈로그] 저장 실패: %v", err)
+		return
+	}
+	c.dirty = false
+	c.lastSave = time.Now()
+}
+
+// saveIfDue 마지막 저장 후 saveInterval이 지났으면 저장 (관찰마다 파일을 다시 쓰지 않도록)
+func (c *ItemCatalog) saveIfDue() {
+	c.mu.Lock()
+	due := c.dirty && time.Since(c.lastSave) >= saveInterval
+	c.mu.Unlock()
+	if due {
+		c.Save()
+	}
+}
+
+// SaveItemCatalog 아직 저장하지 않은 관찰을 디스크에 기록 (프로그램 종료 시 호출)
+func SaveItemCatalog() {
+	catalog().Save()
+}
+
+// Lookup 카탈로그로 정한 타입 (모르면 ok=false)
+func (c *ItemCatalog) Lookup(itemName string) (itemType string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry := c.entries[itemName]; entry != nil {
+		itemType = entry.itemType(c.farmTotal)
+	}
+	return itemType, itemType != ""
+}
+
+// entry 이름별 항목 (없으면 생성), 호출 전에 잠금 필요
+func (c *ItemCatalog) entry(itemName string) *CatalogEntry {
+	entry := c.entries[itemName]
+	if entry == nil {
+		entry = &CatalogEntry{Name: itemName}
+		c.entries[itemName] = entry
+	}
+	return entry
+}
+
+// ObserveAnnounced 봇이 특수/히든으로 알려준 아이템 기록
+func (c *ItemCatalog) ObserveAnnounced(itemName string) {
+	if itemName == "" {
+		return
+	}
+	c.mu.Lock()
+	entry := c.entry(itemName)
+	first := !entry.Announced
+	if first {
+		entry.Announced = true
+		c.dirty = true
+	}
+	c.mu.Unlock()
+	if first {
+		logger.Info("[아이템 카탈로그] 특수 확인: %s", itemName)
+	}
+	c.saveIfDue()
+}
+
+// ObserveFarm 파밍으로 얻은 아이템 기록 (announced: 봇이 특수/히든으로 알려줬는지)
+func (c *ItemCatalog) ObserveFarm(itemName string, announced bool) {
+	if itemName == "" {
+		return
+	}
+	if announced {
+		c.ObserveAnnounced(itemName)
+	}
+	c.mu.Lock()
+	c.entry(itemName).Farmed++
+	c.farmTotal++
+	c.dirty = true
+	c.mu.Unlock()
+	c.saveIfDue()
+}
+
+// ObserveSale 판매가로 타입 신호 기록 (레벨별 일반/특수 평균가로 구분할 수 없으면 무시)
+func (c *ItemCatalog) ObserveSale(itemName string, level, gold int) {
+	if itemName == "" || level <= 0 || gold <= 0 {
+		return
+	}
+	signal := saleTypeSignal(level, gold)
+	if signal == "" {
+		return
+	}
+	c.mu.Lock()
+	entry := c.entry(itemName)
+	if signal == "special" {
+		entry.SaleSpecial++
+	} else {
+		entry.SaleNormal++
+	}
+	c.dirty = true
+	c.mu.Unlock()
+	c.saveIfDue()
+}
+
+// recordFarming 파밍 결과를 텔레메트리와 아이템 카탈로그에 기록
+func (e *Engine) recordFarming(itemName, itemType string, announced bool) {
+	e.telem.RecordFarmingWithItem(itemName, itemType)
+	catalog().ObserveFarm(itemName, announced)
+}
+
+// saleTypeSignal 판매가가 레벨별 일반 / 특수 평균가 중 어느 쪽에 가까운지 (로그 비율 기준)
+// 평균가를 모르거나 두 평균이 비슷하면 빈 문자열
+func saleTypeSignal(level, gold int) string {
+	normal := typeAvgPrice("normal", level)
+	special := typeAvgPrice("special", level)
+	if normal <= 0 || special <= 0 {
+		return ""
+	}
+	ratio := float64(special) / float64(normal)
+	if ratio < salePriceGap && ratio > 1/salePriceGap {
+		return ""
+	}
+	g := math.Log(float64(gold))
+	if math.Abs(g-math.Log(float64(special))) < math.Abs(g-math.Log(float64(normal))) {
+		return "special"
+	}
+	return "normal"
+}
+
+// typeAvgPrice 서버 통계의 타입+레벨별 평균 판매가 (없으면 0)
+func typeAvgPrice(itemType string, level int) int {
+	for _, eff := range GetLevelEfficienciesByType(itemType) {
+		if eff.Level == level {
+			return eff.AvgPrice
+		}
+	}
+	return 0
+}
+
+// merge 서버 카탈로그 반영 (서버 목록에 없는 항목은 커뮤니티 타입 제거)
+func (c *ItemCatalog) merge(items []ItemCatalogItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range c.entries {
+		if entry.Community != "" || entry.CommunitySamples != 0 {
+			entry.Community, entry.CommunitySamples = "", 0
+			c.dirty = true
+		}
+	}
+	for _, item := range items {
+		if item.Name == "" || item.Type == "" {
+			continue
+		}
+		entry := c.entry(item.Name)
+		entry.Community = item.Type
+		entry.CommunitySamples = item.Samples
+		c.dirty = true
+	}
+}
+
+// ItemCatalogItem 서버 카탈로그 항목
+type ItemCatalogItem struct {
+	Name    string `json:"name"`
+	Type    string `json:"type,omitempty"` // 확정 타입 (보고가 갈리면 빈 문자열)
+	Samples int    `json:"samples"`
+}
+
+// FetchItemCatalog 서버에서 아이템 카탈로그 가져오기
+func FetchItemCatalog() ([]ItemCatalogItem, error) {
+	resp, err := conditionalGet(itemCatalogPath, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("서버 연결 실패: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("서버 오류: %d", resp.StatusCode)
+	}
+
+	var data struct {
+		Items []ItemCatalogItem `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("데이터 파싱 실패: %v", err)
+	}
+	return data.Items, nil
+}
+
+// SyncItemCatalog 서버 카탈로그를 받아 로컬 카탈로그에 반영하고 저장 (앱 시작 시 호출)
+// 서버에 연결할 수 없으면 로컬 카탈로그를 그대로 사용
+func SyncItemCatalog() {
+	items, err := FetchItemCatalog()
+	if err != nil {
+		logger.Error("[아이템 카탈로그] 동기화 실패: %v", err)
+		return
+	}
+	c := catalog()
+	c.merge(items)
+	c.Save()
+	logger.Info("[아이템 카탈로그] 서버 항목 %d개 반영", len(items))
+}
@@ -0,0 +1,116 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestItemCatalog(t *testing.T) *ItemCatalog {
+	t.Helper()
+	return &ItemCatalog{path: filepath.Join(t.TempDir(), itemCatalogFile), entries: make(map[string]*CatalogEntry)}
+}
+
+func TestCatalogEntryItemType(t *testing.T) {
+	cases := []struct {
+		name  string
+		entry CatalogEntry
+		want  string
+	}{
+		{"no signal", CatalogEntry{}, ""},
+		{"announced beats sales", CatalogEntry{Announced: true, SaleNormal: 5}, "special"},
+		{"sales beat community", CatalogEntry{SaleNormal: 4, SaleSpecial: 1, Community: "special"}, "normal"},
+		{"special sales", CatalogEntry{SaleSpecial: 2}, "special"},
+		{"too few sales", CatalogEntry{SaleSpecial: 1, Community: "normal"}, "normal"},
+		{"split sales fall back", CatalogEntry{SaleNormal: 2, SaleSpecial: 2, Community: "special"}, "special"},
+		{"split sales only", CatalogEntry{SaleNormal: 2, SaleSpecial: 2}, ""},
+		{"unknown community type", CatalogEntry{Community: "trash"}, ""},
+	}
+	for _, c := range cases {
+		if got := c.entry.itemType(0); got != c.want {
+			t.Errorf("%s: itemType = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestCatalogMergeAndLookup(t *testing.T) {
+	c := newTestItemCatalog(t)
+	c.ObserveAnnounced("붉은 눈동자")
+	c.merge([]ItemCatalogItem{
+		{Name: "이상한 막대", Type: "normal", Samples: 12},
+		{Name: "푸른 광선검", Type: "special", Samples: 3},
+		{Name: "갈린 검", Samples: 4}, // 보고가 갈림
+	})
+
+	lookups := map[string]string{
+		"붉은 눈동자":  "special",
+		"이상한 막대":  "normal",
+		"푸른 광선검":  "special",
+		"갈린 검":    "",
+		"처음 보는 검": "",
+	}
+	for name, want := range lookups {
+		got, ok := c.Lookup(name)
+		if got != want || ok != (want != "") {
+			t.Errorf("Lookup(%s) = (%q, %v), want %q", name, got, ok, want)
+		}
+	}
+
+	// 서버 목록에서 빠진 항목은 커뮤니티 타입 제거, 봇 안내는 유지
+	c.merge([]ItemCatalogItem{{Name: "이상한 막대", Type: "normal", Samples: 13}})
+	if _, ok := c.Lookup("푸른 광선검"); ok {
+		t.Error("community type should be dropped when the server no longer lists the item")
+	}
+	if got, _ := c.Lookup("붉은 눈동자"); got != "special" {
+		t.Errorf("announced type = %q after merge, want special", got)
+	}
+}
+
+func TestCatalogFarmFrequency(t *testing.T) {
+	c := newTestItemCatalog(t)
+
+	for i := 0; i < minFarmVotes-1; i++ {
+		c.ObserveFarm("이상한 막대", false)
+	}
+	c.ObserveFarm("붉은 눈동자", false)
+	if itemType, ok := c.Lookup("이상한 막대"); ok {
+		t.Fatalf("below min farm votes: type = %s, want unknown", itemType)
+	}
+
+	c.ObserveFarm("이상한 막대", false)
+	if itemType, ok := c.Lookup("이상한 막대"); !ok || itemType != "normal" {
+		t.Errorf("frequent item type = %q (ok=%v), want normal", itemType, ok)
+	}
+	if itemType, ok := c.Lookup("붉은 눈동자"); ok {
+		t.Errorf("rare item type = %s, want unknown", itemType)
+	}
+
+	// 봇 안내는 빈도보다 우선
+	c.ObserveFarm("이상한 막대", true)
+	if itemType, _ := c.Lookup("이상한 막대"); itemType != "special" {
+		t.Errorf("announced item type = %s, want special", itemType)
+	}
+
+	// 관찰은 저장 간격 안에서 한 번만 기록, 종료 시 Save로 나머지 기록
+	if _, err := os.Stat(c.path); err != nil {
+		t.Fatalf("first observation not saved: %v", err)
+	}
+	if !c.dirty {
+		t.Error("observations within save interval should stay pending")
+	}
+	c.Save()
+	if c.dirty {
+		t.Error("Save should flush pending observations")
+	}
+}
+
+func TestParseOCRTextAnnouncedSpecial(t *testing.T) {
+	text := "✨ 히든 아이템 『붉은 눈동자』 발견!\n⚔️획득 검: [+0] 붉은 눈동자"
+	state := ParseOCRText(text)
+	if state == nil {
+		t.Fatal("ParseOCRText returned nil")
+	}
+	if state.ItemName != "붉은 눈동자" || !state.Announced || state.ItemType != "special" {
+		t.Errorf("state = %+v, want announced special 붉은 눈동자", state)
+	}
+}
@@ -0,0 +1,235 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/logger"
+)
+
+// ========================
+// 상대 정보 DB (opponents.json)
+// ========================
+//
+// 프로필 / 랭킹 / 배틀 결과 / 모니터링 배틀에서 본 다른 유저 정보를 실행 간에 유지
+// 배틀 모드는 TTL 안의 레벨 정보를 재사용하고 오래된 유저만 /프로필로 다시 조회
+
+const (
+	opponentsFile     = "opponents.json"
+	opponentRetention = 30 * 24 * time.Hour // 이 기간 동안 보이지 않은 유저는 삭제
+)
+
+// Opponent 다른 유저 정보
+type Opponent struct {
+	Username  string `json:"username"`
+	Level     int    `json:"level"`
+	SwordName string `json:"sword_name,omitempty"`
+	Wins      int    `json:"wins"`   // 전체 승리 수 (프로필 / 랭킹 기준)
+	Losses    int    `json:"losses"` // 전체 패배 수 (프로필 / 랭킹 기준)
+	Gold      int    `json:"gold,omitempty"`
+
+	LevelAt  int64 `json:"level_at,omitempty"` // 레벨을 확인한 시각 (프로필 / 배틀 결과, Unix)
+	LastSeen int64 `json:"last_seen"`          // 마지막으로 본 시각 (Unix)
+
+	H2H         headToHead `json:"h2h"`                   // 나와의 전적
+	Blacklisted bool       `json:"blacklisted,omitempty"` // 나와의 전적으로 자동 블랙리스트된 유저
+}
+
+// profile 저장된 정보를 Profile로 변환
+func (o *Opponent) profile() *Profile {
+	return &Profile{
+		Name:      o.Username,
+		Level:     o.Level,
+		SwordName: o.SwordName,
+		Wins:      o.Wins,
+		Losses:    o.Losses,
+		Gold:      o.Gold,
+	}
+}
+
+// OpponentDB 유저명 → 상대 정보
+type OpponentDB struct {
+	path      string
+	opponents map[string]*Opponent
+	dirty     bool
+}
+
+// LoadOpponentDB 실행 파일 옆 opponents.json 로드 (없거나 손상되면 빈 DB)
+func LoadOpponentDB() *OpponentDB {
+	path := opponentsFile
+	if exe, err := os.Executable(); err == nil {
+		path = filepath.Join(filepath.Dir(exe), opponentsFile)
+	}
+	db := &OpponentDB{path: path, opponents: make(map[string]*Opponent)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return db
+	}
+	var list []*Opponent
+	if err := json.Unmarshal(data, &list); err != nil {
+		logger.Error("[상대 DB] 파일 손상: %v", err)
+		return db
+	}
+	cutoff := time.Now().Add(-opponentRetention).Unix()
+	for _, o := range list {
+		if o.Username == "" || o.LastSeen < cutoff {
+			db.dirty = true
+			continue
+		}
+		db.opponents[o.Username] = o
+	}
+	return db
+}
+
+// Save 변경 사항이 있으면 디스크에 기록 (임시 파일 → rename)
+func (db *OpponentDB) Save() {
+	if !db.dirty {
+		return
+	}
+	list := make([]*Opponent, 0, len(db.opponents))
+	for _, o := range db.opponents {
+		list = append(list, o)
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return
+	}
+	tmp := db.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		logger.Error("[상대 DB] 저장 실패: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, db.path); err != nil {
+		logger.Error("[상대 DB] 저장 실패: %v", err)
+		return
+	}
+	db.dirty = false
+}
+
+// Get 유저 정보 (없으면 nil)
+func (db *OpponentDB) Get(username string) *Opponent {
+	return db.opponents[username]
+}
+
+// entry 유저 정보 (없으면 생성), 마지막으로 본 시각 갱신
+func (db *OpponentDB) entry(username string, now time.Time) *Opponent {
+	o := db.opponents[username]
+	if o == nil {
+		o = &Opponent{Username: username}
+		db.opponents[username] = o
+	}
+	o.LastSeen = now.Unix()
+	db.dirty = true
+	return o
+}
+
+// Fresh ttl 안에 레벨을 확인한 유저 정보 (오래됐거나 없으면 nil)
+func (db *OpponentDB) Fresh(username string, ttl time.Duration) *Opponent {
+	o := db.opponents[username]
+	if o == nil || o.LevelAt == 0 || time.Since(time.Unix(o.LevelAt, 0)) > ttl {
+		return nil
+	}
+	return o
+}
+
+// HeadToHead 나와의 전적 (없으면 nil)
+func (db *OpponentDB) HeadToHead(username string) *headToHead {
+	if o := db.opponents[username]; o != nil {
+		return &o.H2H
+	}
+	return nil
+}
+
+// IsBlacklisted 자동 블랙리스트된 유저인지
+func (db *OpponentDB) IsBlacklisted(username string) bool {
+	o := db.opponents[username]
+	return o != nil && o.Blacklisted
+}
+
+// Blacklist 유저를 자동 블랙리스트에 추가
+func (db *OpponentDB) Blacklist(username string) {
+	db.entry(username, time.Now()).Blacklisted = true
+}
+
+// BlacklistCount 자동 블랙리스트된 유저 수
+func (db *OpponentDB) BlacklistCount() int {
+	n := 0
+	for _, o := range db.opponents {
+		if o.Blacklisted {
+			n++
+		}
+	}
+	return n
+}
+
+// ClearBlacklist 자동 블랙리스트 초기화
+func (db *OpponentDB) ClearBlacklist() {
+	for _, o := range db.opponents {
+		if o.Blacklisted {
+			o.Blacklisted = false
+			db.dirty = true
+		}
+	}
+}
+
+// ObserveProfile 다른 유저 프로필 반영
+func (db *OpponentDB) ObserveProfile(p *Profile) {
+	if p == nil || p.Name == "" || p.Level < 0 {
+		return
+	}
+	now := time.Now()
+	o := db.entry(p.Name, now)
+	o.Level = p.Level
+	o.SwordName = p.SwordName
+	o.Wins = p.Wins
+	o.Losses = p.Losses
+	o.Gold = p.Gold
+	o.LevelAt = now.Unix()
+}
+
+// ObserveRanking 랭킹 반영 (배틀 랭킹의 승패만 사용, 강화 랭킹 레벨은 현재 검이 아닐 수 있어 무시)
+func (db *OpponentDB) ObserveRanking(entries []RankingEntry, myName string) {
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.Username == "" || entry.Username == myName {
+			continue
+		}
+		o := db.entry(entry.Username, now)
+		if entry.Wins > 0 || entry.Losses > 0 {
+			o.Wins = entry.Wins
+			o.Losses = entry.Losses
+		}
+	}
+}
+
+// ObserveBattle 배틀 결과 반영 (레벨, 내가 참가했으면 나와의 전적)
+// 전체 승패는 프로필 / 랭킹 값을 그대로 쓰므로 여기서 더하지 않음 (중복 집계 방지)
+// 레벨을 모르면 -1
+func (db *OpponentDB) ObserveBattle(winner, loser string, winnerLevel, loserLevel int, myName string) {
+	now := time.Now()
+	if winner != "" && winner != myName {
+		o := db.entry(winner, now)
+		if winnerLevel >= 0 {
+			o.Level = winnerLevel
+			o.LevelAt = now.Unix()
+		}
+		if myName != "" && loser == myName {
+			o.H2H.Battles++
+			o.H2H.Losses++
+		}
+	}
+	if loser != "" && loser != myName {
+		o := db.entry(loser, now)
+		if loserLevel >= 0 {
+			o.Level = loserLevel
+			o.LevelAt = now.Unix()
+		}
+		if myName != "" && winner == myName {
+			o.H2H.Battles++
+			o.H2H.Wins++
+		}
+	}
+}
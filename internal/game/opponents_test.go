@@ -0,0 +1,107 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestOpponentDB() *OpponentDB {
+	return &OpponentDB{opponents: make(map[string]*Opponent)}
+}
+
+func TestOpponentDBProfileAndRanking(t *testing.T) {
+	db := newTestOpponentDB()
+	db.ObserveProfile(&Profile{Name: "@상대", Level: 10, SwordName: "불꽃검", Wins: 30, Losses: 20, Gold: 5000})
+	db.ObserveProfile(&Profile{Name: "", Level: 3})
+
+	o := db.Fresh("@상대", time.Minute)
+	if o == nil || o.Level != 10 || o.SwordName != "불꽃검" || o.Wins != 30 || o.Losses != 20 {
+		t.Fatalf("fresh opponent = %+v", o)
+	}
+	o.LevelAt = time.Now().Add(-2 * time.Minute).Unix()
+	if db.Fresh("@상대", time.Minute) != nil {
+		t.Error("stale level should not be fresh")
+	}
+	if len(db.opponents) != 1 {
+		t.Errorf("opponents = %d, want 1 (empty name ignored)", len(db.opponents))
+	}
+
+	db.ObserveRanking([]RankingEntry{
+		{Username: "@상대", Wins: 40, Losses: 25},
+		{Username: "@나", Wins: 1, Losses: 1},
+		{Username: "@신규"},
+	}, "@나")
+	if o := db.Get("@상대"); o.Wins != 40 || o.Losses != 25 {
+		t.Errorf("ranking record = %d/%d, want 40/25", o.Wins, o.Losses)
+	}
+	if db.Get("@나") != nil {
+		t.Error("my own ranking entry should be skipped")
+	}
+	if o := db.Get("@신규"); o == nil || o.Wins != 0 || db.Fresh("@신규", time.Hour) != nil {
+		t.Errorf("ranking-only opponent = %+v, want seen without level", o)
+	}
+}
+
+func TestObserveBattleHeadToHead(t *testing.T) {
+	db := newTestOpponentDB()
+
+	db.ObserveBattle("@상대", "@나", 11, 9, "@나")
+	db.ObserveBattle("@나", "@상대", 9, -1, "@나")
+	db.ObserveBattle("@상대", "@제3자", 12, 8, "@나")
+
+	o := db.Get("@상대")
+	if o.Level != 12 {
+		t.Errorf("level = %d, want 12 (unknown level keeps last)", o.Level)
+	}
+	if want := (headToHead{Battles: 2, Wins: 1, Losses: 1}); *db.HeadToHead("@상대") != want {
+		t.Errorf("h2h = %+v, want %+v", *db.HeadToHead("@상대"), want)
+	}
+	if h := db.HeadToHead("@제3자"); h == nil || h.Battles != 0 {
+		t.Errorf("third party h2h = %+v, want seen with no battles", h)
+	}
+	if db.Get("@나") != nil || db.HeadToHead("@없음") != nil {
+		t.Error("my own entry / unknown user should not exist")
+	}
+}
+
+func TestObserveBattleKeepsProfileRecord(t *testing.T) {
+	db := &OpponentDB{opponents: make(map[string]*Opponent)}
+	db.ObserveProfile(&Profile{Name: "상대", Level: 10, Wins: 30, Losses: 20})
+
+	db.ObserveBattle("상대", "나", 11, 9, "나")
+	db.ObserveBattle("나", "상대", 9, 11, "나")
+	db.ObserveBattle("상대", "제3자", 11, 8, "나")
+
+	o := db.Get("상대")
+	if o.Wins != 30 || o.Losses != 20 {
+		t.Errorf("overall record = %d/%d, want profile record 30/20", o.Wins, o.Losses)
+	}
+	if o.Level != 11 {
+		t.Errorf("level = %d, want 11", o.Level)
+	}
+	if want := (headToHead{Battles: 2, Wins: 1, Losses: 1}); o.H2H != want {
+		t.Errorf("h2h = %+v, want %+v", o.H2H, want)
+	}
+	if third := db.Get("제3자"); third == nil || third.H2H.Battles != 0 || third.Wins != 0 || third.Losses != 0 {
+		t.Errorf("third party = %+v, want seen with no record", third)
+	}
+}
+
+func TestObserveBattleWithoutMyName(t *testing.T) {
+	db := newTestOpponentDB()
+
+	// 모니터 모드: 내 이름을 모르고, 한쪽 이름을 못 읽은 결과도 들어올 수 있음
+	db.ObserveBattle("@상대", "", 11, -1, "")
+	db.ObserveBattle("", "@상대", -1, 10, "")
+	db.ObserveBattle("@상대", "@제3자", 12, 8, "")
+
+	if o := db.Get("@상대"); o == nil || o.Level != 12 {
+		t.Fatalf("opponent = %+v, want level 12", o)
+	}
+	if h := db.HeadToHead("@상대"); h == nil || h.Battles != 0 || h.Wins != 0 || h.Losses != 0 {
+		t.Errorf("h2h = %+v, want no battles without my name", h)
+	}
+	if db.Get("") != nil {
+		t.Error("empty name should not be recorded")
+	}
+}
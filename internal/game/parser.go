@@ -21,6 +21,7 @@ type GameState struct {
 	Gold        int
 	ItemType    string // "trash"(쓰레기), "special"(특수), "normal"(일반), "none"
 	ItemName    string // 아이템 이름 (검, 방망이 등)
+	Announced   bool   // 봇이 이 아이템을 특수/히든으로 알려줌
 	LastResult  string // "success", "hold", "destroy", ""
 }
 
@@ -145,6 +146,11 @@ func ParseOCRText(text string) *GameState {
 	// 아이템 이름 먼저 추출
 	state.ItemName = ExtractItemName(text)
 
+	// 봇이 특수/히든이라고 알려준 아이템 (카탈로그 기록은 엔진이 파밍 시점에 함)
+	if name := ExtractSpecialName(text); name != "" && name == state.ItemName {
+		state.Announced = true
+	}
+
 	// 아이템 판별 로직 (v3):
 	// 아이템 이름 기반으로 판별 (전체 텍스트가 아닌 추출된 이름만 검사)
 	// 1. 이름에 "낡은" 포함 → 쓰레기
@@ -153,6 +159,8 @@ func ParseOCRText(text string) *GameState {
 	if state.ItemName != "" {
 		if trashPattern.MatchString(strings.ToLower(state.ItemName)) {
 			state.ItemType = "trash"
+		} else if state.Announced {
+			state.ItemType = "special"
 		} else {
 			state.ItemType = DetermineItemType(state.ItemName)
 		}
@@ -428,14 +436,19 @@ func ExtractDestroyNewSword(text string) (string, int, bool) {
 	return "", 0, false
 }
 
-// DetermineItemType 아이템 이름으로 타입 결정 (v4 로직)
-// 1. 특수 아이템 패턴 먼저 체크 (광선검 등 일반 접미사 포함하는 특수 아이템)
-// 2. 일반 무기 패턴 체크 (몽둥이, 망치, 검, 칼, 도끼)
-// 3. 그 외 전부 → "special"
+// DetermineItemType 아이템 이름으로 타입 결정 (v5 로직)
+// 1. 아이템 카탈로그 (봇 특수 안내, 판매가, 서버 카탈로그로 학습한 타입)
+// 2. 특수 아이템 패턴 체크 (광선검 등 일반 접미사 포함하는 특수 아이템)
+// 3. 일반 무기 패턴 체크 (몽둥이, 망치, 검, 칼, 도끼)
+// 4. 그 외 전부 → "special"
 func DetermineItemType(itemName string) string {
 	if itemName == "" {
 		return "unknown"
 	}
+	// 0순위: 학습된 카탈로그 (새 아이템도 코드 수정 없이 분류)
+	if itemType, ok := catalog().Lookup(itemName); ok {
+		return itemType
+	}
 	// 1순위: 특수 아이템 패턴 (광선검처럼 일반 무기 접미사를 포함하는 특수 아이템)
 	if specialWeaponPattern.MatchString(itemName) {
 		return "special"
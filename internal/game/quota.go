@@ -0,0 +1,104 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/logger"
+)
+
+// ========================
+// 일일 배틀 횟수 (battle_quota.json)
+// ========================
+//
+// 게임은 하루 10회까지 배틀을 허용하고 한국 시간 자정에 초기화
+// 사용 횟수를 파일에 남겨 중간에 재시작해도 남은 횟수를 알 수 있게 함
+
+const (
+	battleQuotaFile  = "battle_quota.json"
+	dailyBattleLimit = 10
+)
+
+// gameDayZone 게임 일자 기준 시간대 (한국 시간)
+var gameDayZone = time.FixedZone("KST", 9*60*60)
+
+// BattleQuota 게임 일자별 배틀 사용 횟수
+type BattleQuota struct {
+	path string
+	Day  string `json:"day"`  // 게임 일자 (2006-01-02, 한국 시간)
+	Used int    `json:"used"` // 이 날 사용한 배틀 수
+}
+
+// gameDay 게임 일자
+func gameDay(now time.Time) string {
+	return now.In(gameDayZone).Format("2006-01-02")
+}
+
+// untilBattleReset 다음 초기화(한국 시간 자정)까지 남은 시간
+func untilBattleReset(now time.Time) time.Duration {
+	local := now.In(gameDayZone)
+	next := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, gameDayZone)
+	return next.Sub(now)
+}
+
+// LoadBattleQuota 실행 파일 옆 battle_quota.json 로드 (없거나 손상되면 0회 사용)
+func LoadBattleQuota() *BattleQuota {
+	path := battleQuotaFile
+	if exe, err := os.Executable(); err == nil {
+		path = filepath.Join(filepath.Dir(exe), battleQuotaFile)
+	}
+	q := &BattleQuota{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return q
+	}
+	if err := json.Unmarshal(data, q); err != nil {
+		logger.Error("[배틀 횟수] 파일 손상: %v", err)
+		q.Day, q.Used = "", 0
+	}
+	return q
+}
+
+// roll 날짜가 바뀌었으면 사용 횟수 초기화
+func (q *BattleQuota) roll(now time.Time) {
+	if day := gameDay(now); q.Day != day {
+		q.Day = day
+		q.Used = 0
+	}
+}
+
+// Remaining 오늘 남은 배틀 수
+func (q *BattleQuota) Remaining(now time.Time) int {
+	q.roll(now)
+	if q.Used >= dailyBattleLimit {
+		return 0
+	}
+	return dailyBattleLimit - q.Used
+}
+
+// Use 배틀 1회 사용 기록
+func (q *BattleQuota) Use(now time.Time) {
+	q.roll(now)
+	q.Used++
+	q.save()
+}
+
+// Exhaust 게임이 제한 도달을 알려왔을 때 오늘 횟수를 모두 사용한 것으로 기록
+func (q *BattleQuota) Exhaust(now time.Time) {
+	q.roll(now)
+	q.Used = dailyBattleLimit
+	q.save()
+}
+
+func (q *BattleQuota) save() {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(q.path, data, 0644); err != nil {
+		logger.Error("[배틀 횟수] 저장 실패: %v", err)
+	}
+}
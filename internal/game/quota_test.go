@@ -0,0 +1,41 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBattleQuotaKSTRollover(t *testing.T) {
+	q := &BattleQuota{path: filepath.Join(t.TempDir(), battleQuotaFile)}
+
+	// 2026-03-01 23:50 KST = 14:50 UTC
+	beforeMidnight := time.Date(2026, 3, 1, 14, 50, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		q.Use(beforeMidnight)
+	}
+	if got := q.Remaining(beforeMidnight); got != dailyBattleLimit-3 {
+		t.Fatalf("remaining = %d, want %d", got, dailyBattleLimit-3)
+	}
+	if got := untilBattleReset(beforeMidnight); got != 10*time.Minute {
+		t.Errorf("untilBattleReset = %v, want 10m", got)
+	}
+
+	// UTC로는 같은 날이지만 한국 시간으로는 다음 날 (00:10 KST)
+	afterMidnight := beforeMidnight.Add(20 * time.Minute)
+	if got := q.Remaining(afterMidnight); got != dailyBattleLimit {
+		t.Errorf("remaining after KST midnight = %d, want %d", got, dailyBattleLimit)
+	}
+	if q.Day != "2026-03-02" {
+		t.Errorf("day = %s, want 2026-03-02", q.Day)
+	}
+
+	// 한도 도달 후에는 0, 다음 날 자정에 다시 초기화
+	q.Exhaust(afterMidnight)
+	if got := q.Remaining(afterMidnight.Add(23 * time.Hour)); got != 0 {
+		t.Errorf("remaining same KST day = %d, want 0", got)
+	}
+	if got := q.Remaining(afterMidnight.Add(24 * time.Hour)); got != dailyBattleLimit {
+		t.Errorf("remaining next KST day = %d, want %d", got, dailyBattleLimit)
+	}
+}
@@ -0,0 +1,80 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/StopDragon/sword-macro-ai/internal/logger"
+)
+
+// ========================
+// /프로필 상태 재동기화
+// ========================
+//
+// 강화 루프는 ResultLevel(없으면 성공 시 +1)로 레벨을 추정하므로 읽기 실패가 쌓이면 실제와 어긋날 수 있음
+// 다음 경우 /강화 전에 /프로필을 보내 레벨 / 검 이름 / 골드를 실제 값으로 맞춤
+//   - resync_every 회 강화마다 (0이면 주기 확인 안 함)
+//   - 결과를 확실히 읽지 못했을 때 (결과 불명확, 성공인데 결과 레벨 없음)
+//   - 레벨이 2 이상 점프했을 때
+// 어긋난 횟수는 세션 통계와 텔레메트리(state_resyncs / state_drifts)에 기록
+
+// requestResync 다음 /강화 전에 재동기화 요청 (먼저 들어온 이유 유지)
+func (e *Engine) requestResync(reason string) {
+	if e.resyncReason == "" {
+		e.resyncReason = reason
+	}
+}
+
+// countEnhanceAction 강화 1회 기록, 주기가 되면 재동기화 요청
+func (e *Engine) countEnhanceAction() {
+	e.actionsSinceResync++
+	if e.cfg.ResyncEvery > 0 && e.actionsSinceResync >= e.cfg.ResyncEvery {
+		e.requestResync(fmt.Sprintf("%d회 주기", e.cfg.ResyncEvery))
+	}
+}
+
+// resyncIfNeeded 재동기화 요청이 있으면 /프로필로 실제 상태 조회
+// 반환: 프로필 (요청이 없거나 조회 실패면 OK=false), 레벨 / 검 이름이 내부 상태와 달랐는지
+func (e *Engine) resyncIfNeeded(level int, swordName string) (ProfileCheckResult, bool) {
+	reason := e.resyncReason
+	if reason == "" {
+		return ProfileCheckResult{}, false
+	}
+	e.resyncReason = ""
+	e.actionsSinceResync = 0
+
+	profile := e.CheckProfileLevel()
+	if !profile.OK {
+		logger.Error("[재동기화] 프로필 조회 실패 (%s)", reason)
+		return profile, false
+	}
+	return profile, e.applyResync(reason, level, swordName, profile)
+}
+
+// applyResync 조회한 프로필을 내부 상태와 비교해 기록하고 세션 프로필 보정
+// 반환: 레벨 / 검 이름이 내부 상태와 달랐는지
+func (e *Engine) applyResync(reason string, level int, swordName string, profile ProfileCheckResult) bool {
+	nameDrift := swordName != "" && profile.SwordName != "" && profile.SwordName != swordName
+	drift := profile.Level != level || nameDrift
+	e.sessionStats.resyncs++
+	if drift {
+		e.sessionStats.drifts++
+		fmt.Printf("  🔁 상태 보정 (%s): [+%d] %s → [+%d] %s\n", reason, level, swordName, profile.Level, profile.SwordName)
+		logger.Error("[재동기화] 상태 불일치 (%s): [+%d] %s → 실제 [+%d] %s",
+			reason, level, swordName, profile.Level, profile.SwordName)
+	} else {
+		logger.Info("[재동기화] 상태 일치 (%s): [+%d] %s", reason, profile.Level, profile.SwordName)
+	}
+	e.telem.RecordResync(drift)
+	e.reconcileKeptSpecials(profile.SwordName, profile.Level)
+
+	if e.sessionProfile != nil {
+		e.sessionProfile.Level = profile.Level
+		if profile.SwordName != "" {
+			e.sessionProfile.SwordName = profile.SwordName
+		}
+		if profile.Gold > 0 {
+			e.sessionProfile.Gold = profile.Gold
+		}
+	}
+	return drift
+}
@@ -0,0 +1,86 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/StopDragon/sword-macro-ai/internal/config"
+	"github.com/StopDragon/sword-macro-ai/internal/telemetry"
+)
+
+func TestCountEnhanceActionPeriod(t *testing.T) {
+	e := &Engine{cfg: &config.Config{ResyncEvery: 3}}
+	for i := 1; i <= 2; i++ {
+		e.countEnhanceAction()
+		if e.resyncReason != "" {
+			t.Fatalf("action %d: resync requested early (%s)", i, e.resyncReason)
+		}
+	}
+	e.countEnhanceAction()
+	if e.resyncReason != "3회 주기" {
+		t.Errorf("resync reason = %q, want period", e.resyncReason)
+	}
+
+	off := &Engine{cfg: &config.Config{}}
+	for i := 0; i < 100; i++ {
+		off.countEnhanceAction()
+	}
+	if off.resyncReason != "" {
+		t.Errorf("resync_every=0 requested resync (%s)", off.resyncReason)
+	}
+}
+
+func TestRequestResyncKeepsFirstReason(t *testing.T) {
+	e := &Engine{cfg: &config.Config{ResyncEvery: 1}}
+	e.requestResync("결과 불명확")
+	e.requestResync("레벨 점프")
+	e.countEnhanceAction()
+	if e.resyncReason != "결과 불명확" {
+		t.Errorf("resync reason = %q, want first reason", e.resyncReason)
+	}
+}
+
+func TestResyncIfNeededWithoutRequest(t *testing.T) {
+	e := &Engine{cfg: &config.Config{}}
+	if profile, drift := e.resyncIfNeeded(5, "푸른 광선검"); profile.OK || drift {
+		t.Errorf("resyncIfNeeded without request = (%+v, %v), want no profile check", profile, drift)
+	}
+}
+
+func TestApplyResyncCountsDrift(t *testing.T) {
+	e := &Engine{
+		cfg:            &config.Config{},
+		telem:          &telemetry.Telemetry{},
+		inventory:      &SpecialInventory{path: filepath.Join(t.TempDir(), "inventory.json")},
+		sessionProfile: &Profile{Level: 5, SwordName: "푸른 광선검", Gold: 1000},
+	}
+	cases := []struct {
+		name      string
+		level     int
+		swordName string
+		profile   ProfileCheckResult
+		drift     bool
+	}{
+		{"일치", 5, "푸른 광선검", ProfileCheckResult{Level: 5, SwordName: "푸른 광선검", OK: true}, false},
+		{"레벨 차이", 5, "푸른 광선검", ProfileCheckResult{Level: 7, SwordName: "푸른 광선검", OK: true}, true},
+		{"이름 차이", 7, "푸른 광선검", ProfileCheckResult{Level: 7, SwordName: "붉은 광선검", OK: true}, true},
+		{"이름 미확인", 7, "붉은 광선검", ProfileCheckResult{Level: 7, OK: true}, false},
+		{"내부 이름 없음", 7, "", ProfileCheckResult{Level: 7, SwordName: "붉은 광선검", OK: true}, false},
+	}
+	drifts := 0
+	for i, c := range cases {
+		if got := e.applyResync("테스트", c.level, c.swordName, c.profile); got != c.drift {
+			t.Errorf("%s: drift = %v, want %v", c.name, got, c.drift)
+		}
+		if c.drift {
+			drifts++
+		}
+		if e.sessionStats.resyncs != i+1 || e.sessionStats.drifts != drifts {
+			t.Errorf("%s: resyncs / drifts = %d / %d, want %d / %d", c.name, e.sessionStats.resyncs, e.sessionStats.drifts, i+1, drifts)
+		}
+	}
+	// 세션 프로필은 실제 값으로 보정 (이름 / 골드를 못 읽었으면 유지)
+	if p := e.sessionProfile; p.Level != 7 || p.SwordName != "붉은 광선검" || p.Gold != 1000 {
+		t.Errorf("session profile = %+v, want [+7] 붉은 광선검 with gold kept", p)
+	}
+}
@@ -0,0 +1,110 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// ========================
+// 특수 아이템 이름 지정 뽑기
+// ========================
+//
+// special_wanted: 찾을 특수 이름 (비우면 모든 특수를 보관/강화)
+// special_sell: 특수여도 보관할 가치가 없어 일반처럼 판매할 이름 (찾는 목록보다 우선)
+// 이름은 부분 일치 ("광선검"은 "푸른 광선검"도 포함)
+
+// matchesAnyName 아이템 이름이 목록 중 하나를 포함하는지
+func matchesAnyName(itemName string, names []string) bool {
+	if itemName == "" {
+		return false
+	}
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" && strings.Contains(itemName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepSpecial 찾던 특수 아이템인지 (false면 일반처럼 판매하고 계속 뽑기)
+func (e *Engine) keepSpecial(itemName string) bool {
+	if matchesAnyName(itemName, e.cfg.SpecialSell) {
+		return false
+	}
+	return len(e.cfg.SpecialWanted) == 0 || matchesAnyName(itemName, e.cfg.SpecialWanted)
+}
+
+// parseNameList 쉼표로 구분한 이름 목록
+func parseNameList(input string) []string {
+	var names []string
+	for _, name := range strings.Split(input, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// nameListLabel 목록 표시용 문자열
+func nameListLabel(names []string, empty string) string {
+	if len(names) == 0 {
+		return empty
+	}
+	return strings.Join(names, ", ")
+}
+
+// promptNameList 이름 목록 입력 (빈칸 = 유지, "-" = 비우기)
+func promptNameList(reader *bufio.Reader, label string, current []string, empty string) []string {
+	fmt.Printf("%s [현재: %s]\n", label, nameListLabel(current, empty))
+	fmt.Print("쉼표로 구분 (Enter = 유지, - = 비우기): ")
+	input, _ := reader.ReadString('\n')
+	switch input = strings.TrimSpace(input); input {
+	case "":
+		return current
+	case "-":
+		return nil
+	default:
+		return parseNameList(input)
+	}
+}
+
+// expectedFarmsUntilWanted 커뮤니티 출현율로 찾는 특수가 나올 때까지 예상 파밍 횟수
+// 반환: 예상 횟수, 파밍 1회당 확률 (출현 기록이 없으면 ok=false)
+func (e *Engine) expectedFarmsUntilWanted(stats *SpecialStats) (farms, prob float64, ok bool) {
+	if stats == nil || stats.TotalFarming <= 0 {
+		return 0, 0, false
+	}
+	found := 0
+	for _, s := range stats.Special {
+		if e.keepSpecial(s.Name) {
+			found += s.Count
+		}
+	}
+	if found == 0 {
+		return 0, 0, false
+	}
+	prob = float64(found) / float64(stats.TotalFarming)
+	return 1 / prob, prob, true
+}
+
+// printSpecialHuntPlan 찾는 목록 / 판매 목록과 예상 파밍 횟수 출력
+func (e *Engine) printSpecialHuntPlan() {
+	fmt.Printf("🎯 찾는 특수: %s\n", nameListLabel(e.cfg.SpecialWanted, "모든 특수"))
+	if len(e.cfg.SpecialSell) > 0 {
+		fmt.Printf("🗑️ 판매할 특수: %s\n", nameListLabel(e.cfg.SpecialSell, "-"))
+	}
+
+	stats, err := FetchSpecialStats()
+	if err != nil {
+		fmt.Printf("📊 예상 파밍 횟수: 알 수 없음 (%v)\n", err)
+		return
+	}
+	farms, prob, ok := e.expectedFarmsUntilWanted(stats)
+	if !ok {
+		fmt.Printf("📊 예상 파밍 횟수: 알 수 없음 (커뮤니티 출현 기록 없음, 전체 파밍 %d회)\n", stats.TotalFarming)
+		return
+	}
+	fmt.Printf("📊 예상 파밍 횟수: 약 %.0f회 (출현율 %.3f%%, 커뮤니티 파밍 %d회 기준)\n",
+		farms, prob*100, stats.TotalFarming)
+}
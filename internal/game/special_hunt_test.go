@@ -0,0 +1,106 @@
+package game
+
+import (
+	"bufio"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StopDragon/sword-macro-ai/internal/config"
+)
+
+func TestKeepSpecial(t *testing.T) {
+	cases := []struct {
+		name         string
+		wanted, sell []string
+		item         string
+		want         bool
+	}{
+		{"no lists keeps all", nil, nil, "푸른 광선검", true},
+		{"wanted substring", []string{"광선검"}, nil, "푸른 광선검", true},
+		{"not wanted", []string{"광선검"}, nil, "불꽃 마검", false},
+		{"sell only", nil, []string{"마검"}, "불꽃 마검", false},
+		{"sell beats wanted", []string{"광선검"}, []string{"푸른"}, "푸른 광선검", false},
+		{"sell does not match", []string{"광선검"}, []string{"붉은"}, "푸른 광선검", true},
+		{"blank names ignored", []string{" ", "광선검"}, []string{""}, "푸른 광선검", true},
+		{"empty item", []string{"광선검"}, nil, "", false},
+	}
+	for _, c := range cases {
+		e := &Engine{cfg: &config.Config{SpecialWanted: c.wanted, SpecialSell: c.sell}}
+		if got := e.keepSpecial(c.item); got != c.want {
+			t.Errorf("%s: keepSpecial(%q) = %v, want %v", c.name, c.item, got, c.want)
+		}
+	}
+}
+
+func TestParseNameList(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{" , ,", nil},
+		{"광선검", []string{"광선검"}},
+		{" 광선검 , 불꽃 마검,,", []string{"광선검", "불꽃 마검"}},
+	}
+	for _, c := range cases {
+		if got := parseNameList(c.input); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("parseNameList(%q) = %q, want %q", c.input, got, c.want)
+		}
+	}
+}
+
+func TestPromptNameList(t *testing.T) {
+	current := []string{"광선검"}
+	cases := []struct {
+		input string
+		want  []string
+	}{
+		{"\n", current},
+		{"", current}, // EOF
+		{" - \n", nil},
+		{"불꽃 마검, 얼음검\n", []string{"불꽃 마검", "얼음검"}},
+	}
+	for _, c := range cases {
+		reader := bufio.NewReader(strings.NewReader(c.input))
+		if got := promptNameList(reader, "찾을 특수", current, "모든 특수"); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("promptNameList(%q) = %q, want %q", c.input, got, c.want)
+		}
+	}
+}
+
+func TestExpectedFarmsUntilWanted(t *testing.T) {
+	stats := &SpecialStats{
+		TotalFarming: 1000,
+		Special: []SpecialEntry{
+			{Name: "푸른 광선검", Count: 4},
+			{Name: "붉은 광선검", Count: 1},
+			{Name: "불꽃 마검", Count: 5},
+		},
+	}
+	cases := []struct {
+		name         string
+		wanted, sell []string
+		stats        *SpecialStats
+		wantFarms    float64
+		wantOK       bool
+	}{
+		{"all specials", nil, nil, stats, 100, true},
+		{"wanted substring", []string{"광선검"}, nil, stats, 200, true},
+		{"sell excluded", []string{"광선검"}, []string{"붉은"}, stats, 250, true},
+		{"never seen", []string{"얼음검"}, nil, stats, 0, false},
+		{"no stats", nil, nil, nil, 0, false},
+		{"no farming", nil, nil, &SpecialStats{}, 0, false},
+	}
+	for _, c := range cases {
+		e := &Engine{cfg: &config.Config{SpecialWanted: c.wanted, SpecialSell: c.sell}}
+		farms, prob, ok := e.expectedFarmsUntilWanted(c.stats)
+		if ok != c.wantOK || math.Abs(farms-c.wantFarms) > 1e-9 {
+			t.Errorf("%s: expectedFarmsUntilWanted = (%.2f, %v), want (%.2f, %v)", c.name, farms, ok, c.wantFarms, c.wantOK)
+		}
+		if ok && math.Abs(prob*farms-1) > 1e-9 {
+			t.Errorf("%s: prob %.4f is not 1/farms", c.name, prob)
+		}
+	}
+}
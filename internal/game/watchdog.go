@@ -0,0 +1,196 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/input"
+	"github.com/StopDragon/sword-macro-ai/internal/logger"
+	"github.com/StopDragon/sword-macro-ai/internal/overlay"
+)
+
+// ========================
+// 채팅 읽기 감시 (watchdog)
+// ========================
+//
+// 창 이동 / 포커스 상실 / 클립보드 경합으로 채팅을 계속 읽지 못하면 루프가 헛돌기만 하므로
+// 명령 왕복(명령 전송 → 응답) 단위로 실패를 세어 메인 루프에서 단계적으로 복구
+// 한 왕복 안에서 빈 읽기 / 응답 대기 시간 초과가 있었고 끝내 새 응답을 받지 못하면 실패 1회
+//   1. 입력창 다시 클릭 (포커스 복구)
+//   2. 잠시 대기 (클립보드 경합 해소)
+//   3. 마지막 명령이 반복해도 안전하면 (/프로필, /랭킹) 다시 전송하고 응답 대기
+//   4. 경고음과 함께 일시정지 (F8 재개, F9 종료)
+// 읽기 / 대기 함수는 결과만 기록하고, 복구 단계는 모드 루프 맨 앞(chatWatchdogTick)에서만 실행
+// 단계마다 마지막 원문과 함께 로그에 기록
+
+// failureSteps 단계별 진입 기준 - 연속 실패한 명령 왕복 수 (인덱스 = 단계-1)
+var failureSteps = [...]int{2, 3, 4, 5}
+
+const (
+	watchdogWait    = 5 * time.Second // 2단계 대기 시간
+	resendWait      = 5 * time.Second // 3단계 재전송 후 응답 대기 시간
+	maxIncidentText = 300             // 로그에 남길 마지막 원문 길이 (뒤쪽 기준, 바이트)
+)
+
+// idempotentCommands 다시 보내도 게임 상태가 바뀌지 않는 명령
+var idempotentCommands = map[string]bool{
+	"/프로필": true,
+	"/랭킹":  true,
+}
+
+// chatWatchdog 채팅 읽기 장애 추적 상태
+type chatWatchdog struct {
+	pending     bool   // 마지막 명령의 새 응답을 아직 받지 못함
+	readFailed  bool   // 이번 왕복에서 마지막 읽기가 실패했거나 응답 대기 시간이 지남
+	failures    int    // 연속 실패한 명령 왕복 수 (새 응답을 받으면 초기화)
+	step        int    // 이번 장애에서 수행한 마지막 복구 단계 (0=정상)
+	lastRaw     string // 마지막으로 읽은 원문 (짧은 클립보드 잔여물 포함)
+	lastCommand string // 마지막으로 전송한 명령 (여러 단계로 입력한 명령은 빈 문자열)
+}
+
+// command 새 명령 왕복 시작 (이전 왕복이 실패로 끝났으면 실패 수 증가)
+func (w *chatWatchdog) command(cmd string) {
+	if w.pending && w.readFailed {
+		w.failures++
+	}
+	w.pending, w.readFailed = true, false
+	w.lastCommand = cmd
+}
+
+// read 클립보드 읽기 결과 (응답 여부는 모르므로 왕복을 끝내지 않음)
+func (w *chatWatchdog) read(raw string, ok bool) {
+	if raw != "" {
+		w.lastRaw = raw
+	}
+	if w.pending {
+		w.readFailed = !ok
+	}
+}
+
+// response 응답 대기 결과 (got: 대기 시간 안에 새 응답을 받았는지)
+func (w *chatWatchdog) response(got bool) {
+	if got {
+		w.pending, w.readFailed, w.failures = false, false, 0
+		return
+	}
+	if w.pending {
+		w.readFailed = true
+	}
+}
+
+// failed 연속 실패 수 (진행 중인 왕복이 이미 실패했으면 포함)
+func (w *chatWatchdog) failed() int {
+	if w.pending && w.readFailed {
+		return w.failures + 1
+	}
+	return w.failures
+}
+
+// watchdogStep 연속 실패 수로 정한 복구 단계 (0=정상)
+func (w *chatWatchdog) watchdogStep() int {
+	step := 0
+	for i, n := range failureSteps {
+		if w.failed() >= n {
+			step = i + 1
+		}
+	}
+	return step
+}
+
+// reset 감시 상태 초기화 (사용자 재개 시)
+func (w *chatWatchdog) reset() {
+	*w = chatWatchdog{lastRaw: w.lastRaw, lastCommand: w.lastCommand}
+}
+
+// noteCommand 전송한 명령 기록 (sendCommand에서 호출)
+func (e *Engine) noteCommand(cmd string) {
+	e.chatWatch.command(cmd)
+}
+
+// chatReadResult 클립보드 읽기 결과 기록 (raw: 필터 전 클립보드 내용, ok: 채팅으로 인정했는지)
+func (e *Engine) chatReadResult(raw string, ok bool) {
+	e.chatWatch.read(raw, ok)
+}
+
+// chatResponseResult 응답 대기 결과 기록 (got: 대기 시간 안에 새 응답을 받았는지)
+func (e *Engine) chatResponseResult(got bool) {
+	e.chatWatch.response(got)
+}
+
+// chatWatchdogTick 모드 루프 맨 앞에서 호출: 복구됐으면 기록, 실패가 쌓였으면 다음 복구 단계 실행
+// 단계는 장애마다 한 번씩만 실행하고, 기준을 여러 개 넘었으면 차례로 실행
+func (e *Engine) chatWatchdogTick() {
+	w := &e.chatWatch
+	if w.step > 0 && w.failed() == 0 {
+		fmt.Printf("  ✅ 채팅 읽기 복구됨 (%d단계에서)\n", w.step)
+		logger.Info("[채팅 감시] 복구 (%d단계)", w.step)
+		w.step = 0
+		return
+	}
+
+	for w.step < w.watchdogStep() && e.running {
+		w.step++
+		if w.step == 1 {
+			e.sessionStats.chatIncidents++
+		}
+		e.logChatIncident()
+
+		switch w.step {
+		case 1:
+			fmt.Println("  🩺 채팅 읽기 실패 - 입력창 다시 클릭")
+			input.Click(e.cfg.ClickX, e.cfg.ClickY)
+			time.Sleep(200 * time.Millisecond)
+		case 2:
+			fmt.Printf("  🩺 채팅 읽기 실패 - %d초 대기\n", int(watchdogWait.Seconds()))
+			e.sleepWithHotkeyCheck(watchdogWait)
+		case 3:
+			if cmd := w.lastCommand; idempotentCommands[cmd] {
+				fmt.Printf("  🩺 채팅 읽기 실패 - %s 다시 전송\n", cmd)
+				e.sendCommand(cmd)
+				e.waitForResponse(resendWait)
+			} else {
+				fmt.Println("  🩺 채팅 읽기 실패 - 마지막 명령은 다시 보낼 수 없어 재전송 생략")
+			}
+		case 4:
+			e.pauseForChatFailure()
+			return
+		}
+	}
+}
+
+// logChatIncident 복구 단계 진입을 마지막 원문과 함께 기록
+func (e *Engine) logChatIncident() {
+	w := &e.chatWatch
+	raw := w.lastRaw
+	if len(raw) > maxIncidentText {
+		raw = "..." + raw[len(raw)-maxIncidentText:]
+	}
+	logger.Error("[채팅 감시] %d단계 진입: 연속 실패 왕복 %d회, 마지막 명령 %q, 마지막 원문 %q",
+		w.step, w.failed(), w.lastCommand, raw)
+}
+
+// pauseForChatFailure 복구 실패 시 경고 후 F8(재개) / F9(종료)까지 일시정지
+func (e *Engine) pauseForChatFailure() {
+	fmt.Print("\a")
+	fmt.Println()
+	fmt.Println("🚨 채팅을 계속 읽지 못해 일시정지했습니다")
+	fmt.Println("   카카오톡 창 위치 / 포커스 / 입력창 좌표를 확인한 뒤")
+	fmt.Println("   F8: 재개 | F9: 종료")
+	overlay.UpdateStatus("🚨 채팅 읽기 실패 - 일시정지\n\nF8: 재개 / F9: 종료")
+
+	for {
+		overlay.PumpEvents()
+		if e.checkStop() {
+			return
+		}
+		if input.CheckF8Pressed() || overlay.CheckPauseClicked() {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	// 재개하면 처음 단계부터 다시 감시
+	e.chatWatch.reset()
+	logger.Info("[채팅 감시] 사용자 재개")
+	fmt.Println("▶️ 재개합니다")
+}
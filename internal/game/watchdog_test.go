@@ -0,0 +1,70 @@
+package game
+
+import "testing"
+
+func TestChatWatchdogCountsRoundTrips(t *testing.T) {
+	var w chatWatchdog
+
+	// 한 왕복 안의 빈 읽기 / 시간 초과는 몇 번이든 실패 1회
+	w.command("/강화")
+	for i := 0; i < 10; i++ {
+		w.read("", false)
+	}
+	w.response(false)
+	w.response(false)
+	if got := w.failed(); got != 1 {
+		t.Fatalf("failed after one round trip = %d, want 1", got)
+	}
+	w.command("/강화")
+	if w.failures != 1 {
+		t.Errorf("failures after next command = %d, want 1", w.failures)
+	}
+
+	// 읽기 실패 없이 끝난 왕복 (응답을 기다리지 않은 명령)은 실패가 아님
+	w.read("채팅 원문", true)
+	w.command("/판매")
+	if w.failures != 1 {
+		t.Errorf("failures after unchecked round trip = %d, want 1", w.failures)
+	}
+
+	// 응답을 받은 뒤의 대기 시간 초과는 세지 않음
+	w.response(true)
+	w.response(false)
+	if got := w.failed(); got != 0 {
+		t.Errorf("failed after response = %d, want 0", got)
+	}
+	if w.lastRaw != "채팅 원문" || w.lastCommand != "/판매" {
+		t.Errorf("last raw / command = %q / %q", w.lastRaw, w.lastCommand)
+	}
+}
+
+func TestChatWatchdogStep(t *testing.T) {
+	var w chatWatchdog
+	want := []int{0, 0, 1, 2, 3, 4, 4}
+	for i, step := range want {
+		if i > 0 {
+			w.command("/프로필")
+			w.response(false)
+		}
+		if got := w.watchdogStep(); got != step {
+			t.Errorf("after %d failed round trips: step = %d, want %d", i, got, step)
+		}
+	}
+
+	// 재개하면 처음 단계부터 (마지막 명령 / 원문은 로그용으로 유지)
+	w.step = 4
+	w.reset()
+	if w.watchdogStep() != 0 || w.step != 0 || w.lastCommand != "/프로필" {
+		t.Errorf("after reset: %+v", w)
+	}
+	// 응답을 받으면 실패 수 초기화
+	for i := 0; i < 3; i++ {
+		w.command("/프로필")
+		w.response(false)
+	}
+	w.command("/프로필")
+	w.response(true)
+	if w.watchdogStep() != 0 {
+		t.Errorf("step after response = %d, want 0", w.watchdogStep())
+	}
+}
@@ -0,0 +1,444 @@
+package server
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/server/store"
+)
+
+// ========================
+// 관리자 API
+// ========================
+
+const adminTokenEnvVar = "SWORD_ADMIN_TOKEN" // 설정하지 않으면 관리자 API 비활성화
+
+// requireAdmin 관리자 토큰 확인 (Authorization: Bearer <토큰>), 실패 시 응답까지 작성
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token := os.Getenv(adminTokenEnvVar)
+	if token == "" {
+		http.Error(w, "Admin API disabled", http.StatusForbidden)
+		return false
+	}
+
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !hmac.Equal([]byte(got), []byte(token)) {
+		log.Printf("[관리자] 인증 실패 (IP=%s)", getClientIP(r))
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// adminActor 감사 로그용 작업자 (API 요청)
+func adminActor(r *http.Request) string {
+	return "api:" + getClientIP(r)
+}
+
+// recordAudit 관리자 작업을 감사 로그에 기록 (실패한 작업도 사유와 함께 기록)
+// 감사 로그 저장 실패는 작업 결과를 바꾸지 않고 서버 로그에만 남김
+// 관리 작업은 모두 여기를 거치므로 캐시된 통계 응답도 함께 무효화 (실패해도 일부 반영됐을 수 있음)
+func recordAudit(actor, action, target, detail string, actionErr error) {
+	invalidateResponses()
+	if actionErr != nil {
+		detail = "실패: " + actionErr.Error()
+		log.Printf("[관리자] %s %s %s 실패: %v", actor, action, target, actionErr)
+	} else {
+		log.Printf("🛡️ [관리자] %s %s %s %s", actor, action, target, detail)
+	}
+
+	e := store.AuditEntry{
+		At:     time.Now().Unix(),
+		Actor:  actor,
+		Action: action,
+		Target: target,
+		Detail: detail,
+	}
+	if err := st.AppendAudit(e); err != nil {
+		log.Printf("[관리자] 감사 로그 기록 실패: %v", err)
+	}
+}
+
+// ========================
+// 관리 작업 (API / CLI 공용)
+// ========================
+
+// adminDeleteSession 세션 기여분 삭제 (반환: 삭제된 페이로드 수)
+func adminDeleteSession(actor, sessionID string) (int, error) {
+	n, err := st.DeleteSession(sessionID)
+	recordAudit(actor, "delete_session", sessionID, fmt.Sprintf("payloads=%d", n), err)
+	return n, err
+}
+
+// adminResetTable 통계 테이블 초기화
+func adminResetTable(actor, table string) error {
+	err := st.ResetTable(table)
+	recordAudit(actor, "reset_table", table, "", err)
+	return err
+}
+
+// adminSetDefault 기본값 오버라이드 (value가 nil이면 코드 기본값으로 복원)
+func adminSetDefault(actor, name string, value []byte) error {
+	err := setOverride(name, value)
+	detail := "restore builtin"
+	if value != nil {
+		detail = string(value)
+	}
+	recordAudit(actor, "set_default", name, detail, err)
+	return err
+}
+
+// adminRecompute 페이로드 원문으로 집계 전체 재계산 (반환: 페이로드 수)
+func adminRecompute(actor string) (int, error) {
+	n, err := st.Recompute()
+	recordAudit(actor, "recompute", "", fmt.Sprintf("payloads=%d", n), err)
+	return n, err
+}
+
+// ========================
+// 관리자 API 핸들러
+// ========================
+
+const (
+	defaultAdminListLimit = 50
+	maxAdminListLimit     = 1000
+)
+
+// parseAdminLimit ?limit= 파라미터 (없으면 기본값, 최대 maxAdminListLimit)
+func parseAdminLimit(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultAdminListLimit, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxAdminListLimit {
+		return 0, fmt.Errorf("invalid limit (1-%d)", maxAdminListLimit)
+	}
+	return n, nil
+}
+
+// decodeAdminRequest POST 본문 해석 (실패 시 응답까지 작성)
+func decodeAdminRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(v); err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// handleAdminSessions 최근 수신 세션 목록 (GET ?limit=50)
+func handleAdminSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit, err := parseAdminLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sessions, err := st.Sessions(limit)
+	if err != nil {
+		log.Printf("[관리자] 세션 목록 조회 실패: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"sessions": sessions})
+}
+
+// handleAdminDeleteSession 세션 기여분 삭제 (POST {"session_id"})
+func handleAdminDeleteSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !requireAdmin(w, r) {
+		return
+	}
+	var req struct {
+		SessionID string `json:"session_id"`
+	}
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+	if req.SessionID == "" {
+		http.Error(w, "session_id is required", http.StatusBadRequest)
+		return
+	}
+
+	n, err := adminDeleteSession(adminActor(r), req.SessionID)
+	if err != nil {
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"session_id": req.SessionID, "payloads": n})
+}
+
+// handleAdminResetTable 통계 테이블 초기화 (POST {"table"})
+func handleAdminResetTable(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !requireAdmin(w, r) {
+		return
+	}
+	var req struct {
+		Table string `json:"table"`
+	}
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+	if !isStatTable(req.Table) {
+		http.Error(w, "table must be one of: "+strings.Join(store.StatTables(), ", "), http.StatusBadRequest)
+		return
+	}
+
+	if err := adminResetTable(adminActor(r), req.Table); err != nil {
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"table": req.Table, "status": "reset"})
+}
+
+// handleAdminDefaults 기본값 조회 (GET) / 오버라이드 (POST {"name","value"}, value가 null이면 복원)
+func handleAdminDefaults(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	if r.Method == "GET" {
+		overrides, err := st.Overrides()
+		if err != nil {
+			log.Printf("[관리자] 오버라이드 조회 실패: %v", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		names := make([]string, 0, len(overrides))
+		for name := range overrides {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"defaults":   currentDefaults(),
+			"overridden": names,
+		})
+		return
+	}
+
+	var req struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+	var value []byte
+	if len(req.Value) > 0 && string(req.Value) != "null" {
+		value = req.Value
+	}
+
+	if err := adminSetDefault(adminActor(r), req.Name, value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"defaults": currentDefaults()})
+}
+
+// handleAdminRecompute 집계 전체 재계산 (POST)
+func handleAdminRecompute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	n, err := adminRecompute(adminActor(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]int{"payloads": n})
+}
+
+// handleAdminAudit 감사 로그 (GET ?limit=50, 최신순)
+func handleAdminAudit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit, err := parseAdminLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := st.AuditLog(limit)
+	if err != nil {
+		log.Printf("[관리자] 감사 로그 조회 실패: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"entries": entries})
+}
+
+// isStatTable 초기화 가능한 통계 테이블인지
+func isStatTable(name string) bool {
+	for _, t := range store.StatTables() {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// quarantineSession 격리 세션 요약
+type quarantineSession struct {
+	SessionID     string   `json:"session_id"`
+	Payloads      int      `json:"payloads"`
+	MaxScore      float64  `json:"max_score"`
+	Reasons       []string `json:"reasons"`
+	FirstReceived int64    `json:"first_received"`
+	LastReceived  int64    `json:"last_received"`
+}
+
+// quarantineAction 격리 세션 처리 요청
+type quarantineAction struct {
+	SessionID string `json:"session_id"`
+	Action    string `json:"action"` // accept: 집계에 반영, purge: 폐기
+}
+
+// handleAdminQuarantine 격리 세션 조회 (GET) / 승인·폐기 (POST)
+// GET ?session_id=... 이면 해당 세션의 격리 페이로드 전체 반환
+func handleAdminQuarantine(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		sessionID := r.URL.Query().Get("session_id")
+		entries, err := st.Quarantined(sessionID)
+		if err != nil {
+			log.Printf("[관리자] 격리 목록 조회 실패: %v", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		if sessionID != "" {
+			if entries == nil {
+				entries = []store.QuarantineEntry{}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"entries": entries})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"sessions": summarizeQuarantine(entries)})
+
+	case "POST":
+		var req quarantineAction
+		if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil || req.SessionID == "" {
+			http.Error(w, "Invalid payload", http.StatusBadRequest)
+			return
+		}
+
+		var n int
+		var err error
+		switch req.Action {
+		case "accept":
+			n, err = acceptQuarantine(req.SessionID)
+		case "purge":
+			n, err = st.RemoveQuarantine(req.SessionID)
+		default:
+			http.Error(w, "action must be accept or purge", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			recordAudit(adminActor(r), "quarantine_"+req.Action, req.SessionID, "", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		if n == 0 {
+			http.Error(w, "Session not quarantined", http.StatusNotFound)
+			return
+		}
+
+		recordAudit(adminActor(r), "quarantine_"+req.Action, req.SessionID, fmt.Sprintf("payloads=%d", n), nil)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"session_id": req.SessionID,
+			"action":     req.Action,
+			"payloads":   n,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// acceptQuarantine 세션의 격리 페이로드를 집계에 반영한 뒤 격리 해제 (반환: 처리 건수)
+// 반영 후 삭제 전에 실패해도 다시 승인하면 seq 중복 검사로 이중 반영되지 않음
+func acceptQuarantine(sessionID string) (int, error) {
+	entries, err := st.Quarantined(sessionID)
+	if err != nil {
+		return 0, err
+	}
+	for i := range entries {
+		if err := st.Ingest(&entries[i].Payload); err != nil && !errors.Is(err, store.ErrDuplicate) {
+			return 0, err
+		}
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	return st.RemoveQuarantine(sessionID)
+}
+
+// summarizeQuarantine 격리 페이로드를 세션별로 요약 (최고 점수 내림차순)
+func summarizeQuarantine(entries []store.QuarantineEntry) []quarantineSession {
+	bySession := make(map[string]*quarantineSession)
+	for _, q := range entries {
+		s := bySession[q.SessionID]
+		if s == nil {
+			s = &quarantineSession{SessionID: q.SessionID, FirstReceived: q.ReceivedAt}
+			bySession[q.SessionID] = s
+		}
+		s.Payloads++
+		if q.Score > s.MaxScore {
+			s.MaxScore = q.Score
+		}
+		s.Reasons = append(s.Reasons, q.Reason)
+		s.LastReceived = q.ReceivedAt
+	}
+
+	sessions := make([]quarantineSession, 0, len(bySession))
+	for _, s := range bySession {
+		sessions = append(sessions, *s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].MaxScore > sessions[j].MaxScore })
+	return sessions
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/StopDragon/sword-macro-ai/internal/server/store"
+)
+
+// ========================
+// 관리 명령 (sword-api admin ...)
+// ========================
+
+const adminUsage = `사용법: sword-api admin <명령>
+  sessions [N]                  최근 수신 세션 N개 (기본 50)
+  delete-session <session_id>   세션 기여분 삭제
+  reset-table <table>           통계 테이블 초기화 (전체 누적 + 일별)
+  defaults                      현재 기본값 출력
+  set-default <name> <file|->   기본값 오버라이드 (enhance_rates, sword_prices, battle_rewards, excluded_app_versions)
+  clear-default <name>          코드 기본값으로 복원
+  recompute                     페이로드 원문으로 집계 전체 재계산
+  audit [N]                     최근 관리자 작업 N개 (기본 50)`
+
+// RunAdmin 관리 명령 실행 (Open 이후 호출, 결과는 stdout에 JSON으로 출력)
+// 실행 중인 서버의 메모리 집계에는 서버를 재시작해야 반영됨 (운영 중에는 /api/admin/* 사용)
+func RunAdmin(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", adminUsage)
+	}
+	actor := cliActor()
+
+	switch cmd := args[0]; cmd {
+	case "sessions":
+		limit, err := adminLimitArg(args)
+		if err != nil {
+			return err
+		}
+		sessions, err := st.Sessions(limit)
+		if err != nil {
+			return err
+		}
+		return printJSON(sessions)
+
+	case "delete-session":
+		if len(args) < 2 {
+			return fmt.Errorf("사용법: sword-api admin delete-session <session_id>")
+		}
+		n, err := adminDeleteSession(actor, args[1])
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("세션 없음: %s", args[1])
+		}
+		return printJSON(map[string]interface{}{"session_id": args[1], "payloads": n})
+
+	case "reset-table":
+		if len(args) < 2 || !isStatTable(args[1]) {
+			return fmt.Errorf("사용법: sword-api admin reset-table <%s>", strings.Join(store.StatTables(), "|"))
+		}
+		return adminResetTable(actor, args[1])
+
+	case "defaults":
+		return printJSON(currentDefaults())
+
+	case "set-default":
+		if len(args) < 3 {
+			return fmt.Errorf("사용법: sword-api admin set-default <name> <file|->")
+		}
+		var value []byte
+		var err error
+		if args[2] == "-" {
+			value, err = io.ReadAll(os.Stdin)
+		} else {
+			value, err = os.ReadFile(args[2])
+		}
+		if err != nil {
+			return fmt.Errorf("기본값 파일 읽기 실패: %v", err)
+		}
+		if err := adminSetDefault(actor, args[1], value); err != nil {
+			return err
+		}
+		return printJSON(currentDefaults())
+
+	case "clear-default":
+		if len(args) < 2 {
+			return fmt.Errorf("사용법: sword-api admin clear-default <name>")
+		}
+		return adminSetDefault(actor, args[1], nil)
+
+	case "recompute":
+		_, err := adminRecompute(actor)
+		return err
+
+	case "audit":
+		limit, err := adminLimitArg(args)
+		if err != nil {
+			return err
+		}
+		entries, err := st.AuditLog(limit)
+		if err != nil {
+			return err
+		}
+		return printJSON(entries)
+
+	default:
+		return fmt.Errorf("알 수 없는 명령: %s\n%s", cmd, adminUsage)
+	}
+}
+
+// cliActor 감사 로그용 작업자 (관리 명령)
+func cliActor() string {
+	if user := os.Getenv("USER"); user != "" {
+		return "cli:" + user
+	}
+	return "cli"
+}
+
+// adminLimitArg 목록 명령의 개수 인자 (없으면 기본값)
+func adminLimitArg(args []string) (int, error) {
+	if len(args) < 2 {
+		return defaultAdminListLimit, nil
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("잘못된 개수: %s", args[1])
+	}
+	return n, nil
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
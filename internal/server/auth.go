@@ -0,0 +1,335 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/server/store"
+)
+
+// ========================
+// 설치별 키 인증 (HMAC-SHA256 본문 서명)
+// ========================
+//
+// 요청 헤더:
+//   X-Key-ID    설치 키 ID (/api/register 발급)
+//   X-Timestamp 요청 시각 (unix 초)
+//   X-Nonce     요청마다 다른 임의 문자열
+//   X-Signature hex(HMAC-SHA256(key, timestamp + "\n" + nonce + "\n" + body))
+
+const (
+	maxBodyBytes     = 1 << 20         // 요청 본문 최대 크기 (1MB)
+	maxClockSkew     = 5 * time.Minute // 허용 시각 오차 (재전송 방지 윈도우)
+	maxNonceLen      = 64
+	minNonceLen      = 16
+	keyBytes         = 32
+	keyIDBytes       = 8
+	nonceCacheMax    = 100000 // 재전송 방지 캐시 최대 크기
+	authErrorHeader  = "X-Auth-Error"
+	authErrUnknown   = "unknown_key"
+	authErrRevoked   = "revoked_key"
+	authErrSignature = "bad_signature"
+	authErrStale     = "stale_timestamp"
+	authErrReplay    = "replay"
+)
+
+// keyStore 설치 키 캐시 (저장소와 동기화)
+type keyStore struct {
+	mu   sync.RWMutex
+	keys map[string]*store.InstallKey
+}
+
+var keys = &keyStore{keys: make(map[string]*store.InstallKey)}
+
+// nonceCache 최근 사용된 nonce (재전송 거부용)
+type nonceCache struct {
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+var nonces = &nonceCache{seen: make(map[string]time.Time)}
+
+// checkAndAdd 처음 보는 nonce면 기록 후 true, 이미 사용된 nonce면 false
+func (c *nonceCache) checkAndAdd(keyID, nonce string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 윈도우(±maxClockSkew)를 벗어난 nonce 정리 - 타임스탬프 검증이 그 이전 요청을 막아줌
+	if now.Sub(c.lastPrune) > time.Minute || len(c.seen) >= nonceCacheMax {
+		for k, t := range c.seen {
+			if now.Sub(t) > 2*maxClockSkew {
+				delete(c.seen, k)
+			}
+		}
+		c.lastPrune = now
+	}
+	if len(c.seen) >= nonceCacheMax {
+		return false // 캐시 포화 시 안전하게 거부
+	}
+
+	k := keyID + ":" + nonce
+	if _, ok := c.seen[k]; ok {
+		return false
+	}
+	c.seen[k] = now
+	return true
+}
+
+// randomHex n바이트 난수의 hex 문자열
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// issueKey 새 설치 키 발급 및 저장
+func issueKey(appVersion, osType string) (*store.InstallKey, error) {
+	id, err := randomHex(keyIDBytes)
+	if err != nil {
+		return nil, fmt.Errorf("키 ID 생성 실패: %v", err)
+	}
+	secret := make([]byte, keyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("키 생성 실패: %v", err)
+	}
+
+	k := &store.InstallKey{
+		ID:         "k_" + id,
+		Secret:     secret,
+		CreatedAt:  time.Now().Unix(),
+		AppVersion: appVersion,
+		OSType:     osType,
+	}
+
+	if err := st.SaveInstallKey(*k); err != nil {
+		return nil, err
+	}
+
+	keys.mu.Lock()
+	keys.keys[k.ID] = k
+	keys.mu.Unlock()
+	return k, nil
+}
+
+// revokeKey 키 폐기 (이후 해당 키로 서명된 요청은 거부)
+func revokeKey(keyID string) error {
+	keys.mu.Lock()
+	k, ok := keys.keys[keyID]
+	if !ok {
+		keys.mu.Unlock()
+		return fmt.Errorf("키 없음: %s", keyID)
+	}
+	if k.RevokedAt == 0 {
+		k.RevokedAt = time.Now().Unix()
+	}
+	revokedAt := k.RevokedAt
+	keys.mu.Unlock()
+
+	return st.RevokeInstallKey(keyID, revokedAt)
+}
+
+// RevokeKey 설치 키 폐기 (Open 이후 호출)
+func RevokeKey(keyID string) error {
+	err := revokeKey(keyID)
+	recordAudit(cliActor(), "revoke_key", keyID, "", err)
+	return err
+}
+
+// loadKeys 저장소의 설치 키를 캐시로 로드
+func loadKeys() error {
+	loaded, err := st.InstallKeys()
+	if err != nil {
+		return err
+	}
+
+	keys.mu.Lock()
+	defer keys.mu.Unlock()
+	keys.keys = make(map[string]*store.InstallKey, len(loaded))
+	for i := range loaded {
+		keys.keys[loaded[i].ID] = &loaded[i]
+	}
+	return nil
+}
+
+// computeSignature 본문 서명 계산
+func computeSignature(secret []byte, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "\n" + nonce + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// authError 인증 실패 (코드는 X-Auth-Error 헤더로 전달)
+type authError struct {
+	code string
+}
+
+func (e authError) Error() string {
+	return e.code
+}
+
+// verifyRequest 설치 키 서명 검증 (성공 시 키 ID 반환)
+func verifyRequest(r *http.Request, body []byte) (string, error) {
+	keyID := r.Header.Get("X-Key-ID")
+	timestamp := r.Header.Get("X-Timestamp")
+	nonce := r.Header.Get("X-Nonce")
+	signature := r.Header.Get("X-Signature")
+
+	if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+		return "", authError{authErrSignature}
+	}
+	if len(nonce) < minNonceLen || len(nonce) > maxNonceLen {
+		return "", authError{authErrSignature}
+	}
+
+	keys.mu.RLock()
+	k, ok := keys.keys[keyID]
+	var secret []byte
+	var revoked bool
+	if ok {
+		secret = k.Secret
+		revoked = k.RevokedAt != 0
+	}
+	keys.mu.RUnlock()
+	if !ok {
+		return "", authError{authErrUnknown}
+	}
+	if revoked {
+		return "", authError{authErrRevoked}
+	}
+
+	expected := computeSignature(secret, timestamp, nonce, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "", authError{authErrSignature}
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", authError{authErrStale}
+	}
+	now := time.Now()
+	skew := now.Sub(time.Unix(ts, 0))
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		return "", authError{authErrStale}
+	}
+
+	if !nonces.checkAndAdd(keyID, nonce, now) {
+		return "", authError{authErrReplay}
+	}
+
+	return keyID, nil
+}
+
+// writeAuthError 인증 실패 응답
+func writeAuthError(w http.ResponseWriter, err error) {
+	code := authErrSignature
+	if ae, ok := err.(authError); ok {
+		code = ae.code
+	}
+	w.Header().Set(authErrorHeader, code)
+	http.Error(w, "Unauthorized: "+code, http.StatusUnauthorized)
+}
+
+// ========================
+// 등록 / 키 교체 API
+// ========================
+
+type registerRequest struct {
+	AppVersion string `json:"app_version"`
+	OSType     string `json:"os_type"`
+}
+
+type registerResponse struct {
+	KeyID string `json:"key_id"`
+	Key   string `json:"key"` // hex
+}
+
+// handleRegister 설치 등록 (설치별 키 발급)
+func handleRegister(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req registerRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+	if len(req.AppVersion) > maxAppVersionLen || len(req.OSType) > maxOSTypeLen {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	k, err := issueKey(req.AppVersion, req.OSType)
+	if err != nil {
+		log.Printf("[등록] 키 발급 실패: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[등록] 새 설치 키 발급: %s (버전=%s OS=%s)", k.ID, req.AppVersion, req.OSType)
+	json.NewEncoder(w).Encode(registerResponse{KeyID: k.ID, Key: hex.EncodeToString(k.Secret)})
+}
+
+// handleRotateKey 키 교체 (현재 키로 서명된 요청 → 새 키 발급, 기존 키 폐기)
+func handleRotateKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 4096))
+	if err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	oldID, err := verifyRequest(r, body)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+
+	var req registerRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "Invalid payload", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(req.AppVersion) > maxAppVersionLen || len(req.OSType) > maxOSTypeLen {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	k, err := issueKey(req.AppVersion, req.OSType)
+	if err != nil {
+		log.Printf("[등록] 키 교체 실패: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if err := revokeKey(oldID); err != nil {
+		log.Printf("[등록] 기존 키 폐기 실패: %v", err)
+	}
+
+	log.Printf("[등록] 키 교체: %s → %s", oldID, k.ID)
+	json.NewEncoder(w).Encode(registerResponse{KeyID: k.ID, Key: hex.EncodeToString(k.Secret)})
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ========================
+// 응답 캐시 (game-data / optimal-sell-point / battle/matchup / items/catalog)
+// ========================
+//
+// 클라이언트가 주기적으로 폴링하는 무거운 조회 응답을 인코딩된 본문째 보관
+// 통계가 바뀌면(텔레메트리 반영, 격리, 관리 작업) 세대 번호를 올려 전체 무효화
+// 본문 해시를 ETag로 내려주고 If-None-Match가 같으면 304 응답
+
+const (
+	maxCachedResponses = 256 // 보관할 최대 (경로, 쿼리) 조합 수 - 넘치면 비움
+	cacheControl       = "public, max-age=60, must-revalidate"
+)
+
+// statsGeneration 통계 세대 번호 (바뀔 때마다 증가)
+var statsGeneration atomic.Uint64
+
+// invalidateResponses 통계 변경 시 캐시된 응답 무효화
+func invalidateResponses() {
+	statsGeneration.Add(1)
+}
+
+// cachedResponse 인코딩된 응답 본문
+type cachedResponse struct {
+	generation uint64
+	body       []byte
+	etag       string
+}
+
+// responseCache (경로, 쿼리, 조회 시작일)별 응답
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{entries: make(map[string]*cachedResponse)}
+}
+
+var responses = newResponseCache()
+
+func (c *responseCache) get(key string, generation uint64) *cachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[key]
+	if e == nil || e.generation != generation {
+		return nil
+	}
+	return e
+}
+
+func (c *responseCache) put(key string, e *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCachedResponses {
+		c.entries = make(map[string]*cachedResponse)
+	}
+	c.entries[key] = e
+}
+
+// serveCached 캐시된 응답을 ETag와 함께 전송 (없거나 오래됐으면 compute로 다시 만듦)
+// 키에 조회 시작일을 넣어 ?window= 조회가 날짜가 바뀌면 새로 계산되도록 함
+func serveCached(w http.ResponseWriter, r *http.Request, q statsQuery, compute func() interface{}) {
+	key := r.URL.Path + "?" + r.URL.RawQuery + "#" + q.since
+
+	// 계산 전에 세대를 읽어둠 - 계산 중 통계가 바뀌면 다음 요청에서 다시 계산
+	generation := statsGeneration.Load()
+	e := responses.get(key, generation)
+	if e == nil {
+		body, err := json.Marshal(compute())
+		if err != nil {
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		body = append(body, '\n') // json.Encoder 출력과 동일하게
+		sum := sha256.Sum256(body)
+		e = &cachedResponse{
+			generation: generation,
+			body:       body,
+			etag:       `"` + hex.EncodeToString(sum[:8]) + `"`,
+		}
+		responses.put(key, e)
+	}
+
+	w.Header().Set("ETag", e.etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	if etagMatches(r.Header.Get("If-None-Match"), e.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Write(e.body)
+}
+
+// etagMatches If-None-Match 헤더에 etag가 있는지 (쉼표 목록, 약한 비교, "*" 지원)
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/StopDragon/sword-macro-ai/internal/server/store"
+)
+
+// ========================
+// 아이템 카탈로그 (이름 → 타입)
+// ========================
+//
+// item_farming_stats의 이름별 타입 보고(특수/일반/쓰레기)를 다수결로 묶어 클라이언트에 배포
+// 클라이언트는 이름 접미사 정규식보다 카탈로그를 먼저 보고 타입을 정하므로
+// 새 아이템이 추가돼도 코드 수정 없이 분류가 맞춰짐
+
+const (
+	minCatalogSamples = 3   // 타입을 정하는 최소 파밍 보고 수
+	catalogMinShare   = 0.8 // 다수 타입이 이 비율 이상이어야 확정
+)
+
+// CatalogItem 아이템 카탈로그 항목
+type CatalogItem struct {
+	Name    string `json:"name"`
+	Type    string `json:"type,omitempty"` // 확정 타입 (보고가 갈리면 빈 문자열)
+	Samples int    `json:"samples"`        // 파밍 보고 수
+	Special int    `json:"special"`
+	Normal  int    `json:"normal"`
+	Trash   int    `json:"trash"`
+}
+
+// catalogType 타입별 보고 수로 확정 타입 결정
+func catalogType(special, normal, trash int) string {
+	total := special + normal + trash
+	if total < minCatalogSamples {
+		return ""
+	}
+	best, bestCount := "special", special
+	if normal > bestCount {
+		best, bestCount = "normal", normal
+	}
+	if trash > bestCount {
+		best, bestCount = "trash", trash
+	}
+	if float64(bestCount) < catalogMinShare*float64(total) {
+		return ""
+	}
+	return best
+}
+
+// buildItemCatalog 통계 버킷으로 아이템 카탈로그 생성 (보고 수 내림차순, 같으면 이름순)
+func buildItemCatalog(b *store.Bucket) []CatalogItem {
+	items := make([]CatalogItem, 0, len(b.ItemFarmingStats))
+	for name, s := range b.ItemFarmingStats {
+		if name == "" || s.TotalCount <= 0 {
+			continue
+		}
+		items = append(items, CatalogItem{
+			Name:    name,
+			Type:    catalogType(s.SpecialCount, s.NormalCount, s.TrashCount),
+			Samples: s.TotalCount,
+			Special: s.SpecialCount,
+			Normal:  s.NormalCount,
+			Trash:   s.TrashCount,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Samples != items[j].Samples {
+			return items[i].Samples > items[j].Samples
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
+
+// handleItemCatalog 아이템 카탈로그
+// GET /api/items/catalog (since / window / 세그먼트 필터 사용 가능)
+func handleItemCatalog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	serveCached(w, r, q, func() interface{} { return itemCatalog(q) })
+}
+
+// itemCatalog 아이템 카탈로그 응답
+func itemCatalog(q statsQuery) map[string]interface{} {
+	// 관리자가 제외한 앱 버전은 게임 데이터와 같이 빼고 계산
+	q.filter = q.filter.exclude(currentDefaults().ExcludedAppVersions)
+	return map[string]interface{}{
+		"min_samples": minCatalogSamples,
+		"items":       buildItemCatalog(q.aggregate()),
+	}
+}
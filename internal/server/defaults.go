@@ -0,0 +1,236 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// ========================
+// 기본값 오버라이드 (재빌드 없이 관리자 API로 교체)
+// ========================
+
+// 오버라이드 가능한 기본값 이름
+const (
+	overrideEnhanceRates  = "enhance_rates"  // defaultEnhanceRates
+	overrideSwordPrices   = "sword_prices"   // defaultSwordPrices
+	overrideBattleRewards = "battle_rewards" // defaultBattleRewards
+
+	overrideExcludedVersions = "excluded_app_versions" // 게임 데이터에서 제외할 앱 버전 (기본: 없음)
+)
+
+// gameDefaults 실측 데이터 부족 시 사용하는 기본값 묶음
+type gameDefaults struct {
+	EnhanceRates  []EnhanceRate  `json:"enhance_rates"`
+	SwordPrices   []SwordPrice   `json:"sword_prices"`
+	BattleRewards []BattleReward `json:"battle_rewards"`
+
+	ExcludedAppVersions []string `json:"excluded_app_versions"`
+}
+
+const maxExcludedVersions = 100
+
+var (
+	defaultsMu     sync.RWMutex
+	activeDefaults = builtinDefaults()
+)
+
+// builtinDefaults 코드에 정의된 기본값
+func builtinDefaults() gameDefaults {
+	return gameDefaults{
+		EnhanceRates:  defaultEnhanceRates,
+		SwordPrices:   defaultSwordPrices,
+		BattleRewards: defaultBattleRewards,
+	}
+}
+
+// currentDefaults 오버라이드를 반영한 현재 기본값
+// 슬라이스는 교체만 하고 수정하지 않으므로 호출자는 읽기 전용으로 사용
+func currentDefaults() gameDefaults {
+	defaultsMu.RLock()
+	defer defaultsMu.RUnlock()
+	return activeDefaults
+}
+
+// loadOverrides 저장소의 오버라이드 적용 (잘못된 값은 건너뜀)
+func loadOverrides() error {
+	overrides, err := st.Overrides()
+	if err != nil {
+		return err
+	}
+
+	d := builtinDefaults()
+	for name, value := range overrides {
+		if err := applyOverride(&d, name, value); err != nil {
+			log.Printf("⚠️ 기본값 오버라이드 무시 (%s): %v", name, err)
+			continue
+		}
+		log.Printf("📦 기본값 오버라이드 적용: %s", name)
+	}
+
+	defaultsMu.Lock()
+	activeDefaults = d
+	defaultsMu.Unlock()
+	return nil
+}
+
+// setOverride 기본값 오버라이드 검증 후 저장 및 적용 (value가 nil이면 코드 기본값으로 복원)
+func setOverride(name string, value []byte) error {
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+
+	d := activeDefaults
+	if value == nil {
+		builtin := builtinDefaults()
+		switch name {
+		case overrideEnhanceRates:
+			d.EnhanceRates = builtin.EnhanceRates
+		case overrideSwordPrices:
+			d.SwordPrices = builtin.SwordPrices
+		case overrideBattleRewards:
+			d.BattleRewards = builtin.BattleRewards
+		case overrideExcludedVersions:
+			d.ExcludedAppVersions = builtin.ExcludedAppVersions
+		default:
+			return fmt.Errorf("unknown default: %s", name)
+		}
+	} else if err := applyOverride(&d, name, value); err != nil {
+		return err
+	}
+
+	if err := st.SetOverride(name, value); err != nil {
+		return err
+	}
+	activeDefaults = d
+	invalidateResponses()
+	return nil
+}
+
+// applyOverride 오버라이드 JSON을 검증해서 기본값에 반영
+func applyOverride(d *gameDefaults, name string, value []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.DisallowUnknownFields()
+
+	switch name {
+	case overrideEnhanceRates:
+		var rates []EnhanceRate
+		if err := dec.Decode(&rates); err != nil {
+			return fmt.Errorf("invalid enhance_rates: %v", err)
+		}
+		if err := validateEnhanceRates(rates); err != nil {
+			return err
+		}
+		d.EnhanceRates = rates
+	case overrideSwordPrices:
+		var prices []SwordPrice
+		if err := dec.Decode(&prices); err != nil {
+			return fmt.Errorf("invalid sword_prices: %v", err)
+		}
+		if err := validateSwordPrices(prices); err != nil {
+			return err
+		}
+		d.SwordPrices = prices
+	case overrideBattleRewards:
+		var rewards []BattleReward
+		if err := dec.Decode(&rewards); err != nil {
+			return fmt.Errorf("invalid battle_rewards: %v", err)
+		}
+		if err := validateBattleRewards(rewards); err != nil {
+			return err
+		}
+		d.BattleRewards = rewards
+	case overrideExcludedVersions:
+		var versions []string
+		if err := dec.Decode(&versions); err != nil {
+			return fmt.Errorf("invalid excluded_app_versions: %v", err)
+		}
+		if err := validateExcludedVersions(versions); err != nil {
+			return err
+		}
+		d.ExcludedAppVersions = versions
+	default:
+		return fmt.Errorf("unknown default: %s", name)
+	}
+	return nil
+}
+
+// validateEnhanceRates 레벨 중복 없음, 확률 0~100, 합계 100 (±1)
+func validateEnhanceRates(rates []EnhanceRate) error {
+	if len(rates) == 0 {
+		return fmt.Errorf("enhance_rates is empty")
+	}
+	seen := make(map[int]bool)
+	for _, r := range rates {
+		if r.Level < 0 || r.Level > maxEventLevel || seen[r.Level] {
+			return fmt.Errorf("enhance_rates: invalid or duplicate level %d", r.Level)
+		}
+		seen[r.Level] = true
+		if !validPercent(r.SuccessRate) || !validPercent(r.KeepRate) || !validPercent(r.DestroyRate) {
+			return fmt.Errorf("enhance_rates: level %d rate out of range", r.Level)
+		}
+		if sum := r.SuccessRate + r.KeepRate + r.DestroyRate; sum < 99 || sum > 101 {
+			return fmt.Errorf("enhance_rates: level %d rates sum to %.1f", r.Level, sum)
+		}
+	}
+	return nil
+}
+
+// validateSwordPrices 레벨 중복 없음, 0 <= min <= avg <= max
+func validateSwordPrices(prices []SwordPrice) error {
+	if len(prices) == 0 {
+		return fmt.Errorf("sword_prices is empty")
+	}
+	seen := make(map[int]bool)
+	for _, p := range prices {
+		if p.Level < 0 || p.Level > maxEventLevel || seen[p.Level] {
+			return fmt.Errorf("sword_prices: invalid or duplicate level %d", p.Level)
+		}
+		seen[p.Level] = true
+		if p.MinPrice < 0 || p.MinPrice > p.AvgPrice || p.AvgPrice > p.MaxPrice {
+			return fmt.Errorf("sword_prices: level %d requires 0 <= min <= avg <= max", p.Level)
+		}
+	}
+	return nil
+}
+
+// validateBattleRewards 레벨차 중복 없음, 승률 0~100, 0 <= min <= avg <= max
+func validateBattleRewards(rewards []BattleReward) error {
+	if len(rewards) == 0 {
+		return fmt.Errorf("battle_rewards is empty")
+	}
+	seen := make(map[int]bool)
+	for _, r := range rewards {
+		if r.LevelDiff < 1 || r.LevelDiff > maxEventLevel || seen[r.LevelDiff] {
+			return fmt.Errorf("battle_rewards: invalid or duplicate level_diff %d", r.LevelDiff)
+		}
+		seen[r.LevelDiff] = true
+		if !validPercent(r.WinRate) {
+			return fmt.Errorf("battle_rewards: level_diff %d win_rate out of range", r.LevelDiff)
+		}
+		if r.MinReward < 0 || r.MinReward > r.AvgReward || r.AvgReward > r.MaxReward {
+			return fmt.Errorf("battle_rewards: level_diff %d requires 0 <= min <= avg <= max", r.LevelDiff)
+		}
+	}
+	return nil
+}
+
+// validateExcludedVersions 버전 문자열 비어있지 않음, 길이 제한, 중복 없음
+func validateExcludedVersions(versions []string) error {
+	if len(versions) > maxExcludedVersions {
+		return fmt.Errorf("excluded_app_versions: too many versions (max %d)", maxExcludedVersions)
+	}
+	seen := make(map[string]bool)
+	for _, v := range versions {
+		if v == "" || len(v) > maxAppVersionLen || seen[v] {
+			return fmt.Errorf("excluded_app_versions: invalid or duplicate version %q", v)
+		}
+		seen[v] = true
+	}
+	return nil
+}
+
+func validPercent(v float64) bool {
+	return v >= 0 && v <= 100
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/server/store"
+)
+
+// ========================
+// v4 개별 이벤트
+// ========================
+
+const (
+	maxEventsPerPayload = 500                                          // 페이로드당 최대 이벤트 수
+	maxEventGold        = 2000000000                                   // 이벤트 골드 변화량 절대값 상한
+	maxEventDurationMs  = int64(10 * time.Minute / time.Millisecond)   // 이벤트 소요 시간 상한
+	maxEventOffsetMs    = int64(7 * 24 * time.Hour / time.Millisecond) // 세션 시작 기준 시각 상한
+	maxEventLevel       = 20
+)
+
+// 이벤트 타입별 허용 결과값
+var eventResults = map[string]map[string]bool{
+	"enhance": {"success": true, "hold": true, "fail": true, "destroy": true},
+	"battle":  {"win": true, "lose": true},
+	"farm":    {"found": true},
+	"sale":    {"sold": true},
+}
+
+// validateEvents 이벤트 목록 검증 (validateStatValues와 같은 수준으로 엄격하게)
+func validateEvents(p *store.TelemetryPayload) error {
+	events := p.Stats.Events
+	if len(events) == 0 {
+		if p.Stats.EventsDropped < 0 || p.Stats.EventsDropped > maxStatValue {
+			return fmt.Errorf("invalid events_dropped")
+		}
+		return nil
+	}
+	if p.SchemaVersion < 4 {
+		return fmt.Errorf("events require schema_version >= 4")
+	}
+	if len(events) > maxEventsPerPayload {
+		return fmt.Errorf("events too many entries")
+	}
+	if p.Stats.EventsDropped < 0 || p.Stats.EventsDropped > maxStatValue {
+		return fmt.Errorf("invalid events_dropped")
+	}
+
+	var lastOffset int64
+	for i, ev := range events {
+		results, ok := eventResults[ev.Type]
+		if !ok {
+			return fmt.Errorf("event %d: invalid type", i)
+		}
+		if !results[ev.Result] {
+			return fmt.Errorf("event %d: invalid result for %s", i, ev.Type)
+		}
+		if ev.Level < 0 || ev.Level > maxEventLevel {
+			return fmt.Errorf("event %d: invalid level", i)
+		}
+		if ev.TargetLevel < 0 || ev.TargetLevel > maxEventLevel {
+			return fmt.Errorf("event %d: invalid target_level", i)
+		}
+		if ev.TargetLevel != 0 && ev.Type != "battle" {
+			return fmt.Errorf("event %d: target_level only allowed for battle", i)
+		}
+		switch ev.ItemType {
+		case "", "normal", "special", "trash":
+		default:
+			return fmt.Errorf("event %d: invalid item_type", i)
+		}
+		if ev.GoldDelta > maxEventGold || ev.GoldDelta < -maxEventGold {
+			return fmt.Errorf("event %d: gold_delta out of range", i)
+		}
+		if ev.Type == "sale" && ev.GoldDelta < 0 {
+			return fmt.Errorf("event %d: negative sale gold", i)
+		}
+		if ev.DurationMs < 0 || ev.DurationMs > maxEventDurationMs {
+			return fmt.Errorf("event %d: duration_ms out of range", i)
+		}
+		if ev.OffsetMs < 0 || ev.OffsetMs > maxEventOffsetMs {
+			return fmt.Errorf("event %d: offset_ms out of range", i)
+		}
+		// 이벤트는 발생 순서대로 와야 함
+		if ev.OffsetMs < lastOffset {
+			return fmt.Errorf("event %d: offset_ms not in order", i)
+		}
+		lastOffset = ev.OffsetMs
+	}
+	return nil
+}
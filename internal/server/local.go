@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/StopDragon/sword-macro-ai/internal/server/store"
+)
+
+// ========================
+// 클라이언트 로컬 모드 (network_mode=local)
+// ========================
+//
+// sword-macro가 네트워크 없이 쓰는 데이터 소스 (api.Backend 구현)
+// 텔레메트리는 검증 후 저장소에 바로 반영하고, 조회는 핸들러와 같은 집계 함수를 직접 호출
+// 로컬 파일에는 본인 데이터만 쌓이므로 설치 키 서명 / 요청 제한 / 이상치 격리는 거치지 않음
+
+// Local 로컬 모드 데이터 소스
+type Local struct{}
+
+// OpenLocal dbPath SQLite 파일을 통계 저장소로 열기
+// DB를 열지 못하면 인메모리 저장소로 동작하며 에러를 함께 반환
+func OpenLocal(dbPath string) (*Local, error) {
+	sl, err := store.OpenSQLite(dbPath)
+	if err != nil {
+		st = store.NewMemory()
+		return &Local{}, fmt.Errorf("로컬 DB 열기 실패: %v", err)
+	}
+	st = sl
+	return &Local{}, nil
+}
+
+// Ingest 텔레메트리 페이로드 반영 (이미 반영된 seq는 무시)
+func (l *Local) Ingest(body []byte) error {
+	var payload store.TelemetryPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("페이로드 파싱 실패: %v", err)
+	}
+	if err := validateTelemetryPayload(&payload); err != nil {
+		return fmt.Errorf("페이로드 검증 실패: %v", err)
+	}
+	payload.Period = receivePeriod(payload.Period, serverNow())
+	if err := st.Ingest(&payload); err != nil && !errors.Is(err, store.ErrDuplicate) {
+		return fmt.Errorf("통계 저장 실패: %v", err)
+	}
+	return nil
+}
+
+// Query 조회 경로의 응답 JSON (클라이언트가 쓰는 경로만 지원)
+func (l *Local) Query(path string, params url.Values) ([]byte, error) {
+	q, err := parseStatsParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp interface{}
+	switch path {
+	case "/api/game-data":
+		resp = getGameData(q, false)
+	case "/api/strategy/optimal-sell-point":
+		resp = optimalSellPoint(q)
+	case "/api/battle/matchup":
+		myLevel, oppLevel, swordType, err := parseMatchupParams(params)
+		if err != nil {
+			return nil, err
+		}
+		q.filter = q.filter.exclude(currentDefaults().ExcludedAppVersions)
+		resp = predictMatchup(q.aggregate(), myLevel, oppLevel, swordType)
+	case "/api/items/catalog":
+		resp = itemCatalog(q)
+	case "/api/stats/special":
+		resp = specialStats(q.aggregate())
+	case "/api/stats/sales":
+		resp = saleStats(q.aggregate())
+	default:
+		return nil, fmt.Errorf("로컬 모드에서 지원하지 않는 경로: %s", path)
+	}
+	return json.Marshal(resp)
+}
+
+// Close 저장소 닫기 (대기 중인 통계 저장)
+func (l *Local) Close() error {
+	err := st.Close()
+	st = store.NewMemory()
+	return err
+}
@@ -0,0 +1,283 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/StopDragon/sword-macro-ai/internal/server/store"
+)
+
+// ========================
+// 배틀 승률 모델 (내 레벨, 상대 레벨, 검 타입)
+// ========================
+//
+// battle_matchups 통계로 로지스틱 회귀를 학습해 임의의 조합에 대한 승률을 예측
+// logit(p) = b0 + b1*레벨차 + b2*내레벨/10 + b3*[특수] + b4*[쓰레기]
+// 같은 레벨차라도 +3 vs +5와 +13 vs +15의 승률이 다를 수 있도록 내 레벨을 함께 사용
+// 학습 데이터가 부족하면 게임 데이터의 레벨차별 승률(upset_stats_by_diff + 기본값)로 대체
+
+const (
+	minMatchupSamples  = 100 // 모델 사용 최소 배틀 수
+	minMatchupOutcomes = 5   // 레벨차별 평균 보상 / 손실 실측 사용 최소 승리 / 패배 수
+	matchupRidge       = 1.0 // 계수 L2 정규화 강도 (절편 제외)
+	matchupMaxIter     = 50
+)
+
+// matchupFeatures 모델 입력 벡터
+func matchupFeatures(itemType string, myLevel, oppLevel int) []float64 {
+	x := []float64{1, float64(oppLevel - myLevel), float64(myLevel) / 10, 0, 0}
+	switch itemType {
+	case "special":
+		x[3] = 1
+	case "trash":
+		x[4] = 1
+	}
+	return x
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
+
+// matchupModel 학습된 로지스틱 회귀 계수
+type matchupModel struct {
+	weights []float64
+	samples int // 학습에 사용한 배틀 수
+}
+
+// predict 승률 (0~1)
+func (m *matchupModel) predict(itemType string, myLevel, oppLevel int) float64 {
+	z := 0.0
+	for i, x := range matchupFeatures(itemType, myLevel, oppLevel) {
+		z += m.weights[i] * x
+	}
+	return sigmoid(z)
+}
+
+// fitMatchupModel 조합별 (배틀 수, 승리 수)로 가중 로지스틱 회귀 학습 (뉴턴법)
+// 배틀 수가 minMatchupSamples 미만이면 nil
+func fitMatchupModel(matchups map[string]*store.MatchupStat) *matchupModel {
+	type cell struct {
+		x       []float64
+		n, wins float64
+	}
+	var cells []cell
+	total, totalWins := 0, 0
+	for key, s := range matchups {
+		itemType, my, opp, ok := store.ParseMatchupKey(key)
+		if !ok || s.Battles <= 0 {
+			continue
+		}
+		cells = append(cells, cell{matchupFeatures(itemType, my, opp), float64(s.Battles), float64(s.Wins)})
+		total += s.Battles
+		totalWins += s.Wins
+	}
+	if total < minMatchupSamples {
+		return nil
+	}
+
+	k := len(matchupFeatures("", 0, 0))
+	w := make([]float64, k)
+	base := (float64(totalWins) + 0.5) / (float64(total) + 1)
+	w[0] = math.Log(base / (1 - base))
+
+	for iter := 0; iter < matchupMaxIter; iter++ {
+		grad := make([]float64, k)
+		hess := make([][]float64, k)
+		for i := range hess {
+			hess[i] = make([]float64, k)
+		}
+		for _, c := range cells {
+			z := 0.0
+			for i, x := range c.x {
+				z += w[i] * x
+			}
+			p := sigmoid(z)
+			r := c.wins - c.n*p
+			v := c.n * p * (1 - p)
+			for i, xi := range c.x {
+				grad[i] += r * xi
+				for j, xj := range c.x {
+					hess[i][j] += v * xi * xj
+				}
+			}
+		}
+		hess[0][0] += 1e-9 // 모든 배틀이 한쪽 결과일 때 특이 행렬 방지
+		for i := 1; i < k; i++ {
+			grad[i] -= matchupRidge * w[i]
+			hess[i][i] += matchupRidge
+		}
+
+		step, ok := solveLinear(hess, grad)
+		if !ok {
+			break
+		}
+		maxStep := 0.0
+		for i := range w {
+			w[i] += step[i]
+			maxStep = math.Max(maxStep, math.Abs(step[i]))
+		}
+		if maxStep < 1e-6 {
+			break
+		}
+	}
+	return &matchupModel{weights: w, samples: total}
+}
+
+// solveLinear a·x = b (가우스 소거, 부분 피벗), 특이 행렬이면 false
+func solveLinear(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range a {
+		m[i] = append(append([]float64{}, a[i]...), b[i])
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for row := col + 1; row < n; row++ {
+			f := m[row][col] / m[col][col]
+			for j := col; j <= n; j++ {
+				m[row][j] -= f * m[col][j]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		sum := m[i][n]
+		for j := i + 1; j < n; j++ {
+			sum -= m[i][j] * x[j]
+		}
+		x[i] = sum / m[i][i]
+	}
+	return x, true
+}
+
+// BattleMatchup 배틀 조합 예측 결과
+type BattleMatchup struct {
+	MyLevel        int     `json:"my_level"`
+	OppLevel       int     `json:"opp_level"`
+	SwordType      string  `json:"sword_type,omitempty"`
+	WinRate        float64 `json:"win_rate"`        // 예측 승률 (%)
+	AvgReward      int     `json:"avg_reward"`      // 승리 시 평균 보상
+	AvgLoss        int     `json:"avg_loss"`        // 패배 시 평균 손실
+	ExpectedReward float64 `json:"expected_reward"` // 배틀 1회 기대 골드
+	Samples        int     `json:"samples"`         // 이 조합의 실측 배틀 수
+	ModelSamples   int     `json:"model_samples"`   // 모델 학습 배틀 수
+	Model          string  `json:"model"`           // logistic, level_diff, none
+}
+
+// predictMatchup 통계 버킷으로 배틀 조합의 승률 / 기대 골드 예측
+func predictMatchup(b *store.Bucket, myLevel, oppLevel int, swordType string) BattleMatchup {
+	res := BattleMatchup{MyLevel: myLevel, OppLevel: oppLevel, SwordType: swordType, Model: "none"}
+	diff := oppLevel - myLevel
+
+	// 레벨차별 실측 보상 / 손실 (검 타입 합산)
+	var diffWins, diffLosses, diffEarned, diffLost, allLosses, allLost int
+	for key, s := range b.BattleMatchups {
+		itemType, my, opp, ok := store.ParseMatchupKey(key)
+		if !ok {
+			continue
+		}
+		if my == myLevel && opp == oppLevel && (swordType == "" || itemType == swordType) {
+			res.Samples += s.Battles
+		}
+		allLosses += s.Battles - s.Wins
+		allLost += s.GoldLost
+		if opp-my == diff {
+			diffWins += s.Wins
+			diffLosses += s.Battles - s.Wins
+			diffEarned += s.GoldEarned
+			diffLost += s.GoldLost
+		}
+	}
+
+	var tableReward *BattleReward
+	rewards := buildGameData(b).BattleRewards
+	for i := range rewards {
+		if rewards[i].LevelDiff == diff {
+			tableReward = &rewards[i]
+			break
+		}
+	}
+
+	switch {
+	case diffWins >= minMatchupOutcomes:
+		res.AvgReward = diffEarned / diffWins
+	case tableReward != nil:
+		res.AvgReward = tableReward.AvgReward
+	}
+	switch {
+	case diffLosses >= minMatchupOutcomes:
+		res.AvgLoss = diffLost / diffLosses
+	case allLosses >= minMatchupOutcomes:
+		res.AvgLoss = allLost / allLosses
+	}
+
+	p := 0.0
+	if m := fitMatchupModel(b.BattleMatchups); m != nil {
+		p = m.predict(swordType, myLevel, oppLevel)
+		res.Model = "logistic"
+		res.ModelSamples = m.samples
+	} else if tableReward != nil {
+		p = tableReward.WinRate / 100
+		res.Model = "level_diff"
+	} else {
+		return res
+	}
+
+	res.WinRate = p * 100
+	res.ExpectedReward = p*float64(res.AvgReward) - (1-p)*float64(res.AvgLoss)
+	return res
+}
+
+// handleBattleMatchup 배틀 조합 승률 / 기대 골드
+// GET /api/battle/matchup?my_level=10&opp_level=12&sword_type=normal (since / window / 세그먼트 필터 사용 가능)
+func handleBattleMatchup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	myLevel, oppLevel, swordType, err := parseMatchupParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 관리자가 제외한 앱 버전은 게임 데이터와 같이 빼고 계산
+	q.filter = q.filter.exclude(currentDefaults().ExcludedAppVersions)
+	serveCached(w, r, q, func() interface{} {
+		return predictMatchup(q.aggregate(), myLevel, oppLevel, swordType)
+	})
+}
+
+// parseMatchupParams my_level / opp_level / sword_type 파라미터 해석
+func parseMatchupParams(params url.Values) (myLevel, oppLevel int, swordType string, err error) {
+	myLevel, err1 := strconv.Atoi(params.Get("my_level"))
+	oppLevel, err2 := strconv.Atoi(params.Get("opp_level"))
+	if err1 != nil || err2 != nil || myLevel < 0 || myLevel > maxEventLevel || oppLevel < 0 || oppLevel > maxEventLevel {
+		return 0, 0, "", fmt.Errorf("my_level and opp_level must be 0-%d", maxEventLevel)
+	}
+	swordType = params.Get("sword_type")
+	switch swordType {
+	case "", "normal", "special", "trash":
+	default:
+		return 0, 0, "", fmt.Errorf("invalid sword_type (normal, special, trash)")
+	}
+	return myLevel, oppLevel, swordType, nil
+}
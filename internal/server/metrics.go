@@ -0,0 +1,295 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ========================
+// Prometheus 메트릭 (/metrics, 텍스트 형식 0.0.4)
+// ========================
+
+const maxMetricSeries = 500 // 메트릭당 최대 레이블 조합 수 (초과분은 "other"로 합산)
+
+// 요청 지연 시간 / DB 저장 시간 히스토그램 구간 (초)
+var latencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// serverMetrics sword-api 메트릭 모음
+type serverMetrics struct {
+	httpRequests      *counterVec
+	httpDuration      *histogramVec
+	telemetryRejected *counterVec
+	telemetryIngested *counterVec
+	ingestDuration    *histogramVec
+	dbSaveDuration    *histogramVec
+	dbSaveErrors      *counterVec
+	stateResyncs      *counterVec
+	stateDrifts       *counterVec
+}
+
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{
+		httpRequests: newCounterVec("sword_api_http_requests_total",
+			"HTTP requests by route, method and status code.", "route", "method", "code"),
+		httpDuration: newHistogramVec("sword_api_http_request_duration_seconds",
+			"HTTP request latency by route.", latencyBuckets, "route"),
+		telemetryRejected: newCounterVec("sword_api_telemetry_rejected_total",
+			"Telemetry payloads not aggregated, by reason.", "reason"),
+		telemetryIngested: newCounterVec("sword_api_telemetry_ingested_total",
+			"Telemetry payloads aggregated, by client attributes.", "schema_version", "app_version", "os_type", "mode"),
+		ingestDuration: newHistogramVec("sword_api_telemetry_ingest_duration_seconds",
+			"Time spent aggregating a telemetry payload (duplicate check and queueing).", latencyBuckets),
+		dbSaveDuration: newHistogramVec("sword_api_db_save_duration_seconds",
+			"Time spent writing a batch of telemetry payloads to the database.", latencyBuckets),
+		dbSaveErrors: newCounterVec("sword_api_db_save_errors_total",
+			"Failed telemetry saves (ingest, quarantine or batch write)."),
+		stateResyncs: newCounterVec("sword_api_state_resyncs_total",
+			"Client /profile resyncs reported in aggregated telemetry."),
+		stateDrifts: newCounterVec("sword_api_state_drifts_total",
+			"Client resyncs that found level or sword name out of sync."),
+	}
+}
+
+var metrics = newServerMetrics()
+
+// 텔레메트리 거부 사유
+const (
+	rejectRateLimit   = "rate_limit"
+	rejectBody        = "body"
+	rejectSignature   = "signature"
+	rejectInvalidJSON = "invalid_json"
+	rejectValidation  = "validation"
+	rejectDuplicate   = "duplicate"
+	rejectQuarantine  = "quarantined"
+)
+
+// rejectTelemetry 텔레메트리 거부 사유 집계
+func rejectTelemetry(reason string) {
+	metrics.telemetryRejected.inc(reason)
+}
+
+// statusRecorder 응답 상태 코드 기록용 ResponseWriter
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// instrument 라우트별 요청 수/지연 시간 측정 (route는 등록 패턴이라 레이블 수가 고정됨)
+func instrument(route string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h(rec, r)
+
+		method := r.Method
+		switch method {
+		case "GET", "POST", "OPTIONS", "HEAD", "PUT", "DELETE":
+		default:
+			method = "other"
+		}
+		metrics.httpRequests.inc(route, method, strconv.Itoa(rec.status))
+		metrics.httpDuration.observe(time.Since(start).Seconds(), route)
+	}
+}
+
+// handleMetrics Prometheus 텍스트 형식 메트릭
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	m := metrics
+	m.httpRequests.write(w)
+	m.httpDuration.write(w)
+	m.telemetryRejected.write(w)
+	m.telemetryIngested.write(w)
+	m.ingestDuration.write(w)
+	m.dbSaveDuration.write(w)
+	m.dbSaveErrors.write(w)
+	m.stateResyncs.write(w)
+	m.stateDrifts.write(w)
+
+	writeGauge(w, "sword_api_rate_limiter_entries", "Client (route class, IP) pairs tracked by the rate limiter.", float64(limiter.size()))
+}
+
+// ========================
+// 메트릭 타입
+// ========================
+
+// counterVec 레이블별 카운터
+type counterVec struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	values map[string]float64 // 레이블 값 조합 키 → 값
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+}
+
+func (c *counterVec) inc(labelValues ...string) {
+	c.add(1, labelValues...)
+}
+
+func (c *counterVec) add(v float64, labelValues ...string) {
+	if v <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := seriesKey(labelValues)
+	if _, ok := c.values[key]; !ok && len(c.values) >= maxMetricSeries {
+		key = overflowKey(len(c.labels))
+	}
+	c.values[key] += v
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, splitSeriesKey(key, len(c.labels))), formatValue(c.values[key]))
+	}
+}
+
+// histogramVec 레이블별 히스토그램
+type histogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64 // 구간별 (누적 아님)
+	sum    float64
+	count  uint64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogram)}
+}
+
+func (h *histogramVec) observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := seriesKey(labelValues)
+	s := h.series[key]
+	if s == nil {
+		if len(h.series) >= maxMetricSeries {
+			key = overflowKey(len(h.labels))
+			s = h.series[key]
+		}
+		if s == nil {
+			s = &histogram{counts: make([]uint64, len(h.buckets))}
+			h.series[key] = s
+		}
+	}
+	for i, le := range h.buckets {
+		if v <= le {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := h.series[key]
+		values := splitSeriesKey(key, len(h.labels))
+		labels := append(append([]string{}, h.labels...), "le")
+
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(labels, append(append([]string{}, values...), formatValue(le))), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(labels, append(append([]string{}, values...), "+Inf")), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, values), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, values), s.count)
+	}
+}
+
+func writeGauge(w io.Writer, name, help string, v float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, help, name, name, formatValue(v))
+}
+
+// ========================
+// 레이블 처리
+// ========================
+
+const seriesSep = "\xff" // 레이블 값에 나올 수 없는 구분자
+
+func seriesKey(values []string) string {
+	return strings.Join(values, seriesSep)
+}
+
+func splitSeriesKey(key string, n int) []string {
+	if n == 0 {
+		return nil
+	}
+	return strings.Split(key, seriesSep)
+}
+
+// overflowKey 레이블 조합 수 초과 시 합산용 키
+func overflowKey(n int) string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = "other"
+	}
+	return seriesKey(values)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, seriesSep, "")
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(values[i]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/StopDragon/sword-macro-ai/internal/server/store"
+)
+
+// ========================
+// 이상치 탐지 (커뮤니티 확률 대비)
+// ========================
+//
+// 레벨별 강화 결과 / 레벨차별 역배 결과를 커뮤니티 사후 확률과 비교
+// 결과 분포 q, 사후 평균 확률 p, 시행 n일 때 점수 = n·KL(q‖p)
+// (Sanov 상한: 이런 결과가 우연히 나올 확률 ≤ e^-점수)
+
+const (
+	quarantineScore = 30.0  // 격리 기준 점수 (우연일 확률 약 1e-13 이하)
+	priorWeight     = 50.0  // 기본 확률을 가상 시행 수로 환산한 사전분포 가중치
+	minOutcomeProb  = 0.001 // 기본 확률 0%인 결과 보정 (log 0 방지)
+)
+
+// outlierScore 페이로드의 이상치 점수와 가장 의심스러운 항목 설명
+// community는 이 페이로드를 반영하기 전 전체 누적 통계
+func outlierScore(p *store.TelemetryPayload, community *store.Bucket) (float64, string) {
+	best, reason := 0.0, ""
+	defaults := currentDefaults()
+
+	// 레벨별 강화 결과 (성공/유지/파괴)
+	if p.SchemaVersion >= 3 {
+		for _, def := range defaults.EnhanceRates {
+			d := p.Stats.EnhanceLevelDetail[def.Level]
+			if d == nil {
+				continue
+			}
+			counts := []int{d.Success, d.Fail, d.Destroy}
+			prior := []float64{def.SuccessRate, def.KeepRate, def.DestroyRate}
+			var seen []int
+			if c := community.EnhanceLevelDetail[def.Level]; c != nil {
+				seen = []int{c.Success, c.Fail, c.Destroy}
+			}
+			score, expected := multinomialScore(counts, prior, seen)
+			if score > best {
+				best = score
+				reason = fmt.Sprintf("강화 +%d 성공/유지/파괴 %d/%d/%d (예상 %.1f%%/%.1f%%/%.1f%%)",
+					def.Level, d.Success, d.Fail, d.Destroy, expected[0]*100, expected[1]*100, expected[2]*100)
+			}
+		}
+	}
+
+	// 레벨차별 역배 결과 (승/패)
+	if p.SchemaVersion >= 2 {
+		for _, def := range defaults.BattleRewards {
+			u := p.Stats.UpsetStatsByDiff[def.LevelDiff]
+			if u == nil {
+				continue
+			}
+			counts := []int{u.Wins, u.Attempts - u.Wins}
+			prior := []float64{def.WinRate, 100 - def.WinRate}
+			var seen []int
+			if c := community.UpsetStatsByDiff[def.LevelDiff]; c != nil {
+				seen = []int{c.Wins, c.Attempts - c.Wins}
+			}
+			score, expected := multinomialScore(counts, prior, seen)
+			if score > best {
+				best = score
+				reason = fmt.Sprintf("역배 %d레벨차 %d승/%d회 (예상 승률 %.2f%%)",
+					def.LevelDiff, u.Wins, u.Attempts, expected[0]*100)
+			}
+		}
+	}
+
+	return best, reason
+}
+
+// multinomialScore n·KL(관측 분포 ‖ 사후 평균 확률)과 사후 평균 확률
+// prior: 기본 확률(%), seen: 커뮤니티 누적 결과 (nil 가능)
+func multinomialScore(counts []int, prior []float64, seen []int) (float64, []float64) {
+	n, seenTotal := 0, 0
+	for i := range counts {
+		if counts[i] < 0 {
+			counts[i] = 0
+		}
+		n += counts[i]
+		if seen != nil && seen[i] > 0 {
+			seenTotal += seen[i]
+		}
+	}
+
+	// 디리클레 사후 평균: (α_i + 관측_i) / (Σα + 관측 합)
+	expected := make([]float64, len(counts))
+	for i := range counts {
+		alpha := priorWeight * prior[i] / 100
+		c := 0
+		if seen != nil && seen[i] > 0 {
+			c = seen[i]
+		}
+		expected[i] = math.Max((alpha+float64(c))/(priorWeight+float64(seenTotal)), minOutcomeProb)
+	}
+	if n == 0 {
+		return 0, expected
+	}
+
+	score := 0.0
+	for i, c := range counts {
+		if c == 0 {
+			continue
+		}
+		q := float64(c) / float64(n)
+		score += float64(c) * math.Log(q/expected[i])
+	}
+	return score, expected
+}
+
+// quarantineCheck 격리 여부 판정 (반환: 점수, 사유, 격리 여부)
+// 이미 반영된 순번(재전송)은 검사하지 않음 (Ingest에서 중복 처리)
+// 이미 검토 중인 세션의 페이로드는 점수와 관계없이 함께 격리
+func quarantineCheck(p *store.TelemetryPayload) (float64, string, bool) {
+	if st.Seen(p.SessionID, p.Seq) {
+		return 0, "", false
+	}
+	if st.IsQuarantined(p.SessionID) {
+		return 0, "검토 중인 세션", true
+	}
+	score, reason := outlierScore(p, communityBaseline())
+	return score, reason, score >= quarantineScore
+}
+
+// baselineCache 통계 세대별 커뮤니티 기준 통계 (통계가 바뀌기 전까지 페이로드마다 다시 합산하지 않음)
+var baselineCache struct {
+	mu         sync.Mutex
+	generation uint64
+	bucket     *store.Bucket
+}
+
+// communityBaseline 이상치 판정 기준 통계 (읽기 전용으로 사용)
+// 관리자가 제외한 앱 버전의 통계는 기준 확률에서도 제외 (제외 목록이 바뀌면 세대도 바뀜)
+func communityBaseline() *store.Bucket {
+	generation := statsGeneration.Load()
+	baselineCache.mu.Lock()
+	defer baselineCache.mu.Unlock()
+	if baselineCache.bucket == nil || baselineCache.generation != generation {
+		community := statsQuery{filter: segmentFilter{}.exclude(currentDefaults().ExcludedAppVersions)}
+		baselineCache.bucket = community.aggregate()
+		baselineCache.generation = generation
+	}
+	return baselineCache.bucket
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ========================
+// Rate Limiting (라우트 종류별 토큰 버킷)
+// ========================
+
+const (
+	trustedProxiesEnvVar = "SWORD_TRUSTED_PROXIES" // X-Forwarded-For를 신뢰할 프록시 (쉼표 구분 IP/CIDR)
+	defaultTrustedProxy  = "127.0.0.0/8,::1/128"   // 같은 서버의 리버스 프록시
+
+	limiterEvictInterval = time.Minute
+	limiterMaxEntries    = 100000 // 추적 최대 (종류, IP) 수
+)
+
+// 라우트 종류
+const (
+	limitTelemetry = "telemetry" // 텔레메트리 수신
+	limitRegister  = "register"  // 설치 등록 / 키 교체
+	limitStats     = "stats"     // 읽기 전용 통계 / 게임 데이터
+	limitAdmin     = "admin"     // 관리자 API
+)
+
+// rateLimit 토큰 버킷 설정
+type rateLimit struct {
+	perMinute float64 // 분당 충전량
+	burst     float64 // 버킷 크기 (연속 허용 요청 수)
+}
+
+// rateLimits 라우트 종류별 한도
+var rateLimits = map[string]rateLimit{
+	limitTelemetry: {perMinute: 60, burst: 30},
+	limitRegister:  {perMinute: 10, burst: 5},
+	limitStats:     {perMinute: 300, burst: 100},
+	limitAdmin:     {perMinute: 30, burst: 10},
+}
+
+// tokenBucket IP별 남은 토큰
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type limiterKey struct {
+	class string
+	ip    string
+}
+
+// rateLimiter (라우트 종류, IP)별 토큰 버킷
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[limiterKey]*tokenBucket
+	lastEvict time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[limiterKey]*tokenBucket)}
+}
+
+var limiter = newRateLimiter()
+
+// allow 요청 허용 여부 (허용 시 토큰 하나 사용)
+func (rl *rateLimiter) allow(class, ip string, now time.Time) bool {
+	limit, ok := rateLimits[class]
+	if !ok {
+		return true
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if now.Sub(rl.lastEvict) > limiterEvictInterval || len(rl.buckets) >= limiterMaxEntries {
+		rl.evict(now)
+	}
+
+	k := limiterKey{class, ip}
+	b := rl.buckets[k]
+	if b == nil {
+		if len(rl.buckets) >= limiterMaxEntries {
+			return false // 포화 시 안전하게 거부
+		}
+		b = &tokenBucket{tokens: limit.burst, last: now}
+		rl.buckets[k] = b
+	}
+
+	b.tokens += now.Sub(b.last).Minutes() * limit.perMinute
+	if b.tokens > limit.burst {
+		b.tokens = limit.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// evict 가득 찬 버킷 삭제 - 새로 만든 버킷과 같으므로 지워도 결과가 같음 (호출자가 Lock 보유)
+func (rl *rateLimiter) evict(now time.Time) {
+	for k, b := range rl.buckets {
+		limit := rateLimits[k.class]
+		if b.tokens+now.Sub(b.last).Minutes()*limit.perMinute >= limit.burst {
+			delete(rl.buckets, k)
+		}
+	}
+	rl.lastEvict = now
+}
+
+// size 추적 중인 (종류, IP) 수
+func (rl *rateLimiter) size() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return len(rl.buckets)
+}
+
+// limited 라우트 종류별 Rate Limit 적용 (CORS preflight 제외)
+func limited(class string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "OPTIONS" && !limiter.allow(class, getClientIP(r), time.Now()) {
+			if class == limitTelemetry {
+				rejectTelemetry(rejectRateLimit)
+			}
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// ========================
+// 클라이언트 IP (신뢰 프록시 처리)
+// ========================
+
+var trustedProxies = loadTrustedProxies()
+
+// loadTrustedProxies SWORD_TRUSTED_PROXIES 해석 (미설정 시 loopback, "none"이면 프록시 헤더 무시)
+func loadTrustedProxies() []netip.Prefix {
+	value, ok := os.LookupEnv(trustedProxiesEnvVar)
+	if !ok {
+		value = defaultTrustedProxy
+	}
+	if value == "none" {
+		return nil
+	}
+
+	var prefixes []netip.Prefix
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				log.Printf("⚠️ %s 항목 무시: %s", trustedProxiesEnvVar, s)
+				continue
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			log.Printf("⚠️ %s 항목 무시: %s", trustedProxiesEnvVar, s)
+			continue
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
+
+func isTrustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// getClientIP 클라이언트 IP 추출
+// 직접 연결한 주소가 신뢰 프록시일 때만 X-Forwarded-For를 오른쪽부터 따라가며,
+// 처음 나오는 신뢰하지 않는 주소를 클라이언트로 봄 (왼쪽 값은 클라이언트가 위조 가능)
+func getClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	addr = addr.Unmap()
+	if !isTrustedProxy(addr) {
+		return addr.String()
+	}
+
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	if len(hops) == 0 {
+		if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); xri != "" {
+			hops = []string{xri}
+		}
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break // 형식이 잘못된 값은 마지막으로 확인한 주소를 사용
+		}
+		addr = hop.Unmap()
+		if !isTrustedProxy(addr) {
+			break
+		}
+	}
+	return addr.String()
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/StopDragon/sword-macro-ai/internal/server/store"
+)
+
+// ========================
+// 세그먼트 필터 (앱 버전 / OS / 모드)
+// ========================
+//
+// 통계 조회 API 공통 파라미터:
+//   ?app_version=2.5.0,2.5.1   해당 버전만 (쉼표로 여러 개)
+//   ?exclude_app_version=2.5.0 해당 버전 제외
+//   ?os_type=windows&mode=battle
+// 포함 조건(app_version / os_type / mode)은 세그먼트 통계(0009 마이그레이션 이후 수신분)만 합산하고,
+// 제외 조건만 있으면 전체 통계에서 해당 세그먼트를 뺌
+
+const maxFilterVersions = 20 // 필터 하나에 지정할 수 있는 최대 버전 수
+
+// segmentFilter 세그먼트 조건
+type segmentFilter struct {
+	appVersions     map[string]bool // 비어있으면 전체
+	excludeVersions map[string]bool
+	osType          string
+	mode            string
+}
+
+// selective 포함 조건이 있는지 (있으면 세그먼트 통계만 사용)
+func (f segmentFilter) selective() bool {
+	return len(f.appVersions) > 0 || f.osType != "" || f.mode != ""
+}
+
+// empty 조건이 하나도 없는지
+func (f segmentFilter) empty() bool {
+	return !f.selective() && len(f.excludeVersions) == 0
+}
+
+// match 세그먼트가 조건에 맞는지
+func (f segmentFilter) match(seg store.Segment) bool {
+	if len(f.appVersions) > 0 && !f.appVersions[seg.AppVersion] {
+		return false
+	}
+	if f.excludeVersions[seg.AppVersion] {
+		return false
+	}
+	if f.osType != "" && seg.OSType != f.osType {
+		return false
+	}
+	if f.mode != "" && seg.Mode != f.mode {
+		return false
+	}
+	return true
+}
+
+// exclude 제외 버전 추가 (관리자 설정 반영용, 원본은 바꾸지 않음)
+func (f segmentFilter) exclude(versions []string) segmentFilter {
+	if len(versions) == 0 {
+		return f
+	}
+	merged := make(map[string]bool, len(f.excludeVersions)+len(versions))
+	for v := range f.excludeVersions {
+		merged[v] = true
+	}
+	for _, v := range versions {
+		merged[v] = true
+	}
+	f.excludeVersions = merged
+	return f
+}
+
+// statsQuery 조회 기간 + 세그먼트 조건
+type statsQuery struct {
+	since  string
+	filter segmentFilter
+}
+
+// parseStatsQuery 조회 기간과 세그먼트 필터 파라미터 해석
+func parseStatsQuery(r *http.Request) (statsQuery, error) {
+	return parseStatsParams(r.URL.Query())
+}
+
+// parseStatsParams 쿼리 파라미터에서 조회 기간과 세그먼트 필터 해석
+func parseStatsParams(q url.Values) (statsQuery, error) {
+	since, err := parseStatsSince(q)
+	if err != nil {
+		return statsQuery{}, err
+	}
+
+	var f segmentFilter
+	if f.appVersions, err = parseVersionList(q.Get("app_version")); err != nil {
+		return statsQuery{}, fmt.Errorf("invalid app_version: %v", err)
+	}
+	if f.excludeVersions, err = parseVersionList(q.Get("exclude_app_version")); err != nil {
+		return statsQuery{}, fmt.Errorf("invalid exclude_app_version: %v", err)
+	}
+	f.osType = q.Get("os_type")
+	if len(f.osType) > maxOSTypeLen {
+		return statsQuery{}, fmt.Errorf("os_type too long")
+	}
+	f.mode = q.Get("mode")
+	if len(f.mode) > maxModeLen {
+		return statsQuery{}, fmt.Errorf("mode too long")
+	}
+	return statsQuery{since: since, filter: f}, nil
+}
+
+// parseVersionList 쉼표로 구분한 버전 목록 (빈 문자열이면 nil)
+func parseVersionList(s string) (map[string]bool, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) > maxFilterVersions {
+		return nil, fmt.Errorf("too many versions (max %d)", maxFilterVersions)
+	}
+	versions := make(map[string]bool, len(parts))
+	for _, v := range parts {
+		v = strings.TrimSpace(v)
+		if v == "" || len(v) > maxAppVersionLen {
+			return nil, fmt.Errorf("empty or too long version")
+		}
+		versions[v] = true
+	}
+	return versions, nil
+}
+
+// aggregate 조건에 맞는 조회 기간 통계
+func (q statsQuery) aggregate() *store.Bucket {
+	if q.filter.empty() {
+		return st.Aggregate(q.since)
+	}
+
+	if q.filter.selective() {
+		b := store.NewBucket()
+		for _, sb := range st.Segments(q.since) {
+			if q.filter.match(sb.Segment) {
+				b.Merge(sb.Bucket)
+			}
+		}
+		return b
+	}
+
+	// 제외 조건만: 전체 통계에서 제외 세그먼트 차감
+	b := st.Aggregate(q.since)
+	for _, sb := range st.Segments(q.since) {
+		if !q.filter.match(sb.Segment) {
+			b.Merge(sb.Bucket.Negated())
+		}
+	}
+	return b
+}
+
+// daily 조건에 맞는 일별 통계 (period 오름차순)
+func (q statsQuery) daily() []store.DailyBucket {
+	if q.filter.empty() {
+		return st.Daily(q.since)
+	}
+
+	byPeriod := make(map[string]*store.Bucket)
+	var periods []string
+	if !q.filter.selective() {
+		for _, d := range st.Daily(q.since) {
+			byPeriod[d.Period] = d.Bucket
+			periods = append(periods, d.Period)
+		}
+	}
+	for _, sb := range st.Segments(q.since) {
+		matched := q.filter.match(sb.Segment)
+		if matched != q.filter.selective() {
+			// 포함 조건: 맞는 세그먼트만 합산 / 제외 조건: 맞지 않는 세그먼트만 차감
+			continue
+		}
+		b := byPeriod[sb.Period]
+		if b == nil {
+			b = store.NewBucket()
+			byPeriod[sb.Period] = b
+			periods = append(periods, sb.Period)
+		}
+		if matched {
+			b.Merge(sb.Bucket)
+		} else {
+			b.Merge(sb.Bucket.Negated())
+		}
+	}
+
+	// Daily와 Segments 모두 period 오름차순이므로 포함 조건에서는 이미 정렬됨
+	days := make([]store.DailyBucket, 0, len(periods))
+	for _, period := range periods {
+		days = append(days, store.DailyBucket{Period: period, Bucket: byPeriod[period]})
+	}
+	return days
+}
@@ -0,0 +1,1712 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/server/store"
+)
+
+// st 통계 저장소 (Open 전에는 인메모리)
+var st store.Store = store.NewMemory()
+
+const (
+	appSecretEnvVar     = "SWORD_APP_SECRET"     // 구 서명(X-App-Signature) 허용 시에만 설정
+	flushIntervalEnvVar = "SWORD_FLUSH_INTERVAL" // DB 배치 저장 주기 (기본 5s)
+
+	// 입력 검증 상수
+	maxSessionIDLen  = 100
+	maxAppVersionLen = 50
+	maxOSTypeLen     = 20
+	maxModeLen       = 20
+	maxPeriodLen     = 20
+	maxSwordNameLen  = 50
+	maxMapEntries    = 1000    // 맵 최대 항목 수
+	maxStatValue     = 1000000 // 단일 통계 최대값
+)
+
+// getAppSecret 구 클라이언트용 앱 시크릿 조회 (미설정 시 구 서명 거부)
+func getAppSecret() string {
+	return os.Getenv(appSecretEnvVar)
+}
+
+// ========================
+// 게임 데이터 구조체
+// ========================
+
+type EnhanceRate struct {
+	Level       int     `json:"level"`
+	SuccessRate float64 `json:"success_rate"`
+	KeepRate    float64 `json:"keep_rate"`
+	DestroyRate float64 `json:"destroy_rate"`
+}
+
+type SwordPrice struct {
+	Level    int `json:"level"`
+	MinPrice int `json:"min_price"`
+	MaxPrice int `json:"max_price"`
+	AvgPrice int `json:"avg_price"`
+}
+
+type BattleReward struct {
+	LevelDiff int     `json:"level_diff"`
+	WinRate   float64 `json:"win_rate"`
+	MinReward int     `json:"min_reward"`
+	MaxReward int     `json:"max_reward"`
+	AvgReward int     `json:"avg_reward"`
+}
+
+type GameData struct {
+	EnhanceRates  []EnhanceRate  `json:"enhance_rates"`
+	SwordPrices   []SwordPrice   `json:"sword_prices"`
+	BattleRewards []BattleReward `json:"battle_rewards"`
+	UpdatedAt     string         `json:"updated_at"`
+}
+
+// ========================
+// 조회 기간 (since / window)
+// ========================
+
+const (
+	maxWindowDays = 366 // window 파라미터 최대 일수
+)
+
+// parseStatsSince 조회 기간 파라미터 해석
+// ?since=2026-09-01 (해당 일자부터) 또는 ?window=7d (오늘 포함 최근 N일, 2w = 14일)
+// 반환: 시작 period ("" = 전체 누적)
+func parseStatsSince(q url.Values) (string, error) {
+	since := q.Get("since")
+	window := q.Get("window")
+
+	if since != "" && window != "" {
+		return "", fmt.Errorf("since and window are mutually exclusive")
+	}
+
+	if since != "" {
+		t, err := time.Parse(store.PeriodLayout, since)
+		if err != nil {
+			return "", fmt.Errorf("invalid since (expected YYYY-MM-DD)")
+		}
+		return t.Format(store.PeriodLayout), nil
+	}
+
+	if window != "" {
+		if len(window) < 2 {
+			return "", fmt.Errorf("invalid window (expected e.g. 7d or 2w)")
+		}
+		n, err := strconv.Atoi(window[:len(window)-1])
+		if err != nil || n < 1 {
+			return "", fmt.Errorf("invalid window (expected e.g. 7d or 2w)")
+		}
+		switch window[len(window)-1] {
+		case 'd':
+		case 'w':
+			n *= 7
+		default:
+			return "", fmt.Errorf("invalid window unit (use d or w)")
+		}
+		if n > maxWindowDays {
+			return "", fmt.Errorf("window too large (max %dd)", maxWindowDays)
+		}
+		return time.Now().AddDate(0, 0, -(n - 1)).Format(store.PeriodLayout), nil
+	}
+
+	return "", nil
+}
+
+// ========================
+// 게임 데이터 (실측 통계 + 기본값 혼합)
+// ========================
+
+const minSampleSize = 10 // 실측 데이터 사용 최소 샘플 수
+
+// 기본 강화 확률 (실측 데이터 부족 시 사용)
+var defaultEnhanceRates = []EnhanceRate{
+	{Level: 0, SuccessRate: 100.0, KeepRate: 0.0, DestroyRate: 0.0},
+	{Level: 1, SuccessRate: 95.0, KeepRate: 5.0, DestroyRate: 0.0},
+	{Level: 2, SuccessRate: 90.0, KeepRate: 10.0, DestroyRate: 0.0},
+	{Level: 3, SuccessRate: 85.0, KeepRate: 15.0, DestroyRate: 0.0},
+	{Level: 4, SuccessRate: 80.0, KeepRate: 20.0, DestroyRate: 0.0},
+	{Level: 5, SuccessRate: 70.0, KeepRate: 25.0, DestroyRate: 5.0},
+	{Level: 6, SuccessRate: 60.0, KeepRate: 30.0, DestroyRate: 10.0},
+	{Level: 7, SuccessRate: 50.0, KeepRate: 35.0, DestroyRate: 15.0},
+	{Level: 8, SuccessRate: 40.0, KeepRate: 40.0, DestroyRate: 20.0},
+	{Level: 9, SuccessRate: 30.0, KeepRate: 45.0, DestroyRate: 25.0},
+	{Level: 10, SuccessRate: 25.0, KeepRate: 45.0, DestroyRate: 30.0},
+	{Level: 11, SuccessRate: 20.0, KeepRate: 45.0, DestroyRate: 35.0},
+	{Level: 12, SuccessRate: 15.0, KeepRate: 45.0, DestroyRate: 40.0},
+	{Level: 13, SuccessRate: 10.0, KeepRate: 45.0, DestroyRate: 45.0},
+	{Level: 14, SuccessRate: 5.0, KeepRate: 45.0, DestroyRate: 50.0},
+}
+
+// 기본 배틀 보상 (실측 데이터 부족 시 사용)
+var defaultBattleRewards = []BattleReward{
+	{LevelDiff: 1, WinRate: 35.0, MinReward: 500, MaxReward: 1500, AvgReward: 1000},
+	{LevelDiff: 2, WinRate: 20.0, MinReward: 1500, MaxReward: 4000, AvgReward: 2750},
+	{LevelDiff: 3, WinRate: 10.0, MinReward: 4000, MaxReward: 10000, AvgReward: 7000},
+	{LevelDiff: 4, WinRate: 5.0, MinReward: 10000, MaxReward: 25000, AvgReward: 17500},
+	{LevelDiff: 5, WinRate: 3.0, MinReward: 25000, MaxReward: 60000, AvgReward: 42500},
+	{LevelDiff: 6, WinRate: 2.0, MinReward: 60000, MaxReward: 140000, AvgReward: 100000},
+	{LevelDiff: 7, WinRate: 1.5, MinReward: 140000, MaxReward: 300000, AvgReward: 220000},
+	{LevelDiff: 8, WinRate: 1.0, MinReward: 300000, MaxReward: 600000, AvgReward: 450000},
+	{LevelDiff: 9, WinRate: 0.7, MinReward: 600000, MaxReward: 1200000, AvgReward: 900000},
+	{LevelDiff: 10, WinRate: 0.5, MinReward: 1200000, MaxReward: 2500000, AvgReward: 1850000},
+	{LevelDiff: 11, WinRate: 0.35, MinReward: 2500000, MaxReward: 5000000, AvgReward: 3750000},
+	{LevelDiff: 12, WinRate: 0.25, MinReward: 5000000, MaxReward: 10000000, AvgReward: 7500000},
+	{LevelDiff: 13, WinRate: 0.18, MinReward: 10000000, MaxReward: 20000000, AvgReward: 15000000},
+	{LevelDiff: 14, WinRate: 0.12, MinReward: 20000000, MaxReward: 40000000, AvgReward: 30000000},
+	{LevelDiff: 15, WinRate: 0.08, MinReward: 40000000, MaxReward: 80000000, AvgReward: 60000000},
+	{LevelDiff: 16, WinRate: 0.05, MinReward: 80000000, MaxReward: 150000000, AvgReward: 115000000},
+	{LevelDiff: 17, WinRate: 0.03, MinReward: 150000000, MaxReward: 300000000, AvgReward: 225000000},
+	{LevelDiff: 18, WinRate: 0.02, MinReward: 300000000, MaxReward: 500000000, AvgReward: 400000000},
+	{LevelDiff: 19, WinRate: 0.01, MinReward: 500000000, MaxReward: 800000000, AvgReward: 650000000},
+	{LevelDiff: 20, WinRate: 0.005, MinReward: 800000000, MaxReward: 1000000000, AvgReward: 900000000},
+}
+
+// 기본 판매가 (게임에서 정해진 값)
+var defaultSwordPrices = []SwordPrice{
+	{Level: 0, MinPrice: 10, MaxPrice: 20, AvgPrice: 15},
+	{Level: 1, MinPrice: 30, MaxPrice: 50, AvgPrice: 40},
+	{Level: 2, MinPrice: 80, MaxPrice: 120, AvgPrice: 100},
+	{Level: 3, MinPrice: 200, MaxPrice: 300, AvgPrice: 250},
+	{Level: 4, MinPrice: 500, MaxPrice: 700, AvgPrice: 600},
+	{Level: 5, MinPrice: 1000, MaxPrice: 1500, AvgPrice: 1250},
+	{Level: 6, MinPrice: 2500, MaxPrice: 3500, AvgPrice: 3000},
+	{Level: 7, MinPrice: 6000, MaxPrice: 8000, AvgPrice: 7000},
+	{Level: 8, MinPrice: 15000, MaxPrice: 20000, AvgPrice: 17500},
+	{Level: 9, MinPrice: 40000, MaxPrice: 55000, AvgPrice: 47500},
+	{Level: 10, MinPrice: 100000, MaxPrice: 140000, AvgPrice: 120000},
+	{Level: 11, MinPrice: 280000, MaxPrice: 350000, AvgPrice: 315000},
+	{Level: 12, MinPrice: 800000, MaxPrice: 1000000, AvgPrice: 900000},
+	{Level: 13, MinPrice: 2500000, MaxPrice: 3200000, AvgPrice: 2850000},
+	{Level: 14, MinPrice: 8000000, MaxPrice: 10000000, AvgPrice: 9000000},
+	{Level: 15, MinPrice: 30000000, MaxPrice: 40000000, AvgPrice: 35000000},
+}
+
+// extractTypeLevel 키에서 타입과 레벨 추출
+// 키 형식: "{type}_{level}" (예: "normal_10", "special_5", "trash_3")
+// 반환: (타입, 레벨, 성공여부)
+func extractTypeLevel(key string) (string, int, bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) < 2 {
+		return "", 0, false
+	}
+
+	// 마지막 부분이 레벨 숫자
+	levelStr := parts[len(parts)-1]
+	level, err := strconv.Atoi(levelStr)
+	if err != nil {
+		return "", 0, false
+	}
+
+	// 나머지가 타입 (normal, special, trash만 허용)
+	itemType := strings.Join(parts[:len(parts)-1], "_")
+	if itemType != "normal" && itemType != "special" && itemType != "trash" {
+		return "", 0, false
+	}
+
+	return itemType, level, true
+}
+
+// getGameData 조회 조건에 맞는 통계 기반 게임 데이터 (기간이 비어있으면 전체 누적)
+// 관리자가 제외한 앱 버전(excluded_app_versions)은 항상 빠지고,
+// includeQuarantined면 검토 대기 중인 격리 페이로드도 합산
+func getGameData(q statsQuery, includeQuarantined bool) GameData {
+	q.filter = q.filter.exclude(currentDefaults().ExcludedAppVersions)
+	b := q.aggregate()
+	if includeQuarantined {
+		entries, err := st.Quarantined("")
+		if err != nil {
+			log.Printf("[게임데이터] 격리 목록 조회 실패: %v", err)
+		}
+		for i := range entries {
+			if entries[i].Period >= q.since && q.filter.match(store.SegmentOf(&entries[i].Payload)) {
+				b.Add(&entries[i].Payload)
+			}
+		}
+	}
+	return buildGameData(b)
+}
+
+// buildGameData 통계 버킷 + 기본값으로 게임 데이터 생성
+func buildGameData(b *store.Bucket) GameData {
+	defaults := currentDefaults()
+
+	// 강화 확률: 실측 데이터 반영
+	enhanceRates := make([]EnhanceRate, len(defaults.EnhanceRates))
+	copy(enhanceRates, defaults.EnhanceRates)
+
+	// v3: 레벨별 강화 상세 통계가 있으면 실측 확률로 대체
+	for i := range enhanceRates {
+		lvl := enhanceRates[i].Level
+		if detail, ok := b.EnhanceLevelDetail[lvl]; ok && detail.Attempts >= minSampleSize {
+			total := float64(detail.Attempts)
+			enhanceRates[i].SuccessRate = float64(detail.Success) / total * 100
+			enhanceRates[i].KeepRate = float64(detail.Fail) / total * 100
+			enhanceRates[i].DestroyRate = float64(detail.Destroy) / total * 100
+		}
+	}
+
+	// 배틀 보상: 실측 승률 반영
+	battleRewards := make([]BattleReward, len(defaults.BattleRewards))
+	copy(battleRewards, defaults.BattleRewards)
+
+	for i := range battleRewards {
+		diff := battleRewards[i].LevelDiff
+		if upsetStat, ok := b.UpsetStatsByDiff[diff]; ok && upsetStat.Attempts >= minSampleSize {
+			// 실측 승률로 대체
+			realWinRate := float64(upsetStat.Wins) / float64(upsetStat.Attempts) * 100
+			battleRewards[i].WinRate = realWinRate
+
+			// 실측 평균 보상으로 대체 (승리 시에만 보상이 있으므로)
+			if upsetStat.Wins > 0 {
+				battleRewards[i].AvgReward = upsetStat.GoldEarned / upsetStat.Wins
+			}
+		}
+	}
+
+	// 검 가격: 실측 판매 데이터 반영
+	swordPrices := make([]SwordPrice, len(defaults.SwordPrices))
+	copy(swordPrices, defaults.SwordPrices)
+
+	// swordSaleStats에서 레벨별 판매 통계 집계
+	// 키 형식: "{검이름}_{레벨}" (예: "불꽃검_10", "검_8")
+	levelSales := make(map[int]struct {
+		totalPrice int
+		count      int
+	})
+	for key, stat := range b.SwordSaleStats {
+		// 키에서 레벨 추출 (마지막 "_" 뒤의 숫자)
+		parts := strings.Split(key, "_")
+		if len(parts) < 2 {
+			continue
+		}
+		levelStr := parts[len(parts)-1]
+		level, err := strconv.Atoi(levelStr)
+		if err != nil {
+			continue
+		}
+		// 레벨별로 집계
+		entry := levelSales[level]
+		entry.totalPrice += stat.TotalPrice
+		entry.count += stat.Count
+		levelSales[level] = entry
+	}
+
+	// 실측 평균 가격으로 대체 (minSampleSize 이상일 때만)
+	for i := range swordPrices {
+		lvl := swordPrices[i].Level
+		if entry, ok := levelSales[lvl]; ok && entry.count >= minSampleSize {
+			realAvgPrice := entry.totalPrice / entry.count
+			swordPrices[i].AvgPrice = realAvgPrice
+			// MinPrice, MaxPrice도 실측 기준으로 추정 (±20%)
+			swordPrices[i].MinPrice = int(float64(realAvgPrice) * 0.8)
+			swordPrices[i].MaxPrice = int(float64(realAvgPrice) * 1.2)
+		}
+	}
+
+	return GameData{
+		EnhanceRates:  enhanceRates,
+		SwordPrices:   swordPrices,
+		BattleRewards: battleRewards,
+		UpdatedAt:     time.Now().Format(time.RFC3339),
+	}
+}
+
+// ========================
+// API 핸들러
+// ========================
+
+func handleGameData(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 격리된 이상치는 기본 제외 (?include_quarantined=1 로 포함)
+	includeQuarantined := r.URL.Query().Get("include_quarantined") == "1"
+
+	serveCached(w, r, q, func() interface{} { return getGameData(q, includeQuarantined) })
+}
+
+func handleTelemetry(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-App-Signature, X-Key-ID, X-Timestamp, X-Nonce, X-Signature")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clientIP := getClientIP(r)
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		rejectTelemetry(rejectBody)
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	// 서명 검증: 설치 키 HMAC 우선, 구 서명은 SWORD_APP_SECRET 설정 시에만 허용
+	legacySig := r.Header.Get("X-App-Signature")
+	keyID := r.Header.Get("X-Key-ID")
+	switch {
+	case keyID != "":
+		if _, err := verifyRequest(r, body); err != nil {
+			log.Printf("[텔레메트리] 인증 실패: %v (키=%s IP=%s)", err, keyID, clientIP)
+			rejectTelemetry(rejectSignature)
+			writeAuthError(w, err)
+			return
+		}
+	case legacySig != "" && getAppSecret() != "":
+		// 아래에서 페이로드 검증 후 확인
+	default:
+		rejectTelemetry(rejectSignature)
+		http.Error(w, "Missing signature", http.StatusUnauthorized)
+		return
+	}
+
+	var payload store.TelemetryPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		rejectTelemetry(rejectInvalidJSON)
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	// 입력 검증
+	if err := validateTelemetryPayload(&payload); err != nil {
+		log.Printf("[텔레메트리] 검증 실패: %v (IP=%s)", err, clientIP)
+		rejectTelemetry(rejectValidation)
+		http.Error(w, "Invalid payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 구 서명 검증 (본문 미포함 - 전환 기간에만 사용)
+	if keyID == "" {
+		expectedSig := generateSignature(payload.SessionID, payload.Period)
+		if !hmac.Equal([]byte(legacySig), []byte(expectedSig)) {
+			rejectTelemetry(rejectSignature)
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	// 일별 버킷은 서버 수신 시각 기준으로 검증 (시계가 틀리거나 조작된 period로 과거/미래 날짜에 쓰기 방지)
+	if period := receivePeriod(payload.Period, serverNow()); period != payload.Period {
+		log.Printf("[텔레메트리] period 보정: %q → %s (세션=%s)", payload.Period, period, shortSessionID(payload.SessionID))
+		payload.Period = period
+	}
+
+	// 이상치 검사: 커뮤니티 확률로 볼 때 우연히 나오기 어려운 결과면 집계 대신 격리
+	if score, reason, suspicious := quarantineCheck(&payload); suspicious {
+		err := st.QuarantinePayload(store.QuarantineEntry{
+			SessionID:  payload.SessionID,
+			Period:     store.NormalizePeriod(payload.Period),
+			Score:      score,
+			Reason:     reason,
+			ReceivedAt: time.Now().Unix(),
+			Payload:    payload,
+		})
+		if errors.Is(err, store.ErrDuplicate) {
+			// 이미 격리된 페이로드의 재전송
+			rejectTelemetry(rejectDuplicate)
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]string{"status": "duplicate"})
+			return
+		} else if err != nil {
+			log.Printf("[텔레메트리] 격리 저장 실패: %v", err)
+			metrics.dbSaveErrors.inc()
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		invalidateResponses() // ?include_quarantined=1 응답이 바뀜
+		log.Printf("🚨 [텔레메트리] 이상치 격리: 세션=%s 점수=%.1f (%s)", payload.SessionID, score, reason)
+		rejectTelemetry(rejectQuarantine)
+
+		// 재전송해도 결과가 같으므로 정상 응답
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		return
+	}
+
+	// 통계 반영 (전체 누적 + 일별, SQLite면 저장 대기열에 넣고 백그라운드에서 배치 저장)
+	// 이미 반영된 (session_id, seq)는 버리고 200 응답 (클라이언트 재전송이 성공으로 끝나도록)
+	ingestStart := time.Now()
+	err = st.Ingest(&payload)
+	metrics.ingestDuration.observe(time.Since(ingestStart).Seconds())
+	if errors.Is(err, store.ErrDuplicate) {
+		log.Printf("[텔레메트리] 중복 페이로드 무시: 세션=%s seq=%d (IP=%s)", payload.SessionID, payload.Seq, clientIP)
+		rejectTelemetry(rejectDuplicate)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"status": "duplicate"})
+		return
+	} else if err != nil {
+		log.Printf("[텔레메트리] 저장 실패: %v", err)
+		metrics.dbSaveErrors.inc()
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	invalidateResponses()
+
+	modeStr := payload.Mode
+	if modeStr == "" {
+		modeStr = "-"
+	}
+	metrics.telemetryIngested.inc(strconv.Itoa(payload.SchemaVersion), payload.AppVersion, payload.OSType, modeStr)
+	metrics.stateResyncs.add(float64(payload.Stats.StateResyncs))
+	metrics.stateDrifts.add(float64(payload.Stats.StateDrifts))
+	log.Printf("[텔레메트리] 세션=%s 버전=%s OS=%s 모드=%s v%d 이벤트=%d", shortSessionID(payload.SessionID), payload.AppVersion, payload.OSType, modeStr, payload.SchemaVersion, len(payload.Stats.Events))
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+func handleStatsDetailed(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b := q.aggregate()
+
+	// 강화 통계
+	enhanceTotal := b.EnhanceSuccess + b.EnhanceFail + b.EnhanceDestroy
+	successRate := "0%"
+	keepRate := "0%"
+	destroyRate := "0%"
+	if enhanceTotal > 0 {
+		successRate = fmt.Sprintf("%.1f%%", float64(b.EnhanceSuccess)/float64(enhanceTotal)*100)
+		keepRate = fmt.Sprintf("%.1f%%", float64(b.EnhanceFail)/float64(enhanceTotal)*100)
+		destroyRate = fmt.Sprintf("%.1f%%", float64(b.EnhanceDestroy)/float64(enhanceTotal)*100)
+	}
+
+	// 배틀 통계
+	battleWinRate := "0%"
+	upsetWinRate := "0%"
+	avgBattleGold := 0
+	if b.BattleCount > 0 {
+		battleWinRate = fmt.Sprintf("%.1f%%", float64(b.BattleWins)/float64(b.BattleCount)*100)
+		avgBattleGold = b.BattleGold / b.BattleCount
+	}
+	if b.UpsetAttempts > 0 {
+		upsetWinRate = fmt.Sprintf("%.1f%%", float64(b.UpsetWins)/float64(b.UpsetAttempts)*100)
+	}
+
+	// 파밍 통계
+	specialRate := "0%"
+	if b.FarmingAttempts > 0 {
+		specialRate = fmt.Sprintf("%.2f%%", float64(b.SpecialFound)/float64(b.FarmingAttempts)*100)
+	}
+
+	// 판매 통계
+	avgSalePrice := 0
+	if b.SalesCount > 0 {
+		avgSalePrice = b.SalesTotalGold / b.SalesCount
+	}
+
+	// 중복 거부 (재전송/재생된 페이로드)
+	duplicates := 0
+	for _, n := range st.Duplicates(q.since) {
+		duplicates += n
+	}
+
+	result := map[string]interface{}{
+		"강화": map[string]interface{}{
+			"총_시도":    enhanceTotal,
+			"성공률":     successRate,
+			"유지율":     keepRate,
+			"파괴율":     destroyRate,
+			"레벨별_성공": b.EnhanceByLevel,
+		},
+		"배틀": map[string]interface{}{
+			"총_대결":   b.BattleCount,
+			"승률":     battleWinRate,
+			"역배_시도": b.UpsetAttempts,
+			"역배_승률": upsetWinRate,
+			"총_전리품": fmt.Sprintf("%dG", b.BattleGold),
+			"평균_전리품": fmt.Sprintf("%dG", avgBattleGold),
+		},
+		"파밍": map[string]interface{}{
+			"총_시도":  b.FarmingAttempts,
+			"특수_확률": specialRate,
+		},
+		"판매": map[string]interface{}{
+			"총_판매": b.SalesCount,
+			"총_수익": fmt.Sprintf("%dG", b.SalesTotalGold),
+			"평균_가격": fmt.Sprintf("%dG", avgSalePrice),
+		},
+		"수집": map[string]interface{}{
+			"중복_거부": duplicates,
+		},
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "ok",
+		"time":   time.Now().Format(time.RFC3339),
+	})
+}
+
+// === v2 API 엔드포인트 ===
+
+// 검 종류별 승률 랭킹
+func handleSwordStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b := q.aggregate()
+
+	type SwordEntry struct {
+		Name         string  `json:"name"`
+		BattleCount  int     `json:"battle_count"`
+		WinRate      float64 `json:"win_rate"`
+		UpsetWinRate float64 `json:"upset_win_rate"`
+	}
+
+	var swords []SwordEntry
+	for name, stat := range b.SwordBattleStats {
+		winRate := 0.0
+		upsetWinRate := 0.0
+		if stat.BattleCount > 0 {
+			winRate = float64(stat.BattleWins) / float64(stat.BattleCount) * 100
+		}
+		if stat.UpsetAttempts > 0 {
+			upsetWinRate = float64(stat.UpsetWins) / float64(stat.UpsetAttempts) * 100
+		}
+		swords = append(swords, SwordEntry{
+			Name:         name,
+			BattleCount:  stat.BattleCount,
+			WinRate:      winRate,
+			UpsetWinRate: upsetWinRate,
+		})
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"swords": swords,
+	})
+}
+
+// 특수 검 출현 확률
+func handleSpecialStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(specialStats(q.aggregate()))
+}
+
+// specialStats 특수 검 이름별 출현 횟수 / 확률
+func specialStats(b *store.Bucket) map[string]interface{} {
+	type SpecialEntry struct {
+		Name  string  `json:"name"`
+		Count int     `json:"count"`
+		Rate  float64 `json:"rate"`
+	}
+
+	var specials []SpecialEntry
+	for name, cnt := range b.SpecialFoundByName {
+		rate := 0.0
+		if b.FarmingAttempts > 0 {
+			rate = float64(cnt) / float64(b.FarmingAttempts) * 100
+		}
+		specials = append(specials, SpecialEntry{
+			Name:  name,
+			Count: cnt,
+			Rate:  rate,
+		})
+	}
+
+	return map[string]interface{}{
+		"total_farming": b.FarmingAttempts,
+		"special":       specials,
+	}
+}
+
+// 역배 실측 승률
+func handleUpsetStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b := q.aggregate()
+
+	// 이론 승률: 기본 배틀 보상에서 추출
+	theoryRates := make(map[int]float64)
+	for _, br := range currentDefaults().BattleRewards {
+		theoryRates[br.LevelDiff] = br.WinRate
+	}
+
+	type DiffStat struct {
+		Attempts   int     `json:"attempts"`
+		Wins       int     `json:"wins"`
+		WinRate    float64 `json:"win_rate"`
+		Theory     float64 `json:"theory"`
+		GoldEarned int     `json:"gold_earned"`
+	}
+
+	byDiff := make(map[string]DiffStat)
+	for diff := 1; diff <= 20; diff++ {
+		stat := b.UpsetStatsByDiff[diff]
+		winRate := 0.0
+		attempts := 0
+		wins := 0
+		gold := 0
+		if stat != nil {
+			attempts = stat.Attempts
+			wins = stat.Wins
+			gold = stat.GoldEarned
+			if attempts > 0 {
+				winRate = float64(wins) / float64(attempts) * 100
+			}
+		}
+		byDiff[fmt.Sprintf("%d", diff)] = DiffStat{
+			Attempts:   attempts,
+			Wins:       wins,
+			WinRate:    winRate,
+			Theory:     theoryRates[diff],
+			GoldEarned: gold,
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"by_level_diff": byDiff,
+	})
+}
+
+// 아이템 파밍 통계
+func handleItemStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b := q.aggregate()
+
+	type ItemEntry struct {
+		Name         string  `json:"name"`
+		TotalCount   int     `json:"total_count"`
+		SpecialCount int     `json:"special_count"`
+		NormalCount  int     `json:"normal_count"`
+		SpecialRate  float64 `json:"special_rate"`
+	}
+
+	var items []ItemEntry
+	for name, stat := range b.ItemFarmingStats {
+		specialRate := 0.0
+		if stat.TotalCount > 0 {
+			specialRate = float64(stat.SpecialCount) / float64(stat.TotalCount) * 100
+		}
+		items = append(items, ItemEntry{
+			Name:         name,
+			TotalCount:   stat.TotalCount,
+			SpecialCount: stat.SpecialCount,
+			NormalCount:  stat.NormalCount,
+			SpecialRate:  specialRate,
+		})
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total_farming": b.FarmingAttempts,
+		"items":         items,
+	})
+}
+
+// 검 종류별 강화 성공률
+func handleEnhanceStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b := q.aggregate()
+
+	type EnhanceEntry struct {
+		Name        string  `json:"name"`
+		Attempts    int     `json:"attempts"`
+		Success     int     `json:"success"`
+		Fail        int     `json:"fail"`
+		Destroy     int     `json:"destroy"`
+		SuccessRate float64 `json:"success_rate"`
+		DestroyRate float64 `json:"destroy_rate"`
+	}
+
+	var swords []EnhanceEntry
+	for name, stat := range b.SwordEnhanceStats {
+		successRate := 0.0
+		destroyRate := 0.0
+		if stat.Attempts > 0 {
+			successRate = float64(stat.Success) / float64(stat.Attempts) * 100
+			destroyRate = float64(stat.Destroy) / float64(stat.Attempts) * 100
+		}
+		swords = append(swords, EnhanceEntry{
+			Name:        name,
+			Attempts:    stat.Attempts,
+			Success:     stat.Success,
+			Fail:        stat.Fail,
+			Destroy:     stat.Destroy,
+			SuccessRate: successRate,
+			DestroyRate: destroyRate,
+		})
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total_attempts": b.EnhanceAttempts,
+		"total_success":  b.EnhanceSuccess,
+		"total_destroy":  b.EnhanceDestroy,
+		"swords":         swords,
+	})
+}
+
+// 검 종류+레벨별 판매 통계
+func handleSaleStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(saleStats(q.aggregate()))
+}
+
+// saleStats 검 이름+레벨별 판매 건수 / 평균가
+func saleStats(b *store.Bucket) map[string]interface{} {
+	type SaleEntry struct {
+		Key        string `json:"key"`        // "검이름_레벨"
+		TotalPrice int    `json:"total_price"`
+		Count      int    `json:"count"`
+		AvgPrice   int    `json:"avg_price"`
+	}
+
+	var sales []SaleEntry
+	totalCount := 0
+	totalGold := 0
+
+	for key, stat := range b.SwordSaleStats {
+		avgPrice := 0
+		if stat.Count > 0 {
+			avgPrice = stat.TotalPrice / stat.Count
+		}
+		sales = append(sales, SaleEntry{
+			Key:        key,
+			TotalPrice: stat.TotalPrice,
+			Count:      stat.Count,
+			AvgPrice:   avgPrice,
+		})
+		totalCount += stat.Count
+		totalGold += stat.TotalPrice
+	}
+
+	return map[string]interface{}{
+		"total_sales": totalCount,
+		"total_gold":  totalGold,
+		"sales":       sales,
+	}
+}
+
+// 최적 판매 시점 계산 (시간 효율 기반)
+func handleOptimalSellPoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	serveCached(w, r, q, func() interface{} { return optimalSellPoint(q) })
+}
+
+// optimalSellPoint 레벨별 / 타입별 골드 효율과 최적 판매 레벨 계산
+func optimalSellPoint(q statsQuery) map[string]interface{} {
+	b := q.aggregate()
+
+	gameData := buildGameData(b)
+
+	// 레벨별 예상 강화 횟수 계산 (0부터 해당 레벨까지)
+	// 기대 시도 횟수 = Σ(1 / 성공률)
+	calcExpectedTrials := func(targetLevel int) float64 {
+		if targetLevel <= 0 {
+			return 0
+		}
+		total := 0.0
+		for lvl := 0; lvl < targetLevel && lvl < len(gameData.EnhanceRates); lvl++ {
+			rate := gameData.EnhanceRates[lvl].SuccessRate / 100.0
+			if rate > 0 {
+				total += 1.0 / rate
+			}
+		}
+		return total
+	}
+
+	// 예상 시간 계산 (초 단위)
+	// 실제 클라이언트 설정 기반:
+	// - TrashDelay: 1.2초 (파밍/판매 후)
+	// - LowDelay: 1.5초 (0-8강)
+	// - MidDelay: 2.5초 (9강)
+	// - HighDelay: 3.5초 (10강+)
+	// + 응답 대기/처리 오버헤드: 약 1초
+	calcExpectedTime := func(targetLevel int) float64 {
+		const (
+			farmTime     = 1.2 // TrashDelay (판매 후 새 검 받기)
+			lowDelay     = 2.5 // LowDelay(1.5) + 응답대기(1.0)
+			midDelay     = 3.5 // MidDelay(2.5) + 응답대기(1.0)
+			highDelay    = 4.5 // HighDelay(3.5) + 응답대기(1.0)
+			slowdownLvl  = 9   // SlowdownLevel
+		)
+
+		totalTime := farmTime
+		for lvl := 0; lvl < targetLevel && lvl < len(gameData.EnhanceRates); lvl++ {
+			rate := gameData.EnhanceRates[lvl].SuccessRate / 100.0
+			if rate <= 0 {
+				continue
+			}
+			expectedTries := 1.0 / rate
+
+			// 레벨별 딜레이 적용
+			var delay float64
+			if lvl >= 10 {
+				delay = highDelay
+			} else if lvl >= slowdownLvl {
+				delay = midDelay
+			} else {
+				delay = lowDelay
+			}
+			totalTime += expectedTries * delay
+		}
+		return totalTime
+	}
+
+	type LevelEfficiency struct {
+		Level              int     `json:"level"`
+		AvgPrice           int     `json:"avg_price"`
+		ExpectedTrials     float64 `json:"expected_trials"`     // 기대 강화 횟수
+		ExpectedTimeSecond float64 `json:"expected_time_second"` // 기대 소요 시간
+		SuccessProb        float64 `json:"success_prob"`        // 성공 확률 (%)
+		GoldPerMinute      float64 `json:"gold_per_minute"`     // 시간당 골드 효율
+		Recommendation     string  `json:"recommendation"`       // 추천 여부
+	}
+
+	var efficiencies []LevelEfficiency
+	bestLevel := 10
+	bestGPM := 0.0
+
+	// 레벨 5-15 범위에서 분석
+	for level := 5; level <= 15 && level < len(gameData.SwordPrices); level++ {
+		price := gameData.SwordPrices[level].AvgPrice
+		trials := calcExpectedTrials(level)
+		timeSeconds := calcExpectedTime(level)
+
+		// 성공 확률 (0부터 해당 레벨까지)
+		successProb := 1.0
+		for lvl := 0; lvl < level && lvl < len(gameData.EnhanceRates); lvl++ {
+			successProb *= gameData.EnhanceRates[lvl].SuccessRate / 100.0
+		}
+
+		// 시간당 골드 효율 = (판매가 × 성공확률) / (소요시간/60)
+		gpm := 0.0
+		if timeSeconds > 0 {
+			gpm = (float64(price) * successProb) / (timeSeconds / 60.0)
+		}
+
+		recommendation := ""
+		if gpm > bestGPM {
+			bestGPM = gpm
+			bestLevel = level
+		}
+
+		efficiencies = append(efficiencies, LevelEfficiency{
+			Level:              level,
+			AvgPrice:           price,
+			ExpectedTrials:     trials,
+			ExpectedTimeSecond: timeSeconds,
+			SuccessProb:        successProb * 100,
+			GoldPerMinute:      gpm,
+			Recommendation:     recommendation,
+		})
+	}
+
+	// 최적 레벨에 추천 표시
+	for i := range efficiencies {
+		if efficiencies[i].Level == bestLevel {
+			efficiencies[i].Recommendation = "optimal"
+		}
+	}
+
+	// 타입별 판매가 집계 (normal_10, special_10 등에서 추출)
+	typeLevelPrices := make(map[string]map[int]struct {
+		totalPrice int
+		count      int
+	})
+	for key, stat := range b.SwordSaleStats {
+		itemType, level, ok := extractTypeLevel(key)
+		if !ok {
+			continue
+		}
+		if typeLevelPrices[itemType] == nil {
+			typeLevelPrices[itemType] = make(map[int]struct {
+				totalPrice int
+				count      int
+			})
+		}
+		entry := typeLevelPrices[itemType][level]
+		entry.totalPrice += stat.TotalPrice
+		entry.count += stat.Count
+		typeLevelPrices[itemType][level] = entry
+	}
+
+	// 타입별 강화 확률 집계 (normal_10, special_10 등에서 추출)
+	typeLevelEnhance := make(map[string]map[int]struct {
+		attempts int
+		success  int
+	})
+	for key, stat := range b.SwordEnhanceStats {
+		itemType, level, ok := extractTypeLevel(key)
+		if !ok {
+			continue
+		}
+		if typeLevelEnhance[itemType] == nil {
+			typeLevelEnhance[itemType] = make(map[int]struct {
+				attempts int
+				success  int
+			})
+		}
+		entry := typeLevelEnhance[itemType][level]
+		entry.attempts += stat.Attempts
+		entry.success += stat.Success
+		typeLevelEnhance[itemType][level] = entry
+	}
+
+	// 타입별 강화 성공률 계산 (샘플 부족 시 기본값 사용)
+	getEnhanceRateForType := func(itemType string, level int) float64 {
+		if typeData, ok := typeLevelEnhance[itemType]; ok {
+			if entry, ok := typeData[level]; ok && entry.attempts >= minSampleSize {
+				return float64(entry.success) / float64(entry.attempts)
+			}
+		}
+		// 기본값 사용
+		if level < len(gameData.EnhanceRates) {
+			return gameData.EnhanceRates[level].SuccessRate / 100.0
+		}
+		return 0.05 // 매우 낮은 기본값
+	}
+
+	// 타입별 평균 판매가 계산 (샘플 부족 시 기본값 사용)
+	getAvgPriceForType := func(itemType string, level int) int {
+		if typeData, ok := typeLevelPrices[itemType]; ok {
+			if entry, ok := typeData[level]; ok && entry.count >= minSampleSize {
+				return entry.totalPrice / entry.count
+			}
+		}
+		// 기본값 사용
+		if level < len(gameData.SwordPrices) {
+			return gameData.SwordPrices[level].AvgPrice
+		}
+		return 0
+	}
+
+	// 타입별 예상 시간 계산
+	calcExpectedTimeForType := func(itemType string, targetLevel int) float64 {
+		const (
+			farmTime    = 1.2
+			lowDelay    = 2.5
+			midDelay    = 3.5
+			highDelay   = 4.5
+			slowdownLvl = 9
+		)
+
+		totalTime := farmTime
+		for lvl := 0; lvl < targetLevel; lvl++ {
+			rate := getEnhanceRateForType(itemType, lvl)
+			if rate <= 0 {
+				continue
+			}
+			expectedTries := 1.0 / rate
+
+			var delay float64
+			if lvl >= 10 {
+				delay = highDelay
+			} else if lvl >= slowdownLvl {
+				delay = midDelay
+			} else {
+				delay = lowDelay
+			}
+			totalTime += expectedTries * delay
+		}
+		return totalTime
+	}
+
+	// 타입별 최적 레벨 계산
+	type TypeOptimal struct {
+		Type           string  `json:"type"`
+		OptimalLevel   int     `json:"optimal_level"`
+		OptimalGPM     float64 `json:"optimal_gpm"`
+		SampleSize     int     `json:"sample_size"`
+		EnhanceSamples int     `json:"enhance_samples"`
+		IsDefault      bool    `json:"is_default"`
+	}
+
+	calcTypeOptimal := func(itemType string) TypeOptimal {
+		bestLvl := 10
+		bestGpm := 0.0
+		totalSales := 0
+		totalEnhance := 0
+
+		// 해당 타입의 총 샘플 수 계산
+		if typeData, ok := typeLevelPrices[itemType]; ok {
+			for _, entry := range typeData {
+				totalSales += entry.count
+			}
+		}
+		if typeData, ok := typeLevelEnhance[itemType]; ok {
+			for _, entry := range typeData {
+				totalEnhance += entry.attempts
+			}
+		}
+
+		isDefault := totalSales < minSampleSize || totalEnhance < minSampleSize
+
+		for level := 5; level <= 15; level++ {
+			price := getAvgPriceForType(itemType, level)
+			timeSeconds := calcExpectedTimeForType(itemType, level)
+
+			// 성공 확률 계산
+			successProb := 1.0
+			for lvl := 0; lvl < level; lvl++ {
+				successProb *= getEnhanceRateForType(itemType, lvl)
+			}
+
+			gpm := 0.0
+			if timeSeconds > 0 {
+				gpm = (float64(price) * successProb) / (timeSeconds / 60.0)
+			}
+
+			if gpm > bestGpm {
+				bestGpm = gpm
+				bestLvl = level
+			}
+		}
+
+		return TypeOptimal{
+			Type:           itemType,
+			OptimalLevel:   bestLvl,
+			OptimalGPM:     bestGpm,
+			SampleSize:     totalSales,
+			EnhanceSamples: totalEnhance,
+			IsDefault:      isDefault,
+		}
+	}
+
+	typeOptimalLevels := map[string]TypeOptimal{
+		"normal":  calcTypeOptimal("normal"),
+		"special": calcTypeOptimal("special"),
+		"trash":   calcTypeOptimal("trash"),
+	}
+
+	// 타입별 레벨 효율 테이블 계산
+	type TypeLevelEfficiency struct {
+		Level              int     `json:"level"`
+		AvgPrice           int     `json:"avg_price"`
+		ExpectedTrials     float64 `json:"expected_trials"`
+		ExpectedTimeSecond float64 `json:"expected_time_second"`
+		SuccessProb        float64 `json:"success_prob"`
+		GoldPerMinute      float64 `json:"gold_per_minute"`
+		SampleSize         int     `json:"sample_size"`
+		Recommendation     string  `json:"recommendation"`
+	}
+
+	calcTypeEfficiencies := func(itemType string) []TypeLevelEfficiency {
+		var typeEffs []TypeLevelEfficiency
+		bestLvl := 10
+		bestGpm := 0.0
+
+		// 해당 타입의 레벨별 샘플 수 계산
+		getSampleSize := func(level int) int {
+			if typeData, ok := typeLevelPrices[itemType]; ok {
+				if entry, ok := typeData[level]; ok {
+					return entry.count
+				}
+			}
+			return 0
+		}
+
+		// 레벨 5-15 범위에서 분석
+		for level := 5; level <= 15; level++ {
+			price := getAvgPriceForType(itemType, level)
+			timeSeconds := calcExpectedTimeForType(itemType, level)
+			sampleSize := getSampleSize(level)
+
+			// 성공 확률 계산 (타입별)
+			successProb := 1.0
+			expectedTrials := 0.0
+			for lvl := 0; lvl < level; lvl++ {
+				rate := getEnhanceRateForType(itemType, lvl)
+				successProb *= rate
+				if rate > 0 {
+					expectedTrials += 1.0 / rate
+				}
+			}
+
+			gpm := 0.0
+			if timeSeconds > 0 {
+				gpm = (float64(price) * successProb) / (timeSeconds / 60.0)
+			}
+
+			if gpm > bestGpm {
+				bestGpm = gpm
+				bestLvl = level
+			}
+
+			typeEffs = append(typeEffs, TypeLevelEfficiency{
+				Level:              level,
+				AvgPrice:           price,
+				ExpectedTrials:     expectedTrials,
+				ExpectedTimeSecond: timeSeconds,
+				SuccessProb:        successProb * 100,
+				GoldPerMinute:      gpm,
+				SampleSize:         sampleSize,
+				Recommendation:     "",
+			})
+		}
+
+		// 최적 레벨에 추천 표시
+		for i := range typeEffs {
+			if typeEffs[i].Level == bestLvl {
+				typeEffs[i].Recommendation = "optimal"
+			}
+		}
+
+		return typeEffs
+	}
+
+	levelEfficienciesByType := map[string][]TypeLevelEfficiency{
+		"normal":  calcTypeEfficiencies("normal"),
+		"special": calcTypeEfficiencies("special"),
+		"trash":   calcTypeEfficiencies("trash"),
+	}
+
+	return map[string]interface{}{
+		"optimal_level":              bestLevel,
+		"optimal_gpm":                bestGPM,
+		"level_efficiencies":         efficiencies,
+		"by_type":                    typeOptimalLevels,
+		"level_efficiencies_by_type": levelEfficienciesByType,
+		"note":                       "gold_per_minute = (avg_price × success_prob) / (expected_time / 60)",
+	}
+}
+
+// v3: 레벨별 강화 실측 통계
+func handleEnhanceLevelDetail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b := q.aggregate()
+
+	type LevelEntry struct {
+		Level       int     `json:"level"`
+		Attempts    int     `json:"attempts"`
+		Success     int     `json:"success"`
+		Fail        int     `json:"fail"`
+		Destroy     int     `json:"destroy"`
+		SuccessRate float64 `json:"success_rate"`
+		KeepRate    float64 `json:"keep_rate"`
+		DestroyRate float64 `json:"destroy_rate"`
+		Default     bool    `json:"is_default"` // 기본값 사용 여부
+	}
+
+	var levels []LevelEntry
+	for _, def := range currentDefaults().EnhanceRates {
+		entry := LevelEntry{
+			Level:       def.Level,
+			SuccessRate: def.SuccessRate,
+			KeepRate:    def.KeepRate,
+			DestroyRate: def.DestroyRate,
+			Default:     true,
+		}
+		if detail, ok := b.EnhanceLevelDetail[def.Level]; ok && detail.Attempts > 0 {
+			entry.Attempts = detail.Attempts
+			entry.Success = detail.Success
+			entry.Fail = detail.Fail
+			entry.Destroy = detail.Destroy
+			total := float64(detail.Attempts)
+			entry.SuccessRate = float64(detail.Success) / total * 100
+			entry.KeepRate = float64(detail.Fail) / total * 100
+			entry.DestroyRate = float64(detail.Destroy) / total * 100
+			entry.Default = detail.Attempts < minSampleSize
+		}
+		levels = append(levels, entry)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"min_sample_size": minSampleSize,
+		"levels":          levels,
+	})
+}
+
+// 일별 통계 추이 (차트용)
+func handleDailyStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q, err := parseStatsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	type DailyEntry struct {
+		Period             string  `json:"period"`
+		EnhanceAttempts    int     `json:"enhance_attempts"`
+		EnhanceSuccessRate float64 `json:"enhance_success_rate"`
+		EnhanceDestroyRate float64 `json:"enhance_destroy_rate"`
+		BattleCount        int     `json:"battle_count"`
+		BattleWinRate      float64 `json:"battle_win_rate"`
+		FarmingAttempts    int     `json:"farming_attempts"`
+		SpecialRate        float64 `json:"special_rate"`
+		SalesCount         int     `json:"sales_count"`
+		AvgSalePrice       int     `json:"avg_sale_price"`
+		DuplicatesRejected int     `json:"duplicates_rejected"`
+	}
+
+	daily := q.daily()
+	dups := st.Duplicates(q.since)
+	days := make([]DailyEntry, 0, len(daily))
+	for _, b := range daily {
+		entry := DailyEntry{
+			Period:             b.Period,
+			EnhanceAttempts:    b.EnhanceAttempts,
+			BattleCount:        b.BattleCount,
+			FarmingAttempts:    b.FarmingAttempts,
+			SalesCount:         b.SalesCount,
+			DuplicatesRejected: dups[b.Period],
+		}
+		if enhanceTotal := b.EnhanceSuccess + b.EnhanceFail + b.EnhanceDestroy; enhanceTotal > 0 {
+			entry.EnhanceSuccessRate = float64(b.EnhanceSuccess) / float64(enhanceTotal) * 100
+			entry.EnhanceDestroyRate = float64(b.EnhanceDestroy) / float64(enhanceTotal) * 100
+		}
+		if b.BattleCount > 0 {
+			entry.BattleWinRate = float64(b.BattleWins) / float64(b.BattleCount) * 100
+		}
+		if b.FarmingAttempts > 0 {
+			entry.SpecialRate = float64(b.SpecialFound) / float64(b.FarmingAttempts) * 100
+		}
+		if b.SalesCount > 0 {
+			entry.AvgSalePrice = b.SalesTotalGold / b.SalesCount
+		}
+		days = append(days, entry)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"days": days,
+	})
+}
+
+// serverNow 서버 현재 시각 (테스트에서 교체)
+var serverNow = time.Now
+
+// maxPeriodSkew 클라이언트 period와 서버 날짜의 허용 차이 (시간대 차이 포함)
+const maxPeriodSkew = 24 * time.Hour
+
+// receivePeriod 페이로드를 기록할 일별 키
+// 서버 날짜와 하루 넘게 차이 나거나 형식이 잘못되면 수신 날짜 사용
+func receivePeriod(period string, now time.Time) string {
+	today := now.Format(store.PeriodLayout)
+	t, err := time.Parse(store.PeriodLayout, period)
+	if err != nil {
+		return today
+	}
+	base, _ := time.Parse(store.PeriodLayout, today)
+	if d := t.Sub(base); d > maxPeriodSkew || d < -maxPeriodSkew {
+		return today
+	}
+	return period
+}
+
+// shortSessionID 로그용으로 줄인 세션 ID (8자보다 짧으면 그대로)
+func shortSessionID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func generateSignature(sessionID, period string) string {
+	h := sha256.Sum256([]byte(sessionID + period + getAppSecret()))
+	return hex.EncodeToString(h[:])[:16]
+}
+
+// validateTelemetryPayload 텔레메트리 페이로드 검증
+func validateTelemetryPayload(p *store.TelemetryPayload) error {
+	// 필수 필드 검증
+	if p.SessionID == "" {
+		return fmt.Errorf("session_id is required")
+	}
+	if len(p.SessionID) > maxSessionIDLen {
+		return fmt.Errorf("session_id too long")
+	}
+	if len(p.AppVersion) > maxAppVersionLen {
+		return fmt.Errorf("app_version too long")
+	}
+	if len(p.OSType) > maxOSTypeLen {
+		return fmt.Errorf("os_type too long")
+	}
+	if len(p.Mode) > maxModeLen {
+		return fmt.Errorf("mode too long")
+	}
+	if len(p.Period) > maxPeriodLen {
+		return fmt.Errorf("period too long")
+	}
+
+	// 스키마 버전 검증
+	if p.SchemaVersion < 1 || p.SchemaVersion > 10 {
+		return fmt.Errorf("invalid schema_version")
+	}
+	if p.Seq < 0 {
+		return fmt.Errorf("invalid seq")
+	}
+
+	// 통계 값 범위 검증
+	if err := validateStatValues(&p.Stats); err != nil {
+		return err
+	}
+
+	// v4 이벤트 검증
+	if err := validateEvents(p); err != nil {
+		return err
+	}
+
+	// 맵 크기 검증
+	if len(p.Stats.EnhanceByLevel) > maxMapEntries {
+		return fmt.Errorf("enhance_by_level too many entries")
+	}
+	if len(p.Stats.SwordBattleStats) > maxMapEntries {
+		return fmt.Errorf("sword_battle_stats too many entries")
+	}
+	if len(p.Stats.SpecialFoundByName) > maxMapEntries {
+		return fmt.Errorf("special_found_by_name too many entries")
+	}
+	if len(p.Stats.UpsetStatsByDiff) > maxMapEntries {
+		return fmt.Errorf("upset_stats_by_diff too many entries")
+	}
+	if len(p.Stats.SwordSaleStats) > maxMapEntries {
+		return fmt.Errorf("sword_sale_stats too many entries")
+	}
+	if len(p.Stats.SwordEnhanceStats) > maxMapEntries {
+		return fmt.Errorf("sword_enhance_stats too many entries")
+	}
+	if len(p.Stats.ItemFarmingStats) > maxMapEntries {
+		return fmt.Errorf("item_farming_stats too many entries")
+	}
+	if len(p.Stats.EnhanceLevelDetail) > maxMapEntries {
+		return fmt.Errorf("enhance_level_detail too many entries")
+	}
+	if len(p.Stats.BattleMatchups) > maxMapEntries {
+		return fmt.Errorf("battle_matchups too many entries")
+	}
+
+	// 맵 키 길이 검증
+	for name := range p.Stats.SwordBattleStats {
+		if len(name) > maxSwordNameLen {
+			return fmt.Errorf("sword name too long: %s", name)
+		}
+	}
+	for name := range p.Stats.SpecialFoundByName {
+		if len(name) > maxSwordNameLen {
+			return fmt.Errorf("special name too long: %s", name)
+		}
+	}
+	for name := range p.Stats.ItemFarmingStats {
+		if len(name) > maxSwordNameLen {
+			return fmt.Errorf("item name too long: %s", name)
+		}
+	}
+	for name := range p.Stats.SwordEnhanceStats {
+		if len(name) > maxSwordNameLen {
+			return fmt.Errorf("enhance sword name too long: %s", name)
+		}
+	}
+
+	return nil
+}
+
+// validateStatValues 통계 값 범위 검증 (음수 및 과도하게 큰 값 방지)
+func validateStatValues(s *store.TelemetryStats) error {
+	// 음수 검증
+	if s.TotalCycles < 0 || s.SuccessfulCycles < 0 || s.FailedCycles < 0 {
+		return fmt.Errorf("negative cycle values")
+	}
+	if s.TotalGoldMined < 0 || s.BattleGoldEarned < 0 {
+		return fmt.Errorf("negative gold values")
+	}
+	if s.EnhanceAttempts < 0 || s.BattleCount < 0 || s.FarmingAttempts < 0 {
+		return fmt.Errorf("negative attempt values")
+	}
+
+	// 최대값 검증
+	if s.TotalCycles > maxStatValue || s.EnhanceAttempts > maxStatValue {
+		return fmt.Errorf("stat value too large")
+	}
+	if s.BattleCount > maxStatValue || s.FarmingAttempts > maxStatValue {
+		return fmt.Errorf("stat value too large")
+	}
+
+	// 레벨 범위 검증 (EnhanceByLevel)
+	for level, count := range s.EnhanceByLevel {
+		if level < 0 || level > 20 {
+			return fmt.Errorf("invalid enhance level: %d", level)
+		}
+		if count < 0 || count > maxStatValue {
+			return fmt.Errorf("invalid enhance count for level %d", level)
+		}
+	}
+
+	// v3 값 검증
+	if s.EnhanceCostTotal < 0 || s.BattleGoldLost < 0 {
+		return fmt.Errorf("negative v3 gold values")
+	}
+	if s.CycleTimeTotal < 0 {
+		return fmt.Errorf("negative cycle time")
+	}
+	for lvl, stat := range s.EnhanceLevelDetail {
+		if lvl < 0 || lvl > 20 {
+			return fmt.Errorf("invalid enhance level detail: %d", lvl)
+		}
+		if stat != nil && (stat.Attempts < 0 || stat.Success < 0 || stat.Fail < 0 || stat.Destroy < 0) {
+			return fmt.Errorf("negative enhance level detail for level %d", lvl)
+		}
+	}
+
+	// 역배 레벨차 검증 (1-20 허용)
+	for diff, stat := range s.UpsetStatsByDiff {
+		if diff < 1 || diff > 20 {
+			return fmt.Errorf("invalid upset level diff: %d", diff)
+		}
+		if stat != nil && (stat.Attempts < 0 || stat.Wins < 0 || stat.GoldEarned < 0) {
+			return fmt.Errorf("negative upset stats for diff %d", diff)
+		}
+	}
+
+	// 상태 재동기화 검증 (불일치는 재동기화 중 일부)
+	if s.StateResyncs < 0 || s.StateDrifts < 0 || s.StateDrifts > s.StateResyncs || s.StateResyncs > maxStatValue {
+		return fmt.Errorf("invalid state resync values")
+	}
+
+	// v4 배틀 조합 검증 (키: 검타입_내레벨_상대레벨)
+	for key, stat := range s.BattleMatchups {
+		itemType, my, opp, ok := store.ParseMatchupKey(key)
+		if !ok || (itemType != "normal" && itemType != "special" && itemType != "trash") {
+			return fmt.Errorf("invalid battle matchup key: %s", key)
+		}
+		if my < 0 || my > maxEventLevel || opp < 0 || opp > maxEventLevel {
+			return fmt.Errorf("invalid battle matchup level: %s", key)
+		}
+		if stat == nil || stat.Battles < 0 || stat.Wins < 0 || stat.Wins > stat.Battles ||
+			stat.GoldEarned < 0 || stat.GoldLost < 0 || stat.Battles > maxStatValue {
+			return fmt.Errorf("invalid battle matchup stats for %s", key)
+		}
+	}
+
+	return nil
+}
+
+// ========================
+// SQLite 영구 저장소
+// ========================
+
+// ========================
+// 서버 구성 (cmd/sword-api)
+// ========================
+
+// Open SQLite 저장소 열기 및 저장된 통계/설치 키 로드
+// 실패 시 에러를 반환하며, 인메모리 저장소로 계속 동작할 수 있음
+func Open(dbPath string) error {
+	if v := os.Getenv(flushIntervalEnvVar); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%s 형식 오류: %q (예: 5s)", flushIntervalEnvVar, v)
+		}
+		store.FlushInterval = d
+	}
+
+	sl, err := store.OpenSQLite(dbPath)
+	if err != nil {
+		return err
+	}
+	sl.SetFlushHook(func(payloads int, took time.Duration, err error) {
+		metrics.dbSaveDuration.observe(took.Seconds())
+		if err != nil {
+			metrics.dbSaveErrors.inc()
+		}
+	})
+	st = sl
+	invalidateResponses()
+	if err := loadKeys(); err != nil {
+		return err
+	}
+	return loadOverrides()
+}
+
+// Close 저장소 닫기
+func Close() {
+	if err := st.Close(); err != nil {
+		log.Printf("[DB] 닫기 실패: %v", err)
+	}
+	st = store.NewMemory()
+
+	defaultsMu.Lock()
+	activeDefaults = builtinDefaults()
+	defaultsMu.Unlock()
+	invalidateResponses()
+}
+
+// RebuildFromEvents 저장된 개별 이벤트로부터 집계 재구성 (Open 이후 호출)
+// force가 아니면 이벤트가 없는 v1-v3 페이로드가 남아 있을 때 거부
+func RebuildFromEvents(force bool) error {
+	events, days, err := st.RebuildFromEvents(force)
+	if errors.Is(err, store.ErrLegacyPayloads) {
+		return fmt.Errorf("%v - 재구성하면 해당 통계가 사라집니다 (계속하려면 --force)", err)
+	}
+	recordAudit(cliActor(), "rebuild_from_events", "", fmt.Sprintf("events=%d days=%d force=%v", events, days, force), err)
+	if err != nil {
+		return err
+	}
+	log.Printf("⚠️ 이벤트가 없는 v1-v3 페이로드 통계는 재구성에 포함되지 않습니다")
+	return nil
+}
+
+// Migrate DB 스키마 마이그레이션 (dryRun이면 DB를 바꾸지 않고 적용 예정 내역만 출력)
+func Migrate(dbPath string, dryRun bool) error {
+	if dryRun {
+		steps, err := store.PlanMigrations(dbPath)
+		if err != nil {
+			return err
+		}
+		if len(steps) == 0 {
+			log.Printf("✅ 적용할 마이그레이션 없음")
+			return nil
+		}
+		for _, step := range steps {
+			log.Printf("📋 %04d_%s", step.Version, step.Name)
+			for i, stmt := range step.Statements {
+				if step.Skipped[i] {
+					log.Printf("   (건너뜀: 이미 반영됨) %s", stmt)
+				} else {
+					log.Printf("   %s", stmt)
+				}
+			}
+		}
+		log.Printf("📋 적용 예정 마이그레이션 %d건 (--dry-run: 변경 없음)", len(steps))
+		return nil
+	}
+
+	steps, err := store.MigrateSQLite(dbPath)
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		log.Printf("📦 스키마 마이그레이션 적용: %04d_%s", step.Version, step.Name)
+	}
+	log.Printf("✅ 마이그레이션 완료 (%d건 적용)", len(steps))
+	return nil
+}
+
+// NewMux API 라우팅 등록
+func NewMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	handle := func(route string, h http.HandlerFunc) {
+		mux.HandleFunc(route, instrument(route, h))
+	}
+	handle("/", handleHealth)
+	handle("/api/health", handleHealth)
+	handle("/api/game-data", limited(limitStats, handleGameData))
+	handle("/api/telemetry", limited(limitTelemetry, handleTelemetry))
+	handle("/api/register", limited(limitRegister, handleRegister))
+	handle("/api/register/rotate", limited(limitRegister, handleRotateKey))
+	handle("/api/admin/quarantine", limited(limitAdmin, handleAdminQuarantine))
+	handle("/api/admin/sessions", limited(limitAdmin, handleAdminSessions))
+	handle("/api/admin/sessions/delete", limited(limitAdmin, handleAdminDeleteSession))
+	handle("/api/admin/reset-table", limited(limitAdmin, handleAdminResetTable))
+	handle("/api/admin/defaults", limited(limitAdmin, handleAdminDefaults))
+	handle("/api/admin/recompute", limited(limitAdmin, handleAdminRecompute))
+	handle("/api/admin/audit", limited(limitAdmin, handleAdminAudit))
+	handle("/api/stats/detailed", limited(limitStats, handleStatsDetailed))
+	// v2 엔드포인트
+	handle("/api/stats/swords", limited(limitStats, handleSwordStats))
+	handle("/api/stats/special", limited(limitStats, handleSpecialStats))
+	handle("/api/stats/upset", limited(limitStats, handleUpsetStats))
+	handle("/api/stats/items", limited(limitStats, handleItemStats))
+	handle("/api/stats/enhance", limited(limitStats, handleEnhanceStats))
+	handle("/api/stats/sales", limited(limitStats, handleSaleStats))
+	handle("/api/strategy/optimal-sell-point", limited(limitStats, handleOptimalSellPoint))
+	handle("/api/battle/matchup", limited(limitStats, handleBattleMatchup))
+	handle("/api/items/catalog", limited(limitStats, handleItemCatalog))
+	// v3 엔드포인트
+	handle("/api/stats/enhance-levels", limited(limitStats, handleEnhanceLevelDetail))
+	handle("/api/stats/daily", limited(limitStats, handleDailyStats))
+	// 운영 모니터링
+	handle("/metrics", handleMetrics)
+	return mux
+}
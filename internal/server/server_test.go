@@ -0,0 +1,982 @@
+package server
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/server/store"
+)
+
+// newTestMux 인메모리 저장소와 빈 인증/제한 상태로 초기화한 라우터
+func newTestMux(t *testing.T) http.Handler {
+	t.Helper()
+	st = store.NewMemory()
+	keys = &keyStore{keys: make(map[string]*store.InstallKey)}
+	nonces = &nonceCache{seen: make(map[string]time.Time)}
+	limiter = newRateLimiter()
+	activeDefaults = builtinDefaults()
+	metrics = newServerMetrics()
+	responses = newResponseCache()
+	setServerDate(t, "2026-01-01")
+	invalidateResponses() // 이전 테스트 저장소로 계산한 기준 통계 무효화
+	return NewMux()
+}
+
+// setServerDate 서버 수신 시각을 해당 날짜 정오로 고정 (테스트 종료 시 복원)
+func setServerDate(t *testing.T, period string) {
+	t.Helper()
+	day, err := time.Parse(store.PeriodLayout, period)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverNow = func() time.Time { return day.Add(12 * time.Hour) }
+	t.Cleanup(func() { serverNow = time.Now })
+}
+
+func do(mux http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	req.RemoteAddr = "192.0.2.1:1234"
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// register 설치 등록 후 (키 ID, 키) 반환
+func register(t *testing.T, mux http.Handler) (string, []byte) {
+	t.Helper()
+	rec := do(mux, httptest.NewRequest("POST", "/api/register", bytes.NewBufferString(`{"app_version":"test","os_type":"linux"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("register: status %d", rec.Code)
+	}
+	var resp registerResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	key, err := hex.DecodeString(resp.Key)
+	if err != nil {
+		t.Fatalf("register: bad key: %v", err)
+	}
+	return resp.KeyID, key
+}
+
+// signedRequest 설치 키로 서명한 POST 요청
+func signedRequest(path, keyID string, key, body []byte, ts time.Time, nonce string) *http.Request {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	req := httptest.NewRequest("POST", path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Key-ID", keyID)
+	req.Header.Set("X-Timestamp", timestamp)
+	req.Header.Set("X-Nonce", nonce)
+	req.Header.Set("X-Signature", computeSignature(key, timestamp, nonce, body))
+	return req
+}
+
+func payloadJSON(t *testing.T, period string, stats store.TelemetryStats) []byte {
+	t.Helper()
+	data, err := json.Marshal(store.TelemetryPayload{
+		SchemaVersion: 3,
+		AppVersion:    "test",
+		OSType:        "linux",
+		SessionID:     "session-0000-test",
+		Period:        period,
+		Stats:         stats,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestTelemetryUpdatesGameData(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+
+	body := payloadJSON(t, time.Now().Format(store.PeriodLayout), store.TelemetryStats{
+		EnhanceAttempts: 20,
+		EnhanceSuccess:  5,
+		EnhanceFail:     15,
+		EnhanceLevelDetail: map[int]*store.EnhanceLevelStat{
+			3: {Attempts: 20, Success: 5, Fail: 15},
+		},
+	})
+	rec := do(mux, signedRequest("/api/telemetry", keyID, key, body, time.Now(), "nonce-0000000000000001"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("telemetry: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(mux, httptest.NewRequest("GET", "/api/game-data", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("game-data: status %d", rec.Code)
+	}
+	var data GameData
+	if err := json.NewDecoder(rec.Body).Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range data.EnhanceRates {
+		if r.Level == 3 && r.SuccessRate != 25 {
+			t.Errorf("level 3 success rate = %v, want 25", r.SuccessRate)
+		}
+	}
+}
+
+func TestGameDataETag(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		return do(mux, req)
+	}
+
+	for _, path := range []string{"/api/game-data", "/api/strategy/optimal-sell-point"} {
+		rec := get(path, "")
+		etag := rec.Header().Get("ETag")
+		if rec.Code != http.StatusOK || etag == "" {
+			t.Fatalf("%s: status %d etag %q", path, rec.Code, etag)
+		}
+		if rec := get(path, etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("%s: If-None-Match status %d, want 304", path, rec.Code)
+		}
+		if rec := get(path, `"other", W/`+etag); rec.Code != http.StatusNotModified {
+			t.Errorf("%s: weak/list If-None-Match status %d, want 304", path, rec.Code)
+		}
+	}
+
+	etag := get("/api/game-data", "").Header().Get("ETag")
+	body := payloadJSON(t, time.Now().Format(store.PeriodLayout), store.TelemetryStats{
+		EnhanceAttempts: 10,
+		EnhanceSuccess:  4,
+		EnhanceFail:     6,
+		EnhanceLevelDetail: map[int]*store.EnhanceLevelStat{
+			2: {Attempts: 10, Success: 4, Fail: 6},
+		},
+	})
+	if rec := do(mux, signedRequest("/api/telemetry", keyID, key, body, time.Now(), "nonce-etag-0000000001")); rec.Code != http.StatusOK {
+		t.Fatalf("telemetry: status %d", rec.Code)
+	}
+
+	// 수신 후에는 캐시가 무효화되어 새 본문과 ETag
+	rec := get("/api/game-data", etag)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("after ingest: status %d, want 200", rec.Code)
+	}
+	if rec.Header().Get("ETag") == etag {
+		t.Error("ETag unchanged after ingest")
+	}
+}
+
+func TestBattleMatchup(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+
+	get := func(query string) BattleMatchup {
+		t.Helper()
+		rec := do(mux, httptest.NewRequest("GET", "/api/battle/matchup?"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", query, rec.Code)
+		}
+		var m BattleMatchup
+		if err := json.Unmarshal(rec.Body.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	for _, query := range []string{"my_level=3", "my_level=3&opp_level=21", "my_level=3&opp_level=5&sword_type=gold"} {
+		if rec := do(mux, httptest.NewRequest("GET", "/api/battle/matchup?"+query, nil)); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+
+	// 데이터가 없으면 레벨차별 기본 승률
+	if m := get("my_level=3&opp_level=5"); m.Model != "level_diff" || m.WinRate != 20.0 {
+		t.Errorf("empty stats: model %q win_rate %.2f, want level_diff 20", m.Model, m.WinRate)
+	}
+
+	// 같은 레벨차라도 높은 레벨일수록 역배가 어려운 데이터
+	data, err := json.Marshal(store.TelemetryPayload{
+		SchemaVersion: 4,
+		AppVersion:    "test",
+		OSType:        "linux",
+		SessionID:     "session-0000-test",
+		Period:        time.Now().Format(store.PeriodLayout),
+		Stats: store.TelemetryStats{
+			BattleCount: 400,
+			BattleMatchups: map[string]*store.MatchupStat{
+				"normal_3_3":   {Battles: 100, Wins: 50, GoldEarned: 50000, GoldLost: 50000},
+				"normal_3_5":   {Battles: 100, Wins: 30, GoldEarned: 90000, GoldLost: 70000},
+				"normal_13_13": {Battles: 100, Wins: 50, GoldEarned: 50000, GoldLost: 50000},
+				"normal_13_15": {Battles: 100, Wins: 5, GoldEarned: 15000, GoldLost: 95000},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(mux, signedRequest("/api/telemetry", keyID, key, data, time.Now(), "nonce-matchup-000001")); rec.Code != http.StatusOK {
+		t.Fatalf("telemetry: status %d", rec.Code)
+	}
+
+	low, high := get("my_level=3&opp_level=5&sword_type=normal"), get("my_level=13&opp_level=15&sword_type=normal")
+	if low.Model != "logistic" || low.ModelSamples != 400 || low.Samples != 100 {
+		t.Fatalf("model %q samples %d/%d, want logistic 100/400", low.Model, low.Samples, low.ModelSamples)
+	}
+	if low.WinRate <= high.WinRate {
+		t.Errorf("+3 vs +5 win rate %.1f%% <= +13 vs +15 %.1f%%", low.WinRate, high.WinRate)
+	}
+	if low.AvgReward != 3000 || low.AvgLoss != 1000 {
+		t.Errorf("avg reward/loss %d/%d, want 3000/1000", low.AvgReward, low.AvgLoss)
+	}
+	want := low.WinRate/100*3000 - (1-low.WinRate/100)*1000
+	if math.Abs(low.ExpectedReward-want) > 1e-6 {
+		t.Errorf("expected reward %.2f, want %.2f", low.ExpectedReward, want)
+	}
+}
+
+func TestItemCatalog(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+
+	data, err := json.Marshal(store.TelemetryPayload{
+		SchemaVersion: 4,
+		AppVersion:    "test",
+		OSType:        "linux",
+		SessionID:     "session-0000-test",
+		Period:        time.Now().Format(store.PeriodLayout),
+		Stats: store.TelemetryStats{
+			FarmingAttempts: 16,
+			ItemFarmingStats: map[string]*store.ItemFarmingStat{
+				"별빛 지팡이": {TotalCount: 5, SpecialCount: 5},
+				"나무 몽둥이": {TotalCount: 6, NormalCount: 5, SpecialCount: 1},
+				"수상한 검":  {TotalCount: 3, NormalCount: 2, SpecialCount: 1},
+				"용검":     {TotalCount: 2, SpecialCount: 2},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(mux, signedRequest("/api/telemetry", keyID, key, data, time.Now(), "nonce-catalog-000001")); rec.Code != http.StatusOK {
+		t.Fatalf("telemetry: status %d", rec.Code)
+	}
+
+	rec := do(mux, httptest.NewRequest("GET", "/api/items/catalog", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	var resp struct {
+		Items []CatalogItem `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, item := range resp.Items {
+		got[item.Name] = item.Type
+	}
+	// 표본이 적거나 (용검) 보고가 갈리면 (수상한 검) 타입 미정
+	want := map[string]string{"별빛 지팡이": "special", "나무 몽둥이": "normal", "수상한 검": "", "용검": ""}
+	for name, typ := range want {
+		if got[name] != typ {
+			t.Errorf("%s: type %q, want %q", name, got[name], typ)
+		}
+	}
+	if len(resp.Items) != len(want) || resp.Items[0].Name != "나무 몽둥이" {
+		t.Errorf("items %+v, want 4 sorted by samples", resp.Items)
+	}
+}
+
+func TestTelemetryAuth(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+	body := payloadJSON(t, "2026-01-01", store.TelemetryStats{EnhanceAttempts: 1, EnhanceSuccess: 1})
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		req     *http.Request
+		want    int
+		errCode string
+	}{
+		{"valid", signedRequest("/api/telemetry", keyID, key, body, now, "nonce-0000000000000001"), http.StatusOK, ""},
+		{"replay", signedRequest("/api/telemetry", keyID, key, body, now, "nonce-0000000000000001"), http.StatusUnauthorized, authErrReplay},
+		{"stale", signedRequest("/api/telemetry", keyID, key, body, now.Add(-2*maxClockSkew), "nonce-0000000000000002"), http.StatusUnauthorized, authErrStale},
+		{"wrong key", signedRequest("/api/telemetry", keyID, []byte("wrong"), body, now, "nonce-0000000000000003"), http.StatusUnauthorized, authErrSignature},
+		{"unknown key", signedRequest("/api/telemetry", "k_missing", key, body, now, "nonce-0000000000000004"), http.StatusUnauthorized, authErrUnknown},
+		{"unsigned", httptest.NewRequest("POST", "/api/telemetry", bytes.NewReader(body)), http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		rec := do(mux, tt.req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.want)
+		}
+		if got := rec.Header().Get(authErrorHeader); got != tt.errCode {
+			t.Errorf("%s: %s = %q, want %q", tt.name, authErrorHeader, got, tt.errCode)
+		}
+	}
+
+	// 중복/거부된 요청은 집계되지 않음
+	if got := st.Aggregate("").EnhanceAttempts; got != 1 {
+		t.Errorf("enhance attempts = %d, want 1", got)
+	}
+}
+
+func TestRevokedKeyRejected(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+	if err := RevokeKey(keyID); err != nil {
+		t.Fatal(err)
+	}
+
+	body := payloadJSON(t, "2026-01-01", store.TelemetryStats{EnhanceAttempts: 1})
+	rec := do(mux, signedRequest("/api/telemetry", keyID, key, body, time.Now(), "nonce-0000000000000001"))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get(authErrorHeader) != authErrRevoked {
+		t.Fatalf("status %d %q, want 401 %q", rec.Code, rec.Header().Get(authErrorHeader), authErrRevoked)
+	}
+}
+
+func TestTelemetryRejectsInvalidPayload(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+
+	body := payloadJSON(t, "2026-01-01", store.TelemetryStats{EnhanceAttempts: maxStatValue + 1})
+	rec := do(mux, signedRequest("/api/telemetry", keyID, key, body, time.Now(), "nonce-0000000000000001"))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", rec.Code)
+	}
+}
+
+func TestStatsSinceFilter(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+
+	old := payloadJSON(t, "2026-01-01", store.TelemetryStats{BattleCount: 3, BattleWins: 1})
+	recent := payloadJSON(t, "2026-02-01", store.TelemetryStats{BattleCount: 5, BattleWins: 5})
+	for i, body := range [][]byte{old, recent} {
+		setServerDate(t, []string{"2026-01-01", "2026-02-01"}[i])
+		nonce := "nonce-000000000000000" + strconv.Itoa(i)
+		if rec := do(mux, signedRequest("/api/telemetry", keyID, key, body, time.Now(), nonce)); rec.Code != http.StatusOK {
+			t.Fatalf("telemetry %d: status %d", i, rec.Code)
+		}
+	}
+
+	rec := do(mux, httptest.NewRequest("GET", "/api/stats/daily?since=2026-01-15", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("daily: status %d", rec.Code)
+	}
+	var daily struct {
+		Days []struct {
+			Period      string `json:"period"`
+			BattleCount int    `json:"battle_count"`
+		} `json:"days"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&daily); err != nil {
+		t.Fatal(err)
+	}
+	if len(daily.Days) != 1 || daily.Days[0].Period != "2026-02-01" || daily.Days[0].BattleCount != 5 {
+		t.Errorf("daily = %+v, want only 2026-02-01 with 5 battles", daily.Days)
+	}
+
+	if got := st.Aggregate("").BattleCount; got != 8 {
+		t.Errorf("total battles = %d, want 8", got)
+	}
+}
+
+func TestTelemetryPeriodUsesReceiveDate(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+
+	// 서버 날짜(2026-01-01)와 하루 넘게 차이 나는 period는 수신 날짜에 기록
+	for i, period := range []string{"2025-06-01", "2026-03-01", "2025-12-31"} {
+		body := payloadJSON(t, period, store.TelemetryStats{BattleCount: 1})
+		nonce := "nonce-period-00000000" + strconv.Itoa(i)
+		if rec := do(mux, signedRequest("/api/telemetry", keyID, key, body, time.Now(), nonce)); rec.Code != http.StatusOK {
+			t.Fatalf("telemetry %s: status %d", period, rec.Code)
+		}
+	}
+
+	if got := st.Aggregate("2026-01-01").BattleCount; got != 2 {
+		t.Errorf("battles since 2026-01-01 = %d, want 2 (two clamped payloads)", got)
+	}
+	if got := st.Aggregate("").BattleCount; got != 3 {
+		t.Errorf("total battles = %d, want 3", got)
+	}
+
+	tests := []struct{ period, want string }{
+		{"2026-01-01", "2026-01-01"},
+		{"2026-01-02", "2026-01-02"},
+		{"2025-12-31", "2025-12-31"},
+		{"2026-01-03", "2026-01-01"},
+		{"2024-01-01", "2026-01-01"},
+		{"garbage", "2026-01-01"},
+	}
+	for _, tt := range tests {
+		if got := receivePeriod(tt.period, serverNow()); got != tt.want {
+			t.Errorf("receivePeriod(%q) = %q, want %q", tt.period, got, tt.want)
+		}
+	}
+}
+
+func TestTelemetryShortSessionID(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+
+	// 로그에 세션 ID 앞부분만 남길 때 8자보다 짧은 ID도 처리
+	body, err := json.Marshal(store.TelemetryPayload{
+		SchemaVersion: 3,
+		AppVersion:    "test",
+		OSType:        "linux",
+		SessionID:     "s1",
+		Period:        "2024-01-01",
+		Stats:         store.TelemetryStats{BattleCount: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(mux, signedRequest("/api/telemetry", keyID, key, body, time.Now(), "nonce-short-session-00")); rec.Code != http.StatusOK {
+		t.Fatalf("telemetry: status %d", rec.Code)
+	}
+	if got := shortSessionID("session-0000-test"); got != "session-" {
+		t.Errorf("shortSessionID = %q, want session-", got)
+	}
+}
+
+func TestSegmentFilters(t *testing.T) {
+	mux := newTestMux(t)
+
+	// 2.5.1은 역배 결과를 잘못 파싱한 릴리스라고 가정
+	for _, p := range []*store.TelemetryPayload{
+		{SchemaVersion: 3, SessionID: "session-good", Period: "2026-01-01", AppVersion: "2.5.0", OSType: "windows",
+			Stats: store.TelemetryStats{BattleCount: 3, UpsetStatsByDiff: map[int]*store.UpsetStat{1: {Attempts: 10, Wins: 4, GoldEarned: 4000}}}},
+		{SchemaVersion: 3, SessionID: "session-bad", Period: "2026-01-01", AppVersion: "2.5.1", OSType: "darwin",
+			Stats: store.TelemetryStats{BattleCount: 5, UpsetStatsByDiff: map[int]*store.UpsetStat{1: {Attempts: 10, Wins: 10, GoldEarned: 10000}}}},
+	} {
+		if err := st.Ingest(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	battles := func(query string) int {
+		t.Helper()
+		rec := do(mux, httptest.NewRequest("GET", "/api/stats/daily?"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("daily?%s: status %d", query, rec.Code)
+		}
+		var daily struct {
+			Days []struct {
+				BattleCount int `json:"battle_count"`
+			} `json:"days"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&daily); err != nil {
+			t.Fatal(err)
+		}
+		total := 0
+		for _, d := range daily.Days {
+			total += d.BattleCount
+		}
+		return total
+	}
+	for query, want := range map[string]int{
+		"":                          8,
+		"app_version=2.5.1":         5,
+		"app_version=2.5.0,2.5.1":   8,
+		"exclude_app_version=2.5.1": 3,
+		"os_type=windows":           3,
+		"os_type=linux":             0,
+	} {
+		if got := battles(query); got != want {
+			t.Errorf("daily?%s battles = %d, want %d", query, got, want)
+		}
+	}
+	if rec := do(mux, httptest.NewRequest("GET", "/api/stats/detailed?app_version=,", nil)); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty app_version: status %d, want 400", rec.Code)
+	}
+
+	// 관리자 제외 설정은 게임 데이터에 항상 적용
+	if got := getGameData(statsQuery{}, false).BattleRewards[0].WinRate; got != 70 {
+		t.Errorf("win rate before exclusion = %.1f, want 70", got)
+	}
+	if err := setOverride(overrideExcludedVersions, []byte(`["2.5.1"]`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := getGameData(statsQuery{}, false).BattleRewards[0].WinRate; got != 40 {
+		t.Errorf("win rate after exclusion = %.1f, want 40", got)
+	}
+}
+
+func TestStatsQueryValidation(t *testing.T) {
+	mux := newTestMux(t)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusOK},
+		{"?since=2026-01-01", http.StatusOK},
+		{"?window=7d", http.StatusOK},
+		{"?window=2w", http.StatusOK},
+		{"?since=2026-01-01&window=7d", http.StatusBadRequest},
+		{"?since=01-01-2026", http.StatusBadRequest},
+		{"?window=7m", http.StatusBadRequest},
+		{"?window=400d", http.StatusBadRequest},
+	}
+	for _, path := range []string{"/api/game-data", "/api/stats/detailed", "/api/stats/daily"} {
+		for _, tt := range tests {
+			rec := do(mux, httptest.NewRequest("GET", path+tt.query, nil))
+			if rec.Code != tt.want {
+				t.Errorf("GET %s%s: status %d, want %d", path, tt.query, rec.Code, tt.want)
+			}
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	rl := newRateLimiter()
+	now := time.Now()
+	limit := rateLimits[limitRegister]
+
+	for i := 0; i < int(limit.burst); i++ {
+		if !rl.allow(limitRegister, "192.0.2.1", now) {
+			t.Fatalf("request %d denied within burst", i)
+		}
+	}
+	if rl.allow(limitRegister, "192.0.2.1", now) {
+		t.Error("request allowed after burst")
+	}
+	// 라우트 종류와 IP별로 따로 계산
+	if !rl.allow(limitStats, "192.0.2.1", now) || !rl.allow(limitRegister, "192.0.2.2", now) {
+		t.Error("separate class/IP should have its own bucket")
+	}
+	// 토큰 하나가 충전되면 다시 허용
+	later := now.Add(time.Duration(float64(time.Minute) / limit.perMinute))
+	if !rl.allow(limitRegister, "192.0.2.1", later) {
+		t.Error("request denied after refill")
+	}
+
+	// 다 충전된 버킷은 정리
+	rl.evict(now.Add(time.Hour))
+	if n := rl.size(); n != 0 {
+		t.Errorf("entries after eviction = %d, want 0", n)
+	}
+}
+
+func TestGetClientIP(t *testing.T) {
+	saved := trustedProxies
+	defer func() { trustedProxies = saved }()
+	trustedProxies = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		remote, xff, want string
+	}{
+		{"198.51.100.7:5000", "", "198.51.100.7"},
+		{"198.51.100.7:5000", "203.0.113.1", "198.51.100.7"}, // 신뢰하지 않는 연결의 헤더는 무시
+		{"127.0.0.1:5000", "203.0.113.1", "203.0.113.1"},
+		{"127.0.0.1:5000", "1.2.3.4, 203.0.113.1, 10.0.0.2", "203.0.113.1"}, // 왼쪽의 위조 값 무시
+		{"127.0.0.1:5000", "garbage, 10.0.0.2", "10.0.0.2"},
+		{"[::ffff:127.0.0.1]:5000", "203.0.113.1", "203.0.113.1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remote
+		if tt.xff != "" {
+			req.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		if got := getClientIP(req); got != tt.want {
+			t.Errorf("getClientIP(%s, %q) = %s, want %s", tt.remote, tt.xff, got, tt.want)
+		}
+	}
+}
+
+func TestTelemetryMethodNotAllowed(t *testing.T) {
+	mux := newTestMux(t)
+	rec := do(mux, httptest.NewRequest("GET", "/api/telemetry", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status %d, want 405", rec.Code)
+	}
+}
+
+func TestTelemetryDuplicateSeq(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+
+	payload := store.TelemetryPayload{
+		SchemaVersion: 3,
+		SessionID:     "session-0000-test",
+		Period:        "2026-01-01",
+		Seq:           1,
+		Stats:         store.TelemetryStats{BattleCount: 2, BattleWins: 1},
+	}
+	body, _ := json.Marshal(payload)
+
+	// 재전송은 nonce가 달라 인증은 통과하지만 집계에는 한 번만 반영
+	for i, nonce := range []string{"nonce-0000000000000001", "nonce-0000000000000002"} {
+		rec := do(mux, signedRequest("/api/telemetry", keyID, key, body, time.Now(), nonce))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("send %d: status %d", i, rec.Code)
+		}
+	}
+	if got := st.Aggregate("").BattleCount; got != 2 {
+		t.Errorf("battle count = %d, want 2", got)
+	}
+
+	rec := do(mux, httptest.NewRequest("GET", "/api/stats/daily", nil))
+	var daily struct {
+		Days []struct {
+			Period             string `json:"period"`
+			DuplicatesRejected int    `json:"duplicates_rejected"`
+		} `json:"days"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&daily); err != nil {
+		t.Fatal(err)
+	}
+	if len(daily.Days) != 1 || daily.Days[0].DuplicatesRejected != 1 {
+		t.Errorf("daily = %+v, want 1 duplicate on 2026-01-01", daily.Days)
+	}
+
+	// 다음 순번은 정상 반영
+	payload.Seq = 2
+	body, _ = json.Marshal(payload)
+	do(mux, signedRequest("/api/telemetry", keyID, key, body, time.Now(), "nonce-0000000000000003"))
+	if got := st.Aggregate("").BattleCount; got != 4 {
+		t.Errorf("battle count after seq 2 = %d, want 4", got)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+
+	payload := store.TelemetryPayload{
+		SchemaVersion: 3,
+		AppVersion:    "2.5.1",
+		OSType:        "windows",
+		SessionID:     "session-0000-test",
+		Period:        "2026-01-01",
+		Seq:           1,
+		Stats:         store.TelemetryStats{BattleCount: 1},
+	}
+	body, _ := json.Marshal(payload)
+	if rec := do(mux, signedRequest("/api/telemetry", keyID, key, body, time.Now(), "nonce-0000000000000001")); rec.Code != http.StatusOK {
+		t.Fatalf("telemetry status %d", rec.Code)
+	}
+	req := httptest.NewRequest("POST", "/api/telemetry", strings.NewReader(string(body)))
+	if rec := do(mux, req); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unsigned status %d", rec.Code)
+	}
+
+	rec := do(mux, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("metrics status %d", rec.Code)
+	}
+	out := rec.Body.String()
+	for _, want := range []string{
+		`sword_api_http_requests_total{route="/api/telemetry",method="POST",code="200"} 1`,
+		`sword_api_http_requests_total{route="/api/telemetry",method="POST",code="401"} 1`,
+		`sword_api_telemetry_rejected_total{reason="signature"} 1`,
+		`sword_api_telemetry_ingested_total{schema_version="3",app_version="2.5.1",os_type="windows",mode="-"} 1`,
+		`sword_api_telemetry_ingest_duration_seconds_count 1`,
+		`sword_api_http_request_duration_seconds_bucket{route="/api/register",le="+Inf"} 1`,
+		"sword_api_rate_limiter_entries 2", // register + telemetry
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestOutlierQuarantine(t *testing.T) {
+	mux := newTestMux(t)
+	t.Setenv(adminTokenEnvVar, "admin-token")
+	keyID, key := register(t, mux)
+
+	// +14 강화 5000회 전부 성공 (기본 확률 5%)
+	cheat := payloadJSON(t, "2026-01-01", store.TelemetryStats{
+		EnhanceAttempts: 5000,
+		EnhanceSuccess:  5000,
+		EnhanceLevelDetail: map[int]*store.EnhanceLevelStat{
+			14: {Attempts: 5000, Success: 5000},
+		},
+	})
+	if rec := do(mux, signedRequest("/api/telemetry", keyID, key, cheat, time.Now(), "nonce-0000000000000001")); rec.Code != http.StatusOK {
+		t.Fatalf("telemetry: status %d", rec.Code)
+	}
+	if got := st.Aggregate("").EnhanceAttempts; got != 0 {
+		t.Errorf("quarantined payload aggregated: %d attempts", got)
+	}
+
+	level14Rate := func(query string) float64 {
+		var data GameData
+		rec := do(mux, httptest.NewRequest("GET", "/api/game-data"+query, nil))
+		if err := json.NewDecoder(rec.Body).Decode(&data); err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range data.EnhanceRates {
+			if r.Level == 14 {
+				return r.SuccessRate
+			}
+		}
+		return -1
+	}
+	if got := level14Rate(""); got != 5 {
+		t.Errorf("game-data level 14 = %v, want default 5", got)
+	}
+	if got := level14Rate("?include_quarantined=1"); got != 100 {
+		t.Errorf("game-data with quarantined level 14 = %v, want 100", got)
+	}
+
+	admin := func(method, body string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/admin/quarantine", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		return do(mux, req)
+	}
+	if rec := admin("GET", "", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong admin token: status %d, want 401", rec.Code)
+	}
+
+	var list struct {
+		Sessions []quarantineSession `json:"sessions"`
+	}
+	rec := admin("GET", "", "admin-token")
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Sessions) != 1 || list.Sessions[0].SessionID != "session-0000-test" || list.Sessions[0].MaxScore < quarantineScore {
+		t.Fatalf("sessions = %+v", list.Sessions)
+	}
+
+	// 검토 중인 세션의 정상 페이로드도 격리
+	normal := payloadJSON(t, "2026-01-01", store.TelemetryStats{BattleCount: 1})
+	do(mux, signedRequest("/api/telemetry", keyID, key, normal, time.Now(), "nonce-0000000000000002"))
+	if got := st.Aggregate("").BattleCount; got != 0 {
+		t.Errorf("payload from session under review aggregated")
+	}
+
+	if rec := admin("POST", `{"session_id":"session-0000-test","action":"purge"}`, "admin-token"); rec.Code != http.StatusOK {
+		t.Fatalf("purge: status %d", rec.Code)
+	}
+	if entries, _ := st.Quarantined(""); len(entries) != 0 {
+		t.Errorf("%d entries left after purge", len(entries))
+	}
+
+	// 폐기 후에는 정상 페이로드가 바로 반영되고, 승인하면 격리분이 반영됨
+	do(mux, signedRequest("/api/telemetry", keyID, key, normal, time.Now(), "nonce-0000000000000003"))
+	do(mux, signedRequest("/api/telemetry", keyID, key, cheat, time.Now(), "nonce-0000000000000004"))
+	if rec := admin("POST", `{"session_id":"session-0000-test","action":"accept"}`, "admin-token"); rec.Code != http.StatusOK {
+		t.Fatalf("accept: status %d", rec.Code)
+	}
+	b := st.Aggregate("")
+	if b.BattleCount != 1 || b.EnhanceAttempts != 5000 {
+		t.Errorf("after accept: %d battles, %d enhance attempts; want 1, 5000", b.BattleCount, b.EnhanceAttempts)
+	}
+}
+
+func TestQuarantineDuplicateSeq(t *testing.T) {
+	mux := newTestMux(t)
+	keyID, key := register(t, mux)
+
+	send := func(seq int64, stats store.TelemetryStats, nonce string) string {
+		t.Helper()
+		body, _ := json.Marshal(store.TelemetryPayload{SchemaVersion: 3, SessionID: "session-0000-seq", Period: "2026-01-01", Seq: seq, Stats: stats})
+		rec := do(mux, signedRequest("/api/telemetry", keyID, key, body, time.Now(), nonce))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("seq %d: status %d", seq, rec.Code)
+		}
+		var resp map[string]string
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return resp["status"]
+	}
+	normal := store.TelemetryStats{BattleCount: 1}
+	cheat := store.TelemetryStats{EnhanceAttempts: 5000, EnhanceSuccess: 5000,
+		EnhanceLevelDetail: map[int]*store.EnhanceLevelStat{14: {Attempts: 5000, Success: 5000}}}
+
+	baseline := communityBaseline()
+	if communityBaseline() != baseline {
+		t.Error("community baseline recomputed without a stats change")
+	}
+	if got := send(1, normal, "nonce-seq-000000000001"); got != "ok" {
+		t.Fatalf("seq 1 status %q", got)
+	}
+	if communityBaseline() == baseline || communityBaseline().BattleCount != 1 {
+		t.Error("community baseline not refreshed after ingest")
+	}
+	send(2, cheat, "nonce-seq-000000000002")
+	if !st.IsQuarantined("session-0000-seq") {
+		t.Fatal("cheat payload not quarantined")
+	}
+
+	// 이미 반영된 순번 / 이미 격리된 순번의 재전송은 중복 (다시 격리하지 않음)
+	if got := send(1, normal, "nonce-seq-000000000003"); got != "duplicate" {
+		t.Errorf("resent ingested seq status %q, want duplicate", got)
+	}
+	if got := send(2, cheat, "nonce-seq-000000000004"); got != "duplicate" {
+		t.Errorf("resent quarantined seq status %q, want duplicate", got)
+	}
+	if entries, _ := st.Quarantined(""); len(entries) != 1 {
+		t.Errorf("quarantine entries = %d, want 1", len(entries))
+	}
+	if got := st.Aggregate("").BattleCount; got != 1 {
+		t.Errorf("battles = %d, want 1", got)
+	}
+}
+
+func TestAdminAPI(t *testing.T) {
+	mux := newTestMux(t)
+	t.Setenv(adminTokenEnvVar, "admin-token")
+	keyID, key := register(t, mux)
+
+	admin := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer admin-token")
+		return do(mux, req)
+	}
+
+	for i, session := range []string{"session-0000-keep", "session-0000-drop"} {
+		body, _ := json.Marshal(store.TelemetryPayload{
+			SchemaVersion: 3,
+			SessionID:     session,
+			Period:        "2026-01-01",
+			Stats:         store.TelemetryStats{BattleCount: 3, SalesCount: 1, SalesTotalGold: 100},
+		})
+		nonce := "nonce-000000000000000" + strconv.Itoa(i)
+		if rec := do(mux, signedRequest("/api/telemetry", keyID, key, body, time.Now(), nonce)); rec.Code != http.StatusOK {
+			t.Fatalf("telemetry %d: status %d", i, rec.Code)
+		}
+	}
+
+	var list struct {
+		Sessions []store.SessionInfo `json:"sessions"`
+	}
+	if err := json.NewDecoder(admin("GET", "/api/admin/sessions", "").Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Sessions) != 2 {
+		t.Fatalf("sessions = %+v, want 2", list.Sessions)
+	}
+
+	if rec := admin("POST", "/api/admin/sessions/delete", `{"session_id":"session-0000-drop"}`); rec.Code != http.StatusOK {
+		t.Fatalf("delete session: status %d", rec.Code)
+	}
+	if got := st.Aggregate("").BattleCount; got != 3 {
+		t.Errorf("battle count after delete = %d, want 3", got)
+	}
+	if rec := admin("POST", "/api/admin/sessions/delete", `{"session_id":"session-0000-drop"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("delete missing session: status %d, want 404", rec.Code)
+	}
+
+	if rec := admin("POST", "/api/admin/reset-table", `{"table":"global_stats"}`); rec.Code != http.StatusOK {
+		t.Fatalf("reset table: status %d", rec.Code)
+	}
+	if got := st.Aggregate("").BattleCount; got != 0 {
+		t.Errorf("battle count after reset = %d, want 0", got)
+	}
+	if rec := admin("POST", "/api/admin/reset-table", `{"table":"install_keys"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("reset non-stat table: status %d, want 400", rec.Code)
+	}
+
+	// 재계산하면 남아있는 세션의 페이로드로 복원
+	if rec := admin("POST", "/api/admin/recompute", ""); rec.Code != http.StatusOK {
+		t.Fatalf("recompute: status %d", rec.Code)
+	}
+	if got := st.Aggregate("").BattleCount; got != 3 {
+		t.Errorf("battle count after recompute = %d, want 3", got)
+	}
+
+	// 기본값 오버라이드는 즉시 game-data에 반영되고, null이면 복원
+	override := `{"name":"sword_prices","value":[{"level":10,"min_price":1,"max_price":3,"avg_price":2}]}`
+	if rec := admin("POST", "/api/admin/defaults", override); rec.Code != http.StatusOK {
+		t.Fatalf("set default: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := getGameData(statsQuery{}, false).SwordPrices; len(got) != 1 || got[0].AvgPrice != 2 {
+		t.Errorf("sword prices = %+v, want override", got)
+	}
+	if rec := admin("POST", "/api/admin/defaults", `{"name":"sword_prices","value":[{"level":10,"min_price":5,"max_price":3,"avg_price":2}]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid override: status %d, want 400", rec.Code)
+	}
+	admin("POST", "/api/admin/defaults", `{"name":"sword_prices","value":null}`)
+	if got := getGameData(statsQuery{}, false).SwordPrices; len(got) != len(defaultSwordPrices) {
+		t.Errorf("sword prices not restored: %d entries", len(got))
+	}
+
+	var audit struct {
+		Entries []store.AuditEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(admin("GET", "/api/admin/audit", "").Body).Decode(&audit); err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, e := range audit.Entries {
+		actions = append(actions, e.Action)
+	}
+	// 최신순: 복원, 잘못된 오버라이드(실패도 기록), 오버라이드, 재계산, 초기화, 삭제(없는 세션), 삭제
+	want := "set_default set_default set_default recompute reset_table delete_session delete_session"
+	if got := strings.Join(actions, " "); got != want {
+		t.Errorf("audit actions = %q, want %q", got, want)
+	}
+}
+
+func TestLocalBackend(t *testing.T) {
+	newTestMux(t)
+	local, err := OpenLocal(t.TempDir() + "/local.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+
+	body, err := json.Marshal(store.TelemetryPayload{
+		SchemaVersion: 3,
+		SessionID:     "session-0000-local",
+		Seq:           1,
+		Period:        "2026-01-01",
+		Stats: store.TelemetryStats{
+			FarmingAttempts: 10,
+			SwordSaleStats:  map[string]*store.SwordSaleStat{"normal_10": {TotalPrice: 30000, Count: 3}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ { // 같은 seq 재전송은 무시
+		if err := local.Ingest(body); err != nil {
+			t.Fatalf("ingest %d: %v", i, err)
+		}
+	}
+	if err := local.Ingest([]byte(`{"schema_version":3}`)); err == nil {
+		t.Error("invalid payload accepted")
+	}
+
+	data, err := local.Query("/api/stats/sales", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sales struct {
+		TotalSales int `json:"total_sales"`
+	}
+	if err := json.Unmarshal(data, &sales); err != nil {
+		t.Fatal(err)
+	}
+	if sales.TotalSales != 3 {
+		t.Errorf("total_sales = %d, want 3", sales.TotalSales)
+	}
+
+	if _, err := local.Query("/api/game-data", url.Values{"window": {"7d"}}); err != nil {
+		t.Errorf("game-data: %v", err)
+	}
+	if _, err := local.Query("/api/battle/matchup", url.Values{"my_level": {"99"}, "opp_level": {"1"}}); err == nil {
+		t.Error("matchup with invalid level accepted")
+	}
+	if _, err := local.Query("/api/admin/sessions", nil); err == nil {
+		t.Error("admin path served in local mode")
+	}
+}
@@ -0,0 +1,538 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ========================
+// 관리자 작업 (세션 / 테이블 / 기본값 / 감사 로그)
+// ========================
+
+// SessionInfo 세션별 수신 현황
+type SessionInfo struct {
+	SessionID  string `json:"session_id"`
+	FirstSeen  int64  `json:"first_seen"`
+	LastSeen   int64  `json:"last_seen"`
+	Payloads   int    `json:"payloads"`
+	AppVersion string `json:"app_version"`
+	OSType     string `json:"os_type"`
+	Mode       string `json:"mode"`
+}
+
+// AuditEntry 관리자 작업 기록
+type AuditEntry struct {
+	ID     int64  `json:"id"`
+	At     int64  `json:"at"`
+	Actor  string `json:"actor"` // cli 또는 api:<IP>
+	Action string `json:"action"`
+	Target string `json:"target"`
+	Detail string `json:"detail"`
+}
+
+// 원문 보관 제한
+// 통계 집계는 계속 유지하고 원문(payload_log)만 정리하므로, 보관 기간 이전 페이로드는 세션 삭제 / 재계산에 포함되지 않음
+const (
+	PayloadRetention  = 366 * 24 * time.Hour // 원문 보관 기간 (조회 기간 최대치와 같음)
+	maxMemoryPayloads = 100_000              // 인메모리 저장소 원문 최대 보관 수
+)
+
+// loggedPayload 반영된 페이로드 원문 (인메모리 저장소용)
+type loggedPayload struct {
+	receivedAt int64
+	payload    TelemetryPayload
+}
+
+// statTables 초기화 가능한 통계 테이블 → 버킷에서 해당 통계 초기화
+// 전체 누적 테이블 이름으로 지정하며 daily_ 테이블도 함께 초기화
+var statTables = map[string]func(b *Bucket){
+	"global_stats": func(b *Bucket) {
+		fresh := NewBucket()
+		fresh.EnhanceByLevel = b.EnhanceByLevel
+		fresh.SwordBattleStats = b.SwordBattleStats
+		fresh.SpecialFoundByName = b.SpecialFoundByName
+		fresh.UpsetStatsByDiff = b.UpsetStatsByDiff
+		fresh.SwordSaleStats = b.SwordSaleStats
+		fresh.SwordEnhanceStats = b.SwordEnhanceStats
+		fresh.ItemFarmingStats = b.ItemFarmingStats
+		fresh.EnhanceLevelDetail = b.EnhanceLevelDetail
+		fresh.BattleMatchups = b.BattleMatchups
+		*b = *fresh
+	},
+	"enhance_by_level":      func(b *Bucket) { b.EnhanceByLevel = make(map[int]int) },
+	"sword_battle_stats":    func(b *Bucket) { b.SwordBattleStats = make(map[string]*SwordBattleStat) },
+	"special_found_by_name": func(b *Bucket) { b.SpecialFoundByName = make(map[string]int) },
+	"upset_stats_by_diff":   func(b *Bucket) { b.UpsetStatsByDiff = make(map[int]*UpsetStat) },
+	"sword_sale_stats":      func(b *Bucket) { b.SwordSaleStats = make(map[string]*SwordSaleStat) },
+	"sword_enhance_stats":   func(b *Bucket) { b.SwordEnhanceStats = make(map[string]*SwordEnhanceStat) },
+	"item_farming_stats":    func(b *Bucket) { b.ItemFarmingStats = make(map[string]*ItemFarmingStat) },
+	"enhance_level_detail":  func(b *Bucket) { b.EnhanceLevelDetail = make(map[int]*EnhanceLevelStat) },
+	"battle_matchups":       func(b *Bucket) { b.BattleMatchups = make(map[string]*MatchupStat) },
+}
+
+// StatTables 초기화 가능한 통계 테이블 이름 (정렬)
+func StatTables() []string {
+	names := make([]string, 0, len(statTables))
+	for name := range statTables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ========================
+// 인메모리 저장소
+// ========================
+
+// logPayload 페이로드 원문과 세션 현황 기록 (호출자가 Lock 보유)
+func (m *Memory) logPayload(p *TelemetryPayload, now int64) {
+	m.payloadLog = append(m.payloadLog, loggedPayload{receivedAt: now, payload: *p})
+	m.prunePayloadLog(now)
+
+	s := m.sessions[p.SessionID]
+	if s == nil {
+		s = &SessionInfo{SessionID: p.SessionID, FirstSeen: now}
+		m.sessions[p.SessionID] = s
+	}
+	s.LastSeen = now
+	s.Payloads++
+	s.AppVersion, s.OSType, s.Mode = p.AppVersion, p.OSType, p.Mode
+}
+
+// prunePayloadLog 보관 기간이 지났거나 최대 개수를 넘은 오래된 원문 제거 (호출자가 Lock 보유)
+func (m *Memory) prunePayloadLog(now int64) {
+	cutoff := now - int64(PayloadRetention/time.Second)
+	drop := 0
+	for drop < len(m.payloadLog) &&
+		(m.payloadLog[drop].receivedAt < cutoff || len(m.payloadLog)-drop > maxMemoryPayloads) {
+		drop++
+	}
+	if drop > 0 {
+		m.payloadLog = append([]loggedPayload(nil), m.payloadLog[drop:]...)
+	}
+}
+
+// prunePayloads 보관 기간이 지난 원문과 중복 판별 기간이 지난 순번 삭제 (배치 저장 트랜잭션 내)
+func prunePayloads(tx *sql.Tx, now time.Time) error {
+	if _, err := tx.Exec("DELETE FROM payload_log WHERE received_at < ?", now.Add(-PayloadRetention).Unix()); err != nil {
+		return fmt.Errorf("payload_log 정리 실패: %v", err)
+	}
+	if _, err := tx.Exec("DELETE FROM payload_seqs WHERE received_at < ?", now.Add(-seqWindow).Unix()); err != nil {
+		return fmt.Errorf("payload_seqs 정리 실패: %v", err)
+	}
+	return nil
+}
+
+// subtract 페이로드 기여분을 집계에서 빼기 (호출자가 Lock 보유)
+func (m *Memory) subtract(p *TelemetryPayload) {
+	delta := NewBucket()
+	delta.Add(p)
+	neg := delta.Negated()
+	period := NormalizePeriod(p.Period)
+	m.total.Merge(neg)
+	m.dailyBucket(period).Merge(neg)
+	m.segmentBucket(period, SegmentOf(p)).Merge(neg)
+}
+
+// resetTable 전체 누적과 모든 일별/세그먼트 버킷에서 통계 초기화
+func (m *Memory) resetTable(reset func(b *Bucket)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	reset(m.total)
+	for _, b := range m.daily {
+		reset(b)
+	}
+	for _, bySeg := range m.segments {
+		for _, b := range bySeg {
+			reset(b)
+		}
+	}
+}
+
+// Sessions 최근 수신 세션
+func (m *Memory) Sessions(limit int) ([]SessionInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := make([]SessionInfo, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, *s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeen > sessions[j].LastSeen })
+	if limit > 0 && len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	return sessions, nil
+}
+
+// DeleteSession 세션 기여분 삭제
+func (m *Memory) DeleteSession(sessionID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	kept := m.payloadLog[:0]
+	for _, lp := range m.payloadLog {
+		if lp.payload.SessionID == sessionID {
+			m.subtract(&lp.payload)
+			removed++
+			continue
+		}
+		kept = append(kept, lp)
+	}
+	m.payloadLog = kept
+	delete(m.sessions, sessionID)
+	delete(m.quarantined, sessionID)
+
+	q := m.quarantine[:0]
+	for _, e := range m.quarantine {
+		if e.SessionID != sessionID {
+			q = append(q, e)
+		}
+	}
+	m.quarantine = q
+	return removed, nil
+}
+
+// ResetTable 통계 테이블 초기화
+func (m *Memory) ResetTable(name string) error {
+	reset, ok := statTables[name]
+	if !ok {
+		return fmt.Errorf("초기화할 수 없는 테이블: %s", name)
+	}
+	m.resetTable(reset)
+	return nil
+}
+
+// Recompute 페이로드 기록으로 집계 재계산
+func (m *Memory) Recompute() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fresh := NewMemory()
+	for i := range m.payloadLog {
+		fresh.record(&m.payloadLog[i].payload)
+	}
+	m.total, m.daily, m.segments = fresh.total, fresh.daily, fresh.segments
+	return len(m.payloadLog), nil
+}
+
+// Overrides 기본값 오버라이드
+func (m *Memory) Overrides() (map[string][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	overrides := make(map[string][]byte, len(m.overrides))
+	for name, v := range m.overrides {
+		overrides[name] = v
+	}
+	return overrides, nil
+}
+
+// SetOverride 기본값 오버라이드 저장 (value가 nil이면 삭제)
+func (m *Memory) SetOverride(name string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if value == nil {
+		delete(m.overrides, name)
+	} else {
+		m.overrides[name] = value
+	}
+	return nil
+}
+
+// AppendAudit 관리자 작업 기록
+func (m *Memory) AppendAudit(e AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.ID = int64(len(m.audit) + 1)
+	m.audit = append(m.audit, e)
+	return nil
+}
+
+// AuditLog 최근 관리자 작업 (최신순)
+func (m *Memory) AuditLog(limit int) ([]AuditEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := make([]AuditEntry, 0, len(m.audit))
+	for i := len(m.audit) - 1; i >= 0; i-- {
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+		entries = append(entries, m.audit[i])
+	}
+	return entries, nil
+}
+
+// ========================
+// SQLite 저장소
+// ========================
+
+// logPayload 페이로드 원문과 세션 현황 기록 (트랜잭션 내)
+func logPayload(tx *sql.Tx, p *TelemetryPayload, period string, now int64) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO payload_log (session_id, period, payload, received_at) VALUES (?, ?, ?, ?)",
+		p.SessionID, period, string(data), now); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO sessions (session_id, first_seen, last_seen, payloads, app_version, os_type, mode)
+		VALUES (?, ?, ?, 1, ?, ?, ?)
+		ON CONFLICT(session_id) DO UPDATE SET
+			last_seen = excluded.last_seen, payloads = payloads + 1,
+			app_version = excluded.app_version, os_type = excluded.os_type, mode = excluded.mode`,
+		p.SessionID, now, now, p.AppVersion, p.OSType, p.Mode)
+	return err
+}
+
+// Sessions 최근 수신 세션
+func (sl *SQLite) Sessions(limit int) ([]SessionInfo, error) {
+	if err := sl.Flush(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = -1 // SQLite: LIMIT -1 = 제한 없음
+	}
+	rows, err := sl.db.Query(`SELECT session_id, first_seen, last_seen, payloads, app_version, os_type, mode
+		FROM sessions ORDER BY last_seen DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sessions 로드 실패: %v", err)
+	}
+	defer rows.Close()
+
+	sessions := []SessionInfo{}
+	for rows.Next() {
+		var s SessionInfo
+		if err := rows.Scan(&s.SessionID, &s.FirstSeen, &s.LastSeen, &s.Payloads, &s.AppVersion, &s.OSType, &s.Mode); err != nil {
+			return nil, fmt.Errorf("sessions 로드 실패: %v", err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// loggedPayloads payload_log 조회 (sessionID가 비어있으면 전체, 수신 순)
+func (sl *SQLite) loggedPayloads(sessionID string) ([]TelemetryPayload, error) {
+	query := "SELECT id, payload FROM payload_log"
+	var args []interface{}
+	if sessionID != "" {
+		query += " WHERE session_id=?"
+		args = append(args, sessionID)
+	}
+	rows, err := sl.db.Query(query+" ORDER BY id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("payload_log 조회 실패: %v", err)
+	}
+	defer rows.Close()
+
+	var payloads []TelemetryPayload
+	for rows.Next() {
+		var id int64
+		var data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("payload_log 읽기 실패: %v", err)
+		}
+		var p TelemetryPayload
+		if err := json.Unmarshal([]byte(data), &p); err != nil {
+			log.Printf("⚠️ payload_log 파싱 실패 (id=%d): %v", id, err)
+			continue
+		}
+		payloads = append(payloads, p)
+	}
+	return payloads, rows.Err()
+}
+
+// DeleteSession 세션 기여분을 집계에서 빼고 세션의 원문/이벤트/격리 기록 삭제
+// payload_seqs는 남겨서 삭제된 페이로드가 재전송되어도 다시 반영되지 않게 함
+func (sl *SQLite) DeleteSession(sessionID string) (int, error) {
+	if err := sl.Flush(); err != nil {
+		return 0, err
+	}
+	payloads, err := sl.loggedPayloads(sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := sl.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("트랜잭션 시작 실패: %v", err)
+	}
+	defer tx.Rollback()
+
+	for i := range payloads {
+		delta := NewBucket()
+		delta.Add(&payloads[i])
+		neg := delta.Negated()
+		if err := upsertBucket(tx, "", neg); err != nil {
+			return 0, fmt.Errorf("누적 통계 차감 실패: %v", err)
+		}
+		period := NormalizePeriod(payloads[i].Period)
+		if err := upsertBucket(tx, period, neg); err != nil {
+			return 0, fmt.Errorf("일별 통계 차감 실패: %v", err)
+		}
+		if err := upsertSegment(tx, period, SegmentOf(&payloads[i]), neg); err != nil {
+			return 0, fmt.Errorf("세그먼트 통계 차감 실패: %v", err)
+		}
+	}
+	for _, table := range []string{"payload_log", "telemetry_events", "sessions", "quarantine"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE session_id=?", sessionID); err != nil {
+			return 0, fmt.Errorf("%s 삭제 실패: %v", table, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("커밋 실패: %v", err)
+	}
+
+	sl.mu.Lock()
+	for i := range payloads {
+		sl.subtract(&payloads[i])
+	}
+	delete(sl.quarantined, sessionID)
+	sl.mu.Unlock()
+	return len(payloads), nil
+}
+
+// ResetTable 통계 테이블 초기화 (전체 누적 + daily_ 테이블)
+func (sl *SQLite) ResetTable(name string) error {
+	reset, ok := statTables[name]
+	if !ok {
+		return fmt.Errorf("초기화할 수 없는 테이블: %s", name)
+	}
+	if err := sl.Flush(); err != nil {
+		return err
+	}
+
+	tx, err := sl.db.Begin()
+	if err != nil {
+		return fmt.Errorf("트랜잭션 시작 실패: %v", err)
+	}
+	defer tx.Rollback()
+
+	if name == "global_stats" {
+		sets := make([]string, len(globalCols))
+		for i, c := range globalCols {
+			sets[i] = c + " = 0"
+		}
+		_, err = tx.Exec("UPDATE global_stats SET " + strings.Join(sets, ", "))
+	} else {
+		_, err = tx.Exec("DELETE FROM " + name)
+	}
+	if err != nil {
+		return fmt.Errorf("%s 초기화 실패: %v", name, err)
+	}
+	if _, err := tx.Exec("DELETE FROM daily_" + name); err != nil {
+		return fmt.Errorf("daily_%s 초기화 실패: %v", name, err)
+	}
+	// 세그먼트 통계는 버킷 JSON이므로 초기화한 버킷으로 다시 저장
+	for _, sb := range sl.Segments("") {
+		reset(sb.Bucket)
+		if err := saveSegment(tx, sb.Period, sb.Segment, sb.Bucket); err != nil {
+			return fmt.Errorf("세그먼트 통계 초기화 실패: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("커밋 실패: %v", err)
+	}
+
+	sl.resetTable(reset)
+	return nil
+}
+
+// Recompute payload_log로 집계 테이블 전체 재계산
+// 주의: payload_log 도입(0008_admin) 전이나 보관 기간(PayloadRetention) 전에 받은 페이로드는 재계산 결과에 포함되지 않음
+func (sl *SQLite) Recompute() (int, error) {
+	if err := sl.Flush(); err != nil {
+		return 0, err
+	}
+	payloads, err := sl.loggedPayloads("")
+	if err != nil {
+		return 0, err
+	}
+	if len(payloads) == 0 {
+		return 0, fmt.Errorf("payload_log가 비어 있음 (재계산하면 기존 통계가 모두 사라짐)")
+	}
+
+	fresh := NewMemory()
+	for i := range payloads {
+		fresh.record(&payloads[i])
+	}
+	if err := sl.replaceAll(fresh); err != nil {
+		return 0, err
+	}
+
+	log.Printf("📦 페이로드 %d건으로 집계 재계산 완료", len(payloads))
+	return len(payloads), nil
+}
+
+// Overrides 기본값 오버라이드
+func (sl *SQLite) Overrides() (map[string][]byte, error) {
+	rows, err := sl.db.Query("SELECT name, value FROM default_overrides")
+	if err != nil {
+		return nil, fmt.Errorf("default_overrides 로드 실패: %v", err)
+	}
+	defer rows.Close()
+
+	overrides := make(map[string][]byte)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("default_overrides 로드 실패: %v", err)
+		}
+		overrides[name] = []byte(value)
+	}
+	return overrides, rows.Err()
+}
+
+// SetOverride 기본값 오버라이드 저장 (value가 nil이면 삭제)
+func (sl *SQLite) SetOverride(name string, value []byte) error {
+	var err error
+	if value == nil {
+		_, err = sl.db.Exec("DELETE FROM default_overrides WHERE name=?", name)
+	} else {
+		_, err = sl.db.Exec("INSERT OR REPLACE INTO default_overrides (name, value, updated_at) VALUES (?, ?, ?)",
+			name, string(value), time.Now().Unix())
+	}
+	if err != nil {
+		return fmt.Errorf("기본값 저장 실패: %v", err)
+	}
+	return nil
+}
+
+// AppendAudit 관리자 작업 기록
+func (sl *SQLite) AppendAudit(e AuditEntry) error {
+	if _, err := sl.db.Exec("INSERT INTO admin_audit (at, actor, action, target, detail) VALUES (?, ?, ?, ?, ?)",
+		e.At, e.Actor, e.Action, e.Target, e.Detail); err != nil {
+		return fmt.Errorf("감사 로그 저장 실패: %v", err)
+	}
+	return nil
+}
+
+// AuditLog 최근 관리자 작업 (최신순)
+func (sl *SQLite) AuditLog(limit int) ([]AuditEntry, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := sl.db.Query("SELECT id, at, actor, action, target, detail FROM admin_audit ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("admin_audit 로드 실패: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.At, &e.Actor, &e.Action, &e.Target, &e.Detail); err != nil {
+			return nil, fmt.Errorf("admin_audit 로드 실패: %v", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
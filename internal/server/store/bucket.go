@@ -0,0 +1,283 @@
+package store
+
+// Bucket 통계 집계 단위 (전체 누적 또는 일별)
+type Bucket struct {
+	EnhanceAttempts int
+	EnhanceSuccess  int
+	EnhanceFail     int
+	EnhanceDestroy  int
+	EnhanceByLevel  map[int]int
+	BattleCount     int
+	BattleWins      int
+	UpsetAttempts   int
+	UpsetWins       int
+	BattleGold      int
+	FarmingAttempts int
+	SpecialFound    int
+	SalesCount      int
+	SalesTotalGold  int
+
+	// === v2 통계 ===
+	SwordBattleStats   map[string]*SwordBattleStat
+	SpecialFoundByName map[string]int
+	UpsetStatsByDiff   map[int]*UpsetStat
+	SwordSaleStats     map[string]*SwordSaleStat
+	SwordEnhanceStats  map[string]*SwordEnhanceStat
+	ItemFarmingStats   map[string]*ItemFarmingStat
+
+	// === v3 통계 ===
+	EnhanceLevelDetail map[int]*EnhanceLevelStat
+	EnhanceCostTotal   int
+	CycleTimeTotal     float64
+	BattleGoldLost     int
+
+	// === v4 통계 ===
+	BattleMatchups map[string]*MatchupStat
+}
+
+// NewBucket 빈 버킷 생성
+func NewBucket() *Bucket {
+	return &Bucket{
+		EnhanceByLevel:     make(map[int]int),
+		SwordBattleStats:   make(map[string]*SwordBattleStat),
+		SpecialFoundByName: make(map[string]int),
+		UpsetStatsByDiff:   make(map[int]*UpsetStat),
+		SwordSaleStats:     make(map[string]*SwordSaleStat),
+		SwordEnhanceStats:  make(map[string]*SwordEnhanceStat),
+		ItemFarmingStats:   make(map[string]*ItemFarmingStat),
+		EnhanceLevelDetail: make(map[int]*EnhanceLevelStat),
+		BattleMatchups:     make(map[string]*MatchupStat),
+	}
+}
+
+// Add 텔레메트리 페이로드를 버킷에 누적
+func (b *Bucket) Add(p *TelemetryPayload) {
+	s := &p.Stats
+
+	// v1 통계
+	b.EnhanceAttempts += s.EnhanceAttempts
+	b.EnhanceSuccess += s.EnhanceSuccess
+	b.EnhanceFail += s.EnhanceFail
+	b.EnhanceDestroy += s.EnhanceDestroy
+	for lvl, cnt := range s.EnhanceByLevel {
+		b.EnhanceByLevel[lvl] += cnt
+	}
+	b.BattleCount += s.BattleCount
+	b.BattleWins += s.BattleWins
+	b.UpsetAttempts += s.UpsetAttempts
+	b.UpsetWins += s.UpsetWins
+	b.BattleGold += s.BattleGoldEarned
+	b.FarmingAttempts += s.FarmingAttempts
+	b.SpecialFound += s.SpecialFound
+	b.SalesCount += s.SalesCount
+	b.SalesTotalGold += s.SalesTotalGold
+
+	// v2 통계 (schema_version >= 2)
+	if p.SchemaVersion >= 2 {
+		b.mergeV2(s.SwordBattleStats, s.SpecialFoundByName, s.UpsetStatsByDiff,
+			s.SwordSaleStats, s.SwordEnhanceStats, s.ItemFarmingStats)
+	}
+
+	// v3 통계 (schema_version >= 3)
+	if p.SchemaVersion >= 3 {
+		b.mergeV3(s.EnhanceLevelDetail)
+		b.EnhanceCostTotal += s.EnhanceCostTotal
+		b.CycleTimeTotal += s.CycleTimeTotal
+		b.BattleGoldLost += s.BattleGoldLost
+	}
+
+	// v4 통계 (schema_version >= 4)
+	if p.SchemaVersion >= 4 {
+		b.mergeV4(s.BattleMatchups)
+	}
+}
+
+// Clone 버킷 깊은 복사본
+func (b *Bucket) Clone() *Bucket {
+	c := NewBucket()
+	c.Merge(b)
+	return c
+}
+
+// Merge 다른 버킷의 통계를 누적 (기간 합산용)
+func (b *Bucket) Merge(o *Bucket) {
+	b.EnhanceAttempts += o.EnhanceAttempts
+	b.EnhanceSuccess += o.EnhanceSuccess
+	b.EnhanceFail += o.EnhanceFail
+	b.EnhanceDestroy += o.EnhanceDestroy
+	for lvl, cnt := range o.EnhanceByLevel {
+		b.EnhanceByLevel[lvl] += cnt
+	}
+	b.BattleCount += o.BattleCount
+	b.BattleWins += o.BattleWins
+	b.UpsetAttempts += o.UpsetAttempts
+	b.UpsetWins += o.UpsetWins
+	b.BattleGold += o.BattleGold
+	b.FarmingAttempts += o.FarmingAttempts
+	b.SpecialFound += o.SpecialFound
+	b.SalesCount += o.SalesCount
+	b.SalesTotalGold += o.SalesTotalGold
+
+	b.mergeV2(o.SwordBattleStats, o.SpecialFoundByName, o.UpsetStatsByDiff,
+		o.SwordSaleStats, o.SwordEnhanceStats, o.ItemFarmingStats)
+
+	b.mergeV3(o.EnhanceLevelDetail)
+	b.EnhanceCostTotal += o.EnhanceCostTotal
+	b.CycleTimeTotal += o.CycleTimeTotal
+	b.BattleGoldLost += o.BattleGoldLost
+
+	b.mergeV4(o.BattleMatchups)
+}
+
+// mergeV2 v2 맵 통계 누적
+func (b *Bucket) mergeV2(
+	swordBattle map[string]*SwordBattleStat,
+	specialByName map[string]int,
+	upsetByDiff map[int]*UpsetStat,
+	swordSale map[string]*SwordSaleStat,
+	swordEnhance map[string]*SwordEnhanceStat,
+	itemFarming map[string]*ItemFarmingStat,
+) {
+	// 검 종류별 배틀 통계
+	for name, stat := range swordBattle {
+		if b.SwordBattleStats[name] == nil {
+			b.SwordBattleStats[name] = &SwordBattleStat{}
+		}
+		b.SwordBattleStats[name].BattleCount += stat.BattleCount
+		b.SwordBattleStats[name].BattleWins += stat.BattleWins
+		b.SwordBattleStats[name].UpsetAttempts += stat.UpsetAttempts
+		b.SwordBattleStats[name].UpsetWins += stat.UpsetWins
+	}
+
+	// 특수 이름별 통계
+	for name, cnt := range specialByName {
+		b.SpecialFoundByName[name] += cnt
+	}
+
+	// 레벨차별 역배 통계
+	for diff, stat := range upsetByDiff {
+		if b.UpsetStatsByDiff[diff] == nil {
+			b.UpsetStatsByDiff[diff] = &UpsetStat{}
+		}
+		b.UpsetStatsByDiff[diff].Attempts += stat.Attempts
+		b.UpsetStatsByDiff[diff].Wins += stat.Wins
+		b.UpsetStatsByDiff[diff].GoldEarned += stat.GoldEarned
+	}
+
+	// 검 판매 통계
+	for key, stat := range swordSale {
+		if b.SwordSaleStats[key] == nil {
+			b.SwordSaleStats[key] = &SwordSaleStat{}
+		}
+		b.SwordSaleStats[key].TotalPrice += stat.TotalPrice
+		b.SwordSaleStats[key].Count += stat.Count
+	}
+
+	// 검 강화 통계
+	for name, stat := range swordEnhance {
+		if b.SwordEnhanceStats[name] == nil {
+			b.SwordEnhanceStats[name] = &SwordEnhanceStat{}
+		}
+		b.SwordEnhanceStats[name].Attempts += stat.Attempts
+		b.SwordEnhanceStats[name].Success += stat.Success
+		b.SwordEnhanceStats[name].Fail += stat.Fail
+		b.SwordEnhanceStats[name].Destroy += stat.Destroy
+	}
+
+	// 아이템 파밍 통계
+	for name, stat := range itemFarming {
+		if b.ItemFarmingStats[name] == nil {
+			b.ItemFarmingStats[name] = &ItemFarmingStat{}
+		}
+		b.ItemFarmingStats[name].TotalCount += stat.TotalCount
+		b.ItemFarmingStats[name].SpecialCount += stat.SpecialCount
+		b.ItemFarmingStats[name].NormalCount += stat.NormalCount
+		b.ItemFarmingStats[name].TrashCount += stat.TrashCount
+	}
+}
+
+// mergeV3 v3 레벨별 강화 상세 통계 누적
+func (b *Bucket) mergeV3(levelDetail map[int]*EnhanceLevelStat) {
+	for lvl, stat := range levelDetail {
+		if b.EnhanceLevelDetail[lvl] == nil {
+			b.EnhanceLevelDetail[lvl] = &EnhanceLevelStat{}
+		}
+		b.EnhanceLevelDetail[lvl].Attempts += stat.Attempts
+		b.EnhanceLevelDetail[lvl].Success += stat.Success
+		b.EnhanceLevelDetail[lvl].Fail += stat.Fail
+		b.EnhanceLevelDetail[lvl].Destroy += stat.Destroy
+	}
+}
+
+// mergeV4 v4 배틀 조합별 통계 누적
+func (b *Bucket) mergeV4(matchups map[string]*MatchupStat) {
+	for key, stat := range matchups {
+		if b.BattleMatchups[key] == nil {
+			b.BattleMatchups[key] = &MatchupStat{}
+		}
+		b.BattleMatchups[key].Battles += stat.Battles
+		b.BattleMatchups[key].Wins += stat.Wins
+		b.BattleMatchups[key].GoldEarned += stat.GoldEarned
+		b.BattleMatchups[key].GoldLost += stat.GoldLost
+	}
+}
+
+// Negated 모든 값의 부호를 바꾼 복사본 (기여분 차감용)
+func (b *Bucket) Negated() *Bucket {
+	n := &Bucket{
+		EnhanceAttempts:  -b.EnhanceAttempts,
+		EnhanceSuccess:   -b.EnhanceSuccess,
+		EnhanceFail:      -b.EnhanceFail,
+		EnhanceDestroy:   -b.EnhanceDestroy,
+		BattleCount:      -b.BattleCount,
+		BattleWins:       -b.BattleWins,
+		UpsetAttempts:    -b.UpsetAttempts,
+		UpsetWins:        -b.UpsetWins,
+		BattleGold:       -b.BattleGold,
+		FarmingAttempts:  -b.FarmingAttempts,
+		SpecialFound:     -b.SpecialFound,
+		SalesCount:       -b.SalesCount,
+		SalesTotalGold:   -b.SalesTotalGold,
+		EnhanceCostTotal: -b.EnhanceCostTotal,
+		CycleTimeTotal:   -b.CycleTimeTotal,
+		BattleGoldLost:   -b.BattleGoldLost,
+
+		EnhanceByLevel:     make(map[int]int, len(b.EnhanceByLevel)),
+		SwordBattleStats:   make(map[string]*SwordBattleStat, len(b.SwordBattleStats)),
+		SpecialFoundByName: make(map[string]int, len(b.SpecialFoundByName)),
+		UpsetStatsByDiff:   make(map[int]*UpsetStat, len(b.UpsetStatsByDiff)),
+		SwordSaleStats:     make(map[string]*SwordSaleStat, len(b.SwordSaleStats)),
+		SwordEnhanceStats:  make(map[string]*SwordEnhanceStat, len(b.SwordEnhanceStats)),
+		ItemFarmingStats:   make(map[string]*ItemFarmingStat, len(b.ItemFarmingStats)),
+		EnhanceLevelDetail: make(map[int]*EnhanceLevelStat, len(b.EnhanceLevelDetail)),
+		BattleMatchups:     make(map[string]*MatchupStat, len(b.BattleMatchups)),
+	}
+	for k, v := range b.EnhanceByLevel {
+		n.EnhanceByLevel[k] = -v
+	}
+	for k, v := range b.SwordBattleStats {
+		n.SwordBattleStats[k] = &SwordBattleStat{-v.BattleCount, -v.BattleWins, -v.UpsetAttempts, -v.UpsetWins}
+	}
+	for k, v := range b.SpecialFoundByName {
+		n.SpecialFoundByName[k] = -v
+	}
+	for k, v := range b.UpsetStatsByDiff {
+		n.UpsetStatsByDiff[k] = &UpsetStat{-v.Attempts, -v.Wins, -v.GoldEarned}
+	}
+	for k, v := range b.SwordSaleStats {
+		n.SwordSaleStats[k] = &SwordSaleStat{-v.TotalPrice, -v.Count}
+	}
+	for k, v := range b.SwordEnhanceStats {
+		n.SwordEnhanceStats[k] = &SwordEnhanceStat{-v.Attempts, -v.Success, -v.Fail, -v.Destroy}
+	}
+	for k, v := range b.ItemFarmingStats {
+		n.ItemFarmingStats[k] = &ItemFarmingStat{-v.TotalCount, -v.SpecialCount, -v.NormalCount, -v.TrashCount}
+	}
+	for k, v := range b.EnhanceLevelDetail {
+		n.EnhanceLevelDetail[k] = &EnhanceLevelStat{-v.Attempts, -v.Success, -v.Fail, -v.Destroy}
+	}
+	for k, v := range b.BattleMatchups {
+		n.BattleMatchups[k] = &MatchupStat{-v.Battles, -v.Wins, -v.GoldEarned, -v.GoldLost}
+	}
+	return n
+}
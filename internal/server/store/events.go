@@ -0,0 +1,257 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// ========================
+// v4 개별 이벤트
+// ========================
+
+// EventsToStats 이벤트 목록을 집계 통계로 변환 (클라이언트 Record* 집계 규칙과 동일)
+// 이벤트에 아이템 이름이 없으므로 이름별 통계(special_found_by_name, item_farming_stats, sword_battle_stats)는 재구성되지 않음
+func EventsToStats(events []TelemetryEvent) TelemetryStats {
+	s := TelemetryStats{
+		EnhanceByLevel:     make(map[int]int),
+		UpsetStatsByDiff:   make(map[int]*UpsetStat),
+		SwordSaleStats:     make(map[string]*SwordSaleStat),
+		SwordEnhanceStats:  make(map[string]*SwordEnhanceStat),
+		EnhanceLevelDetail: make(map[int]*EnhanceLevelStat),
+		BattleMatchups:     make(map[string]*MatchupStat),
+	}
+
+	for _, ev := range events {
+		switch ev.Type {
+		case "enhance":
+			s.EnhanceAttempts++
+			if s.EnhanceLevelDetail[ev.Level] == nil {
+				s.EnhanceLevelDetail[ev.Level] = &EnhanceLevelStat{}
+			}
+			lvlStat := s.EnhanceLevelDetail[ev.Level]
+			lvlStat.Attempts++
+
+			var typeStat *SwordEnhanceStat
+			if ev.ItemType != "" {
+				key := fmt.Sprintf("%s_%d", ev.ItemType, ev.Level)
+				if s.SwordEnhanceStats[key] == nil {
+					s.SwordEnhanceStats[key] = &SwordEnhanceStat{}
+				}
+				typeStat = s.SwordEnhanceStats[key]
+				typeStat.Attempts++
+			}
+
+			switch ev.Result {
+			case "success":
+				s.EnhanceSuccess++
+				s.EnhanceByLevel[ev.Level]++
+				lvlStat.Success++
+				if typeStat != nil {
+					typeStat.Success++
+				}
+			case "fail", "hold":
+				s.EnhanceFail++
+				lvlStat.Fail++
+				if typeStat != nil {
+					typeStat.Fail++
+				}
+			case "destroy":
+				s.EnhanceDestroy++
+				lvlStat.Destroy++
+				if typeStat != nil {
+					typeStat.Destroy++
+				}
+			}
+
+		case "battle":
+			s.BattleCount++
+			levelDiff := ev.TargetLevel - ev.Level
+			isUpset := levelDiff > 0
+			won := ev.Result == "win"
+
+			if isUpset {
+				s.UpsetAttempts++
+			}
+			if won {
+				s.BattleWins++
+				s.BattleGoldEarned += ev.GoldDelta
+				if isUpset {
+					s.UpsetWins++
+				}
+			} else {
+				s.BattleLosses++
+				if ev.GoldDelta < 0 {
+					s.BattleGoldLost += -ev.GoldDelta
+				}
+			}
+
+			if ev.ItemType != "" {
+				key := MatchupKey(ev.ItemType, ev.Level, ev.TargetLevel)
+				if s.BattleMatchups[key] == nil {
+					s.BattleMatchups[key] = &MatchupStat{}
+				}
+				stat := s.BattleMatchups[key]
+				stat.Battles++
+				if won {
+					stat.Wins++
+					stat.GoldEarned += ev.GoldDelta
+				} else if ev.GoldDelta < 0 {
+					stat.GoldLost += -ev.GoldDelta
+				}
+			}
+
+			if isUpset && levelDiff <= 20 {
+				if s.UpsetStatsByDiff[levelDiff] == nil {
+					s.UpsetStatsByDiff[levelDiff] = &UpsetStat{}
+				}
+				stat := s.UpsetStatsByDiff[levelDiff]
+				stat.Attempts++
+				if won && ev.GoldDelta > 0 {
+					stat.Wins++
+					stat.GoldEarned += ev.GoldDelta
+				}
+			}
+
+		case "farm":
+			s.FarmingAttempts++
+			if ev.ItemType == "special" {
+				s.SpecialFound++
+			} else if ev.ItemType == "trash" || ev.ItemType == "normal" {
+				s.TrashFound++
+			}
+
+		case "sale":
+			s.SalesCount++
+			s.SalesTotalGold += ev.GoldDelta
+			if ev.GoldDelta > s.SalesMaxPrice {
+				s.SalesMaxPrice = ev.GoldDelta
+			}
+			if ev.ItemType != "" {
+				key := fmt.Sprintf("%s_%d", ev.ItemType, ev.Level)
+				if s.SwordSaleStats[key] == nil {
+					s.SwordSaleStats[key] = &SwordSaleStat{}
+				}
+				s.SwordSaleStats[key].Count++
+				s.SwordSaleStats[key].TotalPrice += ev.GoldDelta
+			}
+		}
+	}
+
+	return s
+}
+
+// insertEvents 페이로드의 이벤트를 telemetry_events 테이블에 저장 (트랜잭션 내)
+func insertEvents(tx *sql.Tx, p *TelemetryPayload, period string, receivedAt int64) error {
+	for i, ev := range p.Stats.Events {
+		if _, err := tx.Exec(`INSERT INTO telemetry_events (
+			session_id, period, app_version, os_type, mode, seq,
+			type, level, target_level, item_type, result, gold_delta, duration_ms, offset_ms, received_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			p.SessionID, period, p.AppVersion, p.OSType, p.Mode, i,
+			ev.Type, ev.Level, ev.TargetLevel, ev.ItemType, ev.Result, ev.GoldDelta, ev.DurationMs, ev.OffsetMs, receivedAt,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 이벤트 재구성 시 초기화하는 집계 테이블 (global_stats는 writeSnapshot이 덮어씀)
+var rebuildTables = []string{
+	"enhance_by_level", "sword_battle_stats", "special_found_by_name", "upset_stats_by_diff",
+	"sword_sale_stats", "sword_enhance_stats", "item_farming_stats", "enhance_level_detail",
+	"daily_global_stats", "daily_enhance_by_level", "daily_sword_battle_stats", "daily_special_found_by_name",
+	"daily_upset_stats_by_diff", "daily_sword_sale_stats", "daily_sword_enhance_stats",
+	"daily_item_farming_stats", "daily_enhance_level_detail", "segment_stats",
+	"battle_matchups", "daily_battle_matchups",
+}
+
+// RebuildFromEvents telemetry_events 테이블로부터 집계 테이블 전체 재구성
+// 주의: 이벤트가 없는 페이로드(v1-v3)의 통계는 재구성 결과에 포함되지 않음
+// force가 아니면 payload_log에 v1-v3 페이로드가 남아 있을 때 재구성하지 않음
+func (sl *SQLite) RebuildFromEvents(force bool) (int, int, error) {
+	if err := sl.Flush(); err != nil {
+		return 0, 0, err
+	}
+	if !force {
+		var legacy int
+		if err := sl.db.QueryRow("SELECT COUNT(*) FROM payload_log WHERE COALESCE(json_extract(payload, '$.schema_version'), 0) < 4").Scan(&legacy); err != nil {
+			return 0, 0, fmt.Errorf("payload_log 조회 실패: %v", err)
+		}
+		if legacy > 0 {
+			return 0, 0, fmt.Errorf("%w (%d건)", ErrLegacyPayloads, legacy)
+		}
+	}
+	rows, err := sl.db.Query(`SELECT period, app_version, os_type, mode, type, level, target_level, item_type, result, gold_delta, duration_ms, offset_ms
+		FROM telemetry_events ORDER BY period, session_id, id`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("이벤트 조회 실패: %v", err)
+	}
+	defer rows.Close()
+
+	type eventGroup struct {
+		period string
+		Segment
+	}
+	byGroup := make(map[eventGroup][]TelemetryEvent)
+	periods := make(map[string]bool)
+	total := 0
+	for rows.Next() {
+		var g eventGroup
+		var ev TelemetryEvent
+		if err := rows.Scan(&g.period, &g.AppVersion, &g.OSType, &g.Mode, &ev.Type, &ev.Level, &ev.TargetLevel, &ev.ItemType, &ev.Result,
+			&ev.GoldDelta, &ev.DurationMs, &ev.OffsetMs); err != nil {
+			return 0, 0, fmt.Errorf("이벤트 읽기 실패: %v", err)
+		}
+		byGroup[g] = append(byGroup[g], ev)
+		periods[g.period] = true
+		total++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("이벤트 조회 실패: %v", err)
+	}
+
+	rebuilt := NewMemory()
+	for g, events := range byGroup {
+		rebuilt.record(&TelemetryPayload{
+			SchemaVersion: 4, // 이벤트 → v4 집계 형식 (이벤트 목록 없이 집계만)
+			Period:        g.period,
+			AppVersion:    g.AppVersion,
+			OSType:        g.OSType,
+			Mode:          g.Mode,
+			Stats:         EventsToStats(events),
+		})
+	}
+
+	if err := sl.replaceAll(rebuilt); err != nil {
+		return 0, 0, err
+	}
+
+	log.Printf("📦 이벤트 %d건(%d일)으로 집계 재구성 완료", total, len(periods))
+	return total, len(periods), nil
+}
+
+// replaceAll 집계 테이블과 메모리 집계를 새로 계산한 통계로 교체
+// 초기화와 기록을 한 트랜잭션으로 처리 (도중에 실패하면 기존 집계 유지)
+func (sl *SQLite) replaceAll(fresh *Memory) error {
+	tx, err := sl.db.Begin()
+	if err != nil {
+		return fmt.Errorf("트랜잭션 시작 실패: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range rebuildTables {
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			return fmt.Errorf("%s 초기화 실패: %v", table, err)
+		}
+	}
+	if err := writeSnapshot(tx, fresh.Snapshot()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("커밋 실패: %v", err)
+	}
+	sl.replace(fresh)
+	return nil
+}
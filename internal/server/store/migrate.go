@@ -0,0 +1,273 @@
+package store
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ========================
+// 스키마 마이그레이션
+// ========================
+//
+// migrations/NNNN_이름.sql 파일을 번호 순서대로 적용하고 schema_migrations에 기록
+// 새 스키마 버전은 기존 파일을 고치지 말고 다음 번호 파일로 추가
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// Migration 번호가 붙은 마이그레이션 한 건
+type Migration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+// MigrationStep 적용(예정) 내역
+type MigrationStep struct {
+	Migration
+	Skipped []bool // 문장별: 이미 반영되어 건너뜀 (기존 DB의 ADD COLUMN)
+}
+
+var (
+	migrationFileRe = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+	addColumnRe     = regexp.MustCompile(`(?i)^ALTER\s+TABLE\s+(\w+)\s+ADD\s+COLUMN\s+(\w+)`)
+)
+
+// loadMigrations 내장 마이그레이션 파일 로드 (버전 오름차순)
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("마이그레이션 목록 읽기 실패: %v", err)
+	}
+
+	var migrations []Migration
+	seen := make(map[int]string)
+	for _, e := range entries {
+		m := migrationFileRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("잘못된 마이그레이션 파일 이름: %s", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		if prev, ok := seen[version]; ok {
+			return nil, fmt.Errorf("마이그레이션 버전 중복: %s, %s", prev, e.Name())
+		}
+		seen[version] = e.Name()
+
+		data, err := migrationFS.ReadFile("migrations/" + e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("마이그레이션 읽기 실패: %v", err)
+		}
+		migrations = append(migrations, Migration{
+			Version:    version,
+			Name:       m[2],
+			Statements: splitStatements(string(data)),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// splitStatements SQL 파일을 문장 단위로 분리 (-- 주석 제거)
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var stmts []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// ensureMigrationTable schema_migrations 테이블 생성
+func ensureMigrationTable(q queryer) error {
+	_, err := q.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at INTEGER NOT NULL
+	)`)
+	return err
+}
+
+// queryer *sql.DB / *sql.Tx 공통
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// appliedVersions 적용된 마이그레이션 버전
+func appliedVersions(q queryer) (map[int]bool, error) {
+	applied := make(map[int]bool)
+
+	var exists int
+	if err := q.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='schema_migrations'").Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return applied, nil
+	}
+
+	rows, err := q.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		applied[v] = true
+	}
+	return applied, rows.Err()
+}
+
+// columnExists 테이블에 컬럼이 있는지 확인 (테이블이 없으면 false)
+func columnExists(q queryer, table, column string) (bool, error) {
+	rows, err := q.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, err
+		}
+		if strings.EqualFold(name, column) {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// pendingSteps 미적용 마이그레이션과 문장별 건너뜀 여부
+// schema_migrations 도입 전 DB는 ADD COLUMN이 이미 반영되어 있을 수 있으므로 컬럼 존재 시 건너뜀
+// (같은 마이그레이션 안에서 앞 문장이 만드는 테이블의 컬럼은 실행 시점에 다시 확인)
+func pendingSteps(q queryer, migrations []Migration) ([]MigrationStep, error) {
+	applied, err := appliedVersions(q)
+	if err != nil {
+		return nil, fmt.Errorf("적용 내역 조회 실패: %v", err)
+	}
+
+	var steps []MigrationStep
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+		step := MigrationStep{Migration: m, Skipped: make([]bool, len(m.Statements))}
+		for i, stmt := range m.Statements {
+			if c := addColumnRe.FindStringSubmatch(stmt); c != nil {
+				exists, err := columnExists(q, c[1], c[2])
+				if err != nil {
+					return nil, fmt.Errorf("컬럼 확인 실패: %v", err)
+				}
+				step.Skipped[i] = exists
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// migrate 미적용 마이그레이션을 하나의 트랜잭션으로 적용 (반환: 적용 내역)
+func migrate(db *sql.DB) ([]MigrationStep, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("트랜잭션 시작 실패: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := ensureMigrationTable(tx); err != nil {
+		return nil, fmt.Errorf("schema_migrations 생성 실패: %v", err)
+	}
+
+	var done []MigrationStep
+	now := time.Now().Unix()
+	for _, m := range migrations {
+		// 앞선 마이그레이션이 만든 테이블/컬럼을 반영하도록 한 건씩 다시 확인
+		steps, err := pendingSteps(tx, []Migration{m})
+		if err != nil {
+			return nil, err
+		}
+		if len(steps) == 0 {
+			continue
+		}
+		step := steps[0]
+		for i, stmt := range step.Statements {
+			if step.Skipped[i] {
+				continue
+			}
+			if _, err := tx.Exec(stmt); err != nil {
+				return nil, fmt.Errorf("마이그레이션 %04d_%s 실패: %v", m.Version, m.Name, err)
+			}
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+			m.Version, m.Name, now); err != nil {
+			return nil, fmt.Errorf("마이그레이션 기록 실패: %v", err)
+		}
+		done = append(done, step)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("마이그레이션 커밋 실패: %v", err)
+	}
+	return done, nil
+}
+
+// PlanMigrations DB를 바꾸지 않고 적용 예정 마이그레이션 조회 (dry-run)
+// DB 파일이 없으면 전체 마이그레이션이 적용 예정
+func PlanMigrations(dbPath string) ([]MigrationStep, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("DB 열기 실패: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		// 파일 없음 → 새 DB
+		var steps []MigrationStep
+		for _, m := range migrations {
+			steps = append(steps, MigrationStep{Migration: m, Skipped: make([]bool, len(m.Statements))})
+		}
+		return steps, nil
+	}
+	return pendingSteps(db, migrations)
+}
+
+// MigrateSQLite DB 파일에 미적용 마이그레이션 적용 (통계는 로드하지 않음)
+func MigrateSQLite(dbPath string) ([]MigrationStep, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("DB 열기 실패: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	return migrate(db)
+}
@@ -0,0 +1,65 @@
+-- v1/v2 전체 누적 통계
+CREATE TABLE IF NOT EXISTS global_stats (
+	id INTEGER PRIMARY KEY DEFAULT 1,
+	enhance_attempts INTEGER DEFAULT 0,
+	enhance_success INTEGER DEFAULT 0,
+	enhance_fail INTEGER DEFAULT 0,
+	enhance_destroy INTEGER DEFAULT 0,
+	battle_count INTEGER DEFAULT 0,
+	battle_wins INTEGER DEFAULT 0,
+	upset_attempts INTEGER DEFAULT 0,
+	upset_wins INTEGER DEFAULT 0,
+	battle_gold INTEGER DEFAULT 0,
+	farming_attempts INTEGER DEFAULT 0,
+	special_found INTEGER DEFAULT 0,
+	sales_count INTEGER DEFAULT 0,
+	sales_total_gold INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS enhance_by_level (
+	level INTEGER PRIMARY KEY,
+	count INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS sword_battle_stats (
+	name TEXT PRIMARY KEY,
+	battle_count INTEGER DEFAULT 0,
+	battle_wins INTEGER DEFAULT 0,
+	upset_attempts INTEGER DEFAULT 0,
+	upset_wins INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS special_found_by_name (
+	name TEXT PRIMARY KEY,
+	count INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS upset_stats_by_diff (
+	level_diff INTEGER PRIMARY KEY,
+	attempts INTEGER DEFAULT 0,
+	wins INTEGER DEFAULT 0,
+	gold_earned INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS sword_sale_stats (
+	key TEXT PRIMARY KEY,
+	total_price INTEGER DEFAULT 0,
+	count INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS sword_enhance_stats (
+	name TEXT PRIMARY KEY,
+	attempts INTEGER DEFAULT 0,
+	success INTEGER DEFAULT 0,
+	fail INTEGER DEFAULT 0,
+	destroy INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS item_farming_stats (
+	name TEXT PRIMARY KEY,
+	total_count INTEGER DEFAULT 0,
+	special_count INTEGER DEFAULT 0,
+	normal_count INTEGER DEFAULT 0
+);
+
+INSERT OR IGNORE INTO global_stats (id) VALUES (1);
@@ -0,0 +1,13 @@
+-- v3: 강화 비용/사이클 시간/배틀 손실, 쓰레기 파밍 수, 레벨별 강화 상세
+ALTER TABLE global_stats ADD COLUMN enhance_cost_total INTEGER DEFAULT 0;
+ALTER TABLE global_stats ADD COLUMN cycle_time_total REAL DEFAULT 0;
+ALTER TABLE global_stats ADD COLUMN battle_gold_lost INTEGER DEFAULT 0;
+ALTER TABLE item_farming_stats ADD COLUMN trash_count INTEGER DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS enhance_level_detail (
+	level INTEGER PRIMARY KEY,
+	attempts INTEGER DEFAULT 0,
+	success INTEGER DEFAULT 0,
+	fail INTEGER DEFAULT 0,
+	destroy INTEGER DEFAULT 0
+);
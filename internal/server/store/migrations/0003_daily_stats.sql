@@ -0,0 +1,91 @@
+-- 일별(period) 통계
+CREATE TABLE IF NOT EXISTS daily_global_stats (
+	period TEXT PRIMARY KEY,
+	enhance_attempts INTEGER DEFAULT 0,
+	enhance_success INTEGER DEFAULT 0,
+	enhance_fail INTEGER DEFAULT 0,
+	enhance_destroy INTEGER DEFAULT 0,
+	battle_count INTEGER DEFAULT 0,
+	battle_wins INTEGER DEFAULT 0,
+	upset_attempts INTEGER DEFAULT 0,
+	upset_wins INTEGER DEFAULT 0,
+	battle_gold INTEGER DEFAULT 0,
+	farming_attempts INTEGER DEFAULT 0,
+	special_found INTEGER DEFAULT 0,
+	sales_count INTEGER DEFAULT 0,
+	sales_total_gold INTEGER DEFAULT 0,
+	enhance_cost_total INTEGER DEFAULT 0,
+	cycle_time_total REAL DEFAULT 0,
+	battle_gold_lost INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS daily_enhance_by_level (
+	period TEXT,
+	level INTEGER,
+	count INTEGER DEFAULT 0,
+	PRIMARY KEY (period, level)
+);
+
+CREATE TABLE IF NOT EXISTS daily_sword_battle_stats (
+	period TEXT,
+	name TEXT,
+	battle_count INTEGER DEFAULT 0,
+	battle_wins INTEGER DEFAULT 0,
+	upset_attempts INTEGER DEFAULT 0,
+	upset_wins INTEGER DEFAULT 0,
+	PRIMARY KEY (period, name)
+);
+
+CREATE TABLE IF NOT EXISTS daily_special_found_by_name (
+	period TEXT,
+	name TEXT,
+	count INTEGER DEFAULT 0,
+	PRIMARY KEY (period, name)
+);
+
+CREATE TABLE IF NOT EXISTS daily_upset_stats_by_diff (
+	period TEXT,
+	level_diff INTEGER,
+	attempts INTEGER DEFAULT 0,
+	wins INTEGER DEFAULT 0,
+	gold_earned INTEGER DEFAULT 0,
+	PRIMARY KEY (period, level_diff)
+);
+
+CREATE TABLE IF NOT EXISTS daily_sword_sale_stats (
+	period TEXT,
+	key TEXT,
+	total_price INTEGER DEFAULT 0,
+	count INTEGER DEFAULT 0,
+	PRIMARY KEY (period, key)
+);
+
+CREATE TABLE IF NOT EXISTS daily_sword_enhance_stats (
+	period TEXT,
+	name TEXT,
+	attempts INTEGER DEFAULT 0,
+	success INTEGER DEFAULT 0,
+	fail INTEGER DEFAULT 0,
+	destroy INTEGER DEFAULT 0,
+	PRIMARY KEY (period, name)
+);
+
+CREATE TABLE IF NOT EXISTS daily_item_farming_stats (
+	period TEXT,
+	name TEXT,
+	total_count INTEGER DEFAULT 0,
+	special_count INTEGER DEFAULT 0,
+	normal_count INTEGER DEFAULT 0,
+	trash_count INTEGER DEFAULT 0,
+	PRIMARY KEY (period, name)
+);
+
+CREATE TABLE IF NOT EXISTS daily_enhance_level_detail (
+	period TEXT,
+	level INTEGER,
+	attempts INTEGER DEFAULT 0,
+	success INTEGER DEFAULT 0,
+	fail INTEGER DEFAULT 0,
+	destroy INTEGER DEFAULT 0,
+	PRIMARY KEY (period, level)
+);
@@ -0,0 +1,22 @@
+-- v4 개별 이벤트
+CREATE TABLE IF NOT EXISTS telemetry_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id TEXT,
+	period TEXT,
+	app_version TEXT,
+	os_type TEXT,
+	mode TEXT,
+	seq INTEGER,
+	type TEXT,
+	level INTEGER,
+	target_level INTEGER,
+	item_type TEXT,
+	result TEXT,
+	gold_delta INTEGER,
+	duration_ms INTEGER,
+	offset_ms INTEGER,
+	received_at INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_period ON telemetry_events(period);
+CREATE INDEX IF NOT EXISTS idx_events_type_level ON telemetry_events(type, level);
@@ -0,0 +1,9 @@
+-- 설치별 키 (HMAC 서명)
+CREATE TABLE IF NOT EXISTS install_keys (
+	key_id TEXT PRIMARY KEY,
+	secret TEXT NOT NULL,
+	created_at INTEGER,
+	revoked_at INTEGER DEFAULT 0,
+	app_version TEXT DEFAULT '',
+	os_type TEXT DEFAULT ''
+);
@@ -0,0 +1,13 @@
+-- 세션별 페이로드 순번 (재전송/재생 중복 방지)
+CREATE TABLE IF NOT EXISTS payload_seqs (
+	session_id TEXT NOT NULL,
+	seq INTEGER NOT NULL,
+	received_at INTEGER,
+	PRIMARY KEY (session_id, seq)
+);
+
+-- 일별 중복 거부 횟수
+CREATE TABLE IF NOT EXISTS duplicate_payloads (
+	period TEXT PRIMARY KEY,
+	count INTEGER DEFAULT 0
+);
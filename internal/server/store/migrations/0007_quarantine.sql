@@ -0,0 +1,12 @@
+-- 이상치로 판정되어 집계에서 제외된 페이로드 (관리자 검토 대기)
+CREATE TABLE IF NOT EXISTS quarantine (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id TEXT NOT NULL,
+	period TEXT,
+	score REAL,
+	reason TEXT,
+	payload TEXT NOT NULL,
+	received_at INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_quarantine_session ON quarantine(session_id);
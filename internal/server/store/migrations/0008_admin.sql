@@ -0,0 +1,40 @@
+-- 반영된 페이로드 원문 (세션 기여분 삭제 / 전체 재계산용)
+CREATE TABLE IF NOT EXISTS payload_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id TEXT NOT NULL,
+	period TEXT,
+	payload TEXT NOT NULL,
+	received_at INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_payload_log_session ON payload_log(session_id);
+
+-- 세션별 수신 현황
+CREATE TABLE IF NOT EXISTS sessions (
+	session_id TEXT PRIMARY KEY,
+	first_seen INTEGER,
+	last_seen INTEGER,
+	payloads INTEGER DEFAULT 0,
+	app_version TEXT DEFAULT '',
+	os_type TEXT DEFAULT '',
+	mode TEXT DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_last_seen ON sessions(last_seen);
+
+-- 관리자가 덮어쓴 기본값 (enhance_rates, sword_prices, battle_rewards)
+CREATE TABLE IF NOT EXISTS default_overrides (
+	name TEXT PRIMARY KEY,
+	value TEXT NOT NULL,
+	updated_at INTEGER
+);
+
+-- 관리자 작업 기록
+CREATE TABLE IF NOT EXISTS admin_audit (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	at INTEGER NOT NULL,
+	actor TEXT NOT NULL,
+	action TEXT NOT NULL,
+	target TEXT DEFAULT '',
+	detail TEXT DEFAULT ''
+);
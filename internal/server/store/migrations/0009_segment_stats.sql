@@ -0,0 +1,9 @@
+-- 앱 버전 / OS / 모드별 일별 통계 (버킷 전체를 JSON으로 보관)
+CREATE TABLE IF NOT EXISTS segment_stats (
+	period TEXT,
+	app_version TEXT,
+	os_type TEXT,
+	mode TEXT,
+	stats TEXT NOT NULL,
+	PRIMARY KEY (period, app_version, os_type, mode)
+);
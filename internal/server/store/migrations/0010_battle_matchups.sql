@@ -0,0 +1,19 @@
+-- (검 타입, 내 레벨, 상대 레벨)별 배틀 통계 - 배틀 승률 모델 학습용
+-- key: "normal_10_12"
+CREATE TABLE IF NOT EXISTS battle_matchups (
+	key TEXT PRIMARY KEY,
+	battles INTEGER DEFAULT 0,
+	wins INTEGER DEFAULT 0,
+	gold_earned INTEGER DEFAULT 0,
+	gold_lost INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS daily_battle_matchups (
+	period TEXT,
+	key TEXT,
+	battles INTEGER DEFAULT 0,
+	wins INTEGER DEFAULT 0,
+	gold_earned INTEGER DEFAULT 0,
+	gold_lost INTEGER DEFAULT 0,
+	PRIMARY KEY (period, key)
+);
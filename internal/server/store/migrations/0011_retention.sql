@@ -0,0 +1,3 @@
+-- 보관 기간이 지난 원문 / 순번 정리용 (배치 저장 시 received_at 기준 삭제)
+CREATE INDEX IF NOT EXISTS idx_payload_log_received ON payload_log(received_at);
+CREATE INDEX IF NOT EXISTS idx_payload_seqs_received ON payload_seqs(received_at);
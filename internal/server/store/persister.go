@@ -0,0 +1,229 @@
+package store
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ========================
+// 배치 저장 (SQLite)
+// ========================
+//
+// Ingest는 메모리 집계에 바로 반영하고 페이로드를 대기열에 쌓음
+// 백그라운드 저장기 하나가 주기마다(또는 대기열이 차면) 대기열 전체를 한 트랜잭션으로 저장:
+// 바뀐 키(전체 누적 / 일자 / 세그먼트)는 합산한 증분만 upsert, 원문/이벤트/순번은 페이로드별로 기록
+// 원문과 순번은 보관 기간이 지나면 pruneInterval마다 저장 트랜잭션에서 함께 삭제
+// Close와 관리 작업(세션 삭제, 재계산 등)은 먼저 대기열을 비움
+
+const (
+	maxPendingPayloads = 500       // 대기열이 이만큼 차면 주기를 기다리지 않고 저장
+	pruneInterval      = time.Hour // 보관 기간이 지난 원문 / 순번 정리 주기 (배치 저장 때 함께 실행)
+)
+
+// FlushInterval 배치 저장 주기 (OpenSQLite 전에 설정)
+var FlushInterval = 5 * time.Second
+
+// pendingPayload 저장 대기 중인 페이로드
+type pendingPayload struct {
+	payload    TelemetryPayload
+	period     string
+	receivedAt int64
+}
+
+// ingestBatch 저장 대기열
+type ingestBatch struct {
+	payloads []pendingPayload
+	dups     map[string]int // period → 중복 거부 횟수
+}
+
+func newIngestBatch() *ingestBatch {
+	return &ingestBatch{dups: make(map[string]int)}
+}
+
+func (b *ingestBatch) empty() bool {
+	return len(b.payloads) == 0 && len(b.dups) == 0
+}
+
+// prepend 저장에 실패한 배치를 대기열 앞에 되돌림 (수신 순서 유지)
+func (b *ingestBatch) prepend(failed *ingestBatch) {
+	b.payloads = append(append([]pendingPayload{}, failed.payloads...), b.payloads...)
+	for period, n := range failed.dups {
+		b.dups[period] += n
+	}
+}
+
+// FlushHook 배치 저장 결과 콜백 (메트릭용, 저장한 페이로드 수 / 소요 시간 / 오류)
+type FlushHook func(payloads int, took time.Duration, err error)
+
+// persister 백그라운드 저장기 상태
+type persister struct {
+	interval time.Duration
+	wake     chan struct{} // 대기열이 찼을 때 즉시 저장 요청
+	stop     chan struct{}
+	done     chan struct{}
+	flushMu  sync.Mutex // 저장은 한 번에 하나만
+
+	pending *ingestBatch // sl.mu 보호
+
+	lastPrune time.Time // 마지막 원문 / 순번 정리 시각 (flushMu 보호)
+
+	hook FlushHook
+}
+
+// SetFlushHook 배치 저장 결과 콜백 등록
+func (sl *SQLite) SetFlushHook(hook FlushHook) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.persist.hook = hook
+}
+
+// startPersister 백그라운드 저장기 시작
+func (sl *SQLite) startPersister(interval time.Duration) {
+	sl.persist = &persister{
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		pending:  newIngestBatch(),
+	}
+	go sl.runPersister()
+}
+
+func (sl *SQLite) runPersister() {
+	p := sl.persist
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.wake:
+		case <-p.stop:
+			return
+		}
+		if err := sl.Flush(); err != nil {
+			log.Printf("⚠️ 배치 저장 실패 (다음 주기에 재시도): %v", err)
+		}
+	}
+}
+
+// stopPersister 저장기 종료 후 남은 대기열 저장
+func (sl *SQLite) stopPersister() error {
+	close(sl.persist.stop)
+	<-sl.persist.done
+	return sl.Flush()
+}
+
+// Flush 대기열을 한 트랜잭션으로 저장 (실패하면 대기열에 되돌림)
+func (sl *SQLite) Flush() error {
+	p := sl.persist
+	p.flushMu.Lock()
+	defer p.flushMu.Unlock()
+
+	now := time.Now()
+	prune := now.Sub(p.lastPrune) >= pruneInterval
+
+	sl.mu.Lock()
+	batch := p.pending
+	if batch.empty() && !prune {
+		sl.mu.Unlock()
+		return nil
+	}
+	p.pending = newIngestBatch()
+	hook := p.hook
+	sl.mu.Unlock()
+
+	start := time.Now()
+	err := sl.writeBatch(batch, prune)
+	if err == nil && prune {
+		p.lastPrune = now
+	}
+
+	if err != nil {
+		sl.mu.Lock()
+		p.pending.prepend(batch)
+		sl.mu.Unlock()
+	}
+
+	if hook != nil {
+		hook(len(batch.payloads), time.Since(start), err)
+	}
+	return err
+}
+
+// writeBatch 배치 저장 트랜잭션 (prune이면 보관 기간이 지난 원문 / 순번도 삭제)
+func (sl *SQLite) writeBatch(batch *ingestBatch, prune bool) error {
+	tx, err := sl.db.Begin()
+	if err != nil {
+		return fmt.Errorf("트랜잭션 시작 실패: %v", err)
+	}
+	defer tx.Rollback()
+
+	// 바뀐 키별 증분 합산
+	total := NewBucket()
+	daily := make(map[string]*Bucket)
+	segments := make(map[string]map[Segment]*Bucket)
+	for i := range batch.payloads {
+		pp := &batch.payloads[i]
+		p := &pp.payload
+
+		if p.Seq > 0 {
+			if err := recordSeq(tx, p, pp.receivedAt); err != nil {
+				return err
+			}
+		}
+		if err := insertEvents(tx, p, pp.period, pp.receivedAt); err != nil {
+			return fmt.Errorf("이벤트 저장 실패: %v", err)
+		}
+		if err := logPayload(tx, p, pp.period, pp.receivedAt); err != nil {
+			return fmt.Errorf("페이로드 기록 실패: %v", err)
+		}
+
+		total.Add(p)
+		if daily[pp.period] == nil {
+			daily[pp.period] = NewBucket()
+			segments[pp.period] = make(map[Segment]*Bucket)
+		}
+		daily[pp.period].Add(p)
+		seg := SegmentOf(p)
+		if segments[pp.period][seg] == nil {
+			segments[pp.period][seg] = NewBucket()
+		}
+		segments[pp.period][seg].Add(p)
+	}
+
+	if len(batch.payloads) > 0 {
+		if err := upsertBucket(tx, "", total); err != nil {
+			return fmt.Errorf("누적 통계 저장 실패: %v", err)
+		}
+	}
+	for period, d := range daily {
+		if err := upsertBucket(tx, period, d); err != nil {
+			return fmt.Errorf("일별 통계 저장 실패: %v", err)
+		}
+		for seg, d := range segments[period] {
+			if err := upsertSegment(tx, period, seg, d); err != nil {
+				return fmt.Errorf("세그먼트 통계 저장 실패: %v", err)
+			}
+		}
+	}
+	for period, n := range batch.dups {
+		if err := upsertAdd(tx, "duplicate_payloads", []string{"period"}, []interface{}{period}, []string{"count"}, []interface{}{n}); err != nil {
+			return fmt.Errorf("중복 횟수 저장 실패: %v", err)
+		}
+	}
+
+	if prune {
+		if err := prunePayloads(tx, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("커밋 실패: %v", err)
+	}
+	return nil
+}
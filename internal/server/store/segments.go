@@ -0,0 +1,144 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ========================
+// 세그먼트 통계 (앱 버전 / OS / 모드별)
+// ========================
+//
+// 특정 클라이언트 릴리스가 잘못된 데이터를 보낸 경우 사후에 걸러낼 수 있도록
+// 일별 통계를 (app_version, os_type, mode) 조합별로도 보관
+// 0009 마이그레이션 전에 받은 통계는 세그먼트에 없음
+
+// Segment 페이로드를 보낸 클라이언트 구분
+type Segment struct {
+	AppVersion string `json:"app_version"`
+	OSType     string `json:"os_type"`
+	Mode       string `json:"mode"`
+}
+
+// SegmentOf 페이로드의 세그먼트
+func SegmentOf(p *TelemetryPayload) Segment {
+	return Segment{AppVersion: p.AppVersion, OSType: p.OSType, Mode: p.Mode}
+}
+
+// SegmentBucket 일자 + 세그먼트별 통계
+type SegmentBucket struct {
+	Period string
+	Segment
+	*Bucket
+}
+
+// ========================
+// 인메모리 저장소
+// ========================
+
+// segmentBucket 세그먼트 버킷 조회 (없으면 생성, 호출자가 Lock 보유)
+func (m *Memory) segmentBucket(period string, seg Segment) *Bucket {
+	bySeg := m.segments[period]
+	if bySeg == nil {
+		bySeg = make(map[Segment]*Bucket)
+		m.segments[period] = bySeg
+	}
+	b := bySeg[seg]
+	if b == nil {
+		b = NewBucket()
+		bySeg[seg] = b
+	}
+	return b
+}
+
+// Segments since 이후 일자 + 세그먼트별 통계 복사본 (period, 세그먼트 오름차순)
+func (m *Memory) Segments(since string) []SegmentBucket {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.segmentsSince(since)
+}
+
+// segmentsSince Segments 본체 (호출자가 RLock 보유)
+func (m *Memory) segmentsSince(since string) []SegmentBucket {
+	var out []SegmentBucket
+	for period, bySeg := range m.segments {
+		if period < since {
+			continue
+		}
+		for seg, b := range bySeg {
+			out = append(out, SegmentBucket{Period: period, Segment: seg, Bucket: b.Clone()})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Period != b.Period {
+			return a.Period < b.Period
+		}
+		if a.AppVersion != b.AppVersion {
+			return a.AppVersion < b.AppVersion
+		}
+		if a.OSType != b.OSType {
+			return a.OSType < b.OSType
+		}
+		return a.Mode < b.Mode
+	})
+	return out
+}
+
+// ========================
+// SQLite 저장소
+// ========================
+
+// upsertSegment 세그먼트 통계에 증분 더하기 (트랜잭션 내)
+// 버킷 JSON을 DB에서 읽어 합산하므로 메모리 반영 전에 다른 트랜잭션이 끼어도 누락되지 않음
+func upsertSegment(tx *sql.Tx, period string, seg Segment, d *Bucket) error {
+	b := NewBucket()
+	var data string
+	err := tx.QueryRow("SELECT stats FROM segment_stats WHERE period=? AND app_version=? AND os_type=? AND mode=?",
+		period, seg.AppVersion, seg.OSType, seg.Mode).Scan(&data)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal([]byte(data), b); err != nil {
+			return fmt.Errorf("세그먼트 통계 파싱 실패: %v", err)
+		}
+	}
+	b.Merge(d)
+	return saveSegment(tx, period, seg, b)
+}
+
+// saveSegment 세그먼트 통계 한 건 저장 (트랜잭션 내)
+func saveSegment(tx *sql.Tx, period string, seg Segment, b *Bucket) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT OR REPLACE INTO segment_stats (period, app_version, os_type, mode, stats) VALUES (?, ?, ?, ?, ?)",
+		period, seg.AppVersion, seg.OSType, seg.Mode, string(data))
+	return err
+}
+
+// loadSegments 세그먼트 통계 로드
+func (sl *SQLite) loadSegments() error {
+	rows, err := sl.db.Query("SELECT period, app_version, os_type, mode, stats FROM segment_stats")
+	if err != nil {
+		return fmt.Errorf("segment_stats 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period, data string
+		var seg Segment
+		if err := rows.Scan(&period, &seg.AppVersion, &seg.OSType, &seg.Mode, &data); err != nil {
+			return fmt.Errorf("segment_stats 로드 실패: %v", err)
+		}
+		b := sl.segmentBucket(period, seg)
+		if err := json.Unmarshal([]byte(data), b); err != nil {
+			return fmt.Errorf("segment_stats 파싱 실패 (%s): %v", period, err)
+		}
+	}
+	return rows.Err()
+}
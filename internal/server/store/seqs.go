@@ -0,0 +1,85 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ========================
+// 최근 반영된 순번 (중복 판별)
+// ========================
+//
+// 클라이언트 아웃박스는 최대 7일 동안 재전송하므로 그보다 조금 긴 기간의 (session_id, seq)만 메모리에 두고 확인
+// Ingest가 DB를 조회하지 않아 배치 저장 트랜잭션을 기다리지 않음
+
+const (
+	seqWindow  = 8 * 24 * time.Hour // 중복 판별 기간 (클라이언트 아웃박스 보관 기간 + 여유)
+	maxSeqKeys = 1_000_000          // 기간 안이라도 이보다 많으면 오래된 것부터 버림
+)
+
+// recentSeqs 최근 반영된 (session_id, seq) (반영 순서 유지)
+type recentSeqs struct {
+	at    map[seqKey]int64 // 키 → 반영 시각 (Unix)
+	order []seqKey         // 반영 순 (오래된 것부터)
+}
+
+func newRecentSeqs() *recentSeqs {
+	return &recentSeqs{at: make(map[seqKey]int64)}
+}
+
+func (r *recentSeqs) has(k seqKey) bool {
+	_, ok := r.at[k]
+	return ok
+}
+
+// add 순번 기록 후 기간 / 개수 제한 적용
+func (r *recentSeqs) add(k seqKey, now int64) {
+	if _, ok := r.at[k]; !ok {
+		r.order = append(r.order, k)
+	}
+	r.at[k] = now
+	r.prune(now)
+}
+
+// prune 기간이 지났거나 개수를 넘은 오래된 순번 제거
+func (r *recentSeqs) prune(now int64) {
+	cutoff := now - int64(seqWindow/time.Second)
+	drop := 0
+	for drop < len(r.order) {
+		k := r.order[drop]
+		if r.at[k] >= cutoff && len(r.order)-drop <= maxSeqKeys {
+			break
+		}
+		delete(r.at, k)
+		drop++
+	}
+	if drop > 0 {
+		r.order = append([]seqKey(nil), r.order[drop:]...)
+	}
+}
+
+// loadRecentSeqs payload_seqs에서 중복 판별 기간 안의 순번 로드
+func loadRecentSeqs(db *sql.DB, now time.Time) (*recentSeqs, error) {
+	r := newRecentSeqs()
+	rows, err := db.Query("SELECT session_id, seq, received_at FROM payload_seqs WHERE received_at >= ? ORDER BY received_at",
+		now.Add(-seqWindow).Unix())
+	if err != nil {
+		return nil, fmt.Errorf("payload_seqs 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k seqKey
+		var at int64
+		if err := rows.Scan(&k.sessionID, &k.seq, &at); err != nil {
+			return nil, fmt.Errorf("payload_seqs 로드 실패: %v", err)
+		}
+		r.order = append(r.order, k)
+		r.at[k] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("payload_seqs 로드 실패: %v", err)
+	}
+	r.prune(now.Unix())
+	return r, nil
+}
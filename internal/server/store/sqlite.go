@@ -0,0 +1,813 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ========================
+// SQLite 저장소
+// ========================
+
+// SQLite SQLite 저장소
+// 조회는 메모리에 올린 집계로 처리하고, 바뀐 키만 주기적으로 배치 upsert (persister.go)
+type SQLite struct {
+	*Memory
+	db      *sql.DB
+	persist *persister
+}
+
+// OpenSQLite SQLite DB 열기, 스키마 초기화, 저장된 통계 로드
+func OpenSQLite(dbPath string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("DB 열기 실패: %v", err)
+	}
+
+	// SQLite 쓰기는 한 번에 하나만 (동시 트랜잭션 시 SQLITE_BUSY 방지)
+	db.SetMaxOpenConns(1)
+
+	// WAL 모드 (동시 읽기/쓰기 성능 향상)
+	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("WAL 설정 실패: %v", err)
+	}
+	// 관리 명령(sword-api admin)이 실행 중인 서버와 동시에 쓸 때 잠금 대기
+	if _, err := db.Exec("PRAGMA busy_timeout=5000"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("busy_timeout 설정 실패: %v", err)
+	}
+
+	applied, err := migrate(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	for _, step := range applied {
+		log.Printf("📦 스키마 마이그레이션 적용: %04d_%s", step.Version, step.Name)
+	}
+	log.Printf("📦 SQLite DB 초기화 완료: %s", dbPath)
+
+	sl := &SQLite{Memory: NewMemory(), db: db}
+	if err := sl.load(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("DB 로드 실패: %v", err)
+	}
+	sl.startPersister(FlushInterval)
+	return sl, nil
+}
+
+// Ingest 페이로드를 메모리 집계에 반영하고 저장 대기열에 추가 (DB 저장은 백그라운드 저장기가 배치로 처리)
+// (session_id, seq) 중복은 메모리의 최근 순번으로 확인 (DB 조회 없음)
+func (sl *SQLite) Ingest(p *TelemetryPayload) error {
+	period := NormalizePeriod(p.Period)
+	now := time.Now().Unix()
+
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	pending := sl.persist.pending
+	if p.Seq > 0 {
+		k := seqKey{p.SessionID, p.Seq}
+		if sl.seqs.has(k) {
+			sl.dups[period]++
+			pending.dups[period]++
+			return ErrDuplicate
+		}
+		sl.seqs.add(k, now)
+	}
+
+	sl.record(p)
+	pending.payloads = append(pending.payloads, pendingPayload{payload: *p, period: period, receivedAt: now})
+	if len(pending.payloads) >= maxPendingPayloads {
+		select {
+		case sl.persist.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// recordSeq (session_id, seq) 기록 (트랜잭션 내, 중복은 Ingest에서 걸러짐)
+func recordSeq(tx *sql.Tx, p *TelemetryPayload, receivedAt int64) error {
+	if _, err := tx.Exec("INSERT OR IGNORE INTO payload_seqs (session_id, seq, received_at) VALUES (?, ?, ?)",
+		p.SessionID, p.Seq, receivedAt); err != nil {
+		return fmt.Errorf("순번 저장 실패: %v", err)
+	}
+	return nil
+}
+
+// globalCols global_stats / daily_global_stats 공통 합산 컬럼
+var globalCols = []string{
+	"enhance_attempts", "enhance_success", "enhance_fail", "enhance_destroy",
+	"battle_count", "battle_wins", "upset_attempts", "upset_wins", "battle_gold",
+	"farming_attempts", "special_found", "sales_count", "sales_total_gold",
+	"enhance_cost_total", "cycle_time_total", "battle_gold_lost",
+}
+
+// upsertBucket 버킷(페이로드 증분)에 들어있는 키만 더하기 upsert
+// period가 비어있으면 전체 누적 테이블, 아니면 daily_ 테이블
+func upsertBucket(tx *sql.Tx, period string, d *Bucket) error {
+	prefix := ""
+	var keyCols []string
+	var keyVals []interface{}
+	if period != "" {
+		prefix = "daily_"
+		keyCols = []string{"period"}
+		keyVals = []interface{}{period}
+	}
+
+	var err error
+	add := func(table, keyCol string, key interface{}, cols []string, vals ...interface{}) {
+		if err != nil {
+			return
+		}
+		kc := append(append([]string{}, keyCols...), keyCol)
+		kv := append(append([]interface{}{}, keyVals...), key)
+		err = upsertAdd(tx, prefix+table, kc, kv, cols, vals)
+	}
+
+	// global_stats는 id=1 단일 행, daily_global_stats는 period별 행
+	globalVals := []interface{}{
+		d.EnhanceAttempts, d.EnhanceSuccess, d.EnhanceFail, d.EnhanceDestroy,
+		d.BattleCount, d.BattleWins, d.UpsetAttempts, d.UpsetWins, d.BattleGold,
+		d.FarmingAttempts, d.SpecialFound, d.SalesCount, d.SalesTotalGold,
+		d.EnhanceCostTotal, d.CycleTimeTotal, d.BattleGoldLost,
+	}
+	if period == "" {
+		err = upsertAdd(tx, "global_stats", []string{"id"}, []interface{}{1}, globalCols, globalVals)
+	} else {
+		err = upsertAdd(tx, "daily_global_stats", keyCols, keyVals, globalCols, globalVals)
+	}
+
+	for level, count := range d.EnhanceByLevel {
+		add("enhance_by_level", "level", level, []string{"count"}, count)
+	}
+	for name, s := range d.SwordBattleStats {
+		add("sword_battle_stats", "name", name, []string{"battle_count", "battle_wins", "upset_attempts", "upset_wins"},
+			s.BattleCount, s.BattleWins, s.UpsetAttempts, s.UpsetWins)
+	}
+	for name, count := range d.SpecialFoundByName {
+		add("special_found_by_name", "name", name, []string{"count"}, count)
+	}
+	for diff, s := range d.UpsetStatsByDiff {
+		add("upset_stats_by_diff", "level_diff", diff, []string{"attempts", "wins", "gold_earned"},
+			s.Attempts, s.Wins, s.GoldEarned)
+	}
+	for key, s := range d.SwordSaleStats {
+		add("sword_sale_stats", "key", key, []string{"total_price", "count"}, s.TotalPrice, s.Count)
+	}
+	for name, s := range d.SwordEnhanceStats {
+		add("sword_enhance_stats", "name", name, []string{"attempts", "success", "fail", "destroy"},
+			s.Attempts, s.Success, s.Fail, s.Destroy)
+	}
+	for name, s := range d.ItemFarmingStats {
+		add("item_farming_stats", "name", name, []string{"total_count", "special_count", "normal_count", "trash_count"},
+			s.TotalCount, s.SpecialCount, s.NormalCount, s.TrashCount)
+	}
+	for level, s := range d.EnhanceLevelDetail {
+		add("enhance_level_detail", "level", level, []string{"attempts", "success", "fail", "destroy"},
+			s.Attempts, s.Success, s.Fail, s.Destroy)
+	}
+	for key, s := range d.BattleMatchups {
+		add("battle_matchups", "key", key, []string{"battles", "wins", "gold_earned", "gold_lost"},
+			s.Battles, s.Wins, s.GoldEarned, s.GoldLost)
+	}
+	return err
+}
+
+// upsertAdd 키 행의 값에 더하기 (행이 없으면 생성)
+func upsertAdd(tx *sql.Tx, table string, keyCols []string, keyVals []interface{}, valCols []string, vals []interface{}) error {
+	cols := append(append([]string{}, keyCols...), valCols...)
+	sets := make([]string, len(valCols))
+	for i, c := range valCols {
+		sets[i] = fmt.Sprintf("%s = COALESCE(%s, 0) + excluded.%s", c, c, c)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT(%s) DO UPDATE SET %s",
+		table, strings.Join(cols, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", "),
+		strings.Join(keyCols, ", "), strings.Join(sets, ", "))
+
+	args := append(append([]interface{}{}, keyVals...), vals...)
+	_, err := tx.Exec(query, args...)
+	return err
+}
+
+// InstallKeys 저장된 설치 키 전체
+func (sl *SQLite) InstallKeys() ([]InstallKey, error) {
+	rows, err := sl.db.Query("SELECT key_id, secret, created_at, revoked_at, app_version, os_type FROM install_keys")
+	if err != nil {
+		return nil, fmt.Errorf("install_keys 로드 실패: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []InstallKey
+	for rows.Next() {
+		var k InstallKey
+		var secretHex string
+		if err := rows.Scan(&k.ID, &secretHex, &k.CreatedAt, &k.RevokedAt, &k.AppVersion, &k.OSType); err != nil {
+			continue
+		}
+		secret, err := hex.DecodeString(secretHex)
+		if err != nil {
+			continue
+		}
+		k.Secret = secret
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// SaveInstallKey 설치 키 저장
+func (sl *SQLite) SaveInstallKey(k InstallKey) error {
+	if _, err := sl.db.Exec(`INSERT INTO install_keys (key_id, secret, created_at, revoked_at, app_version, os_type)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		k.ID, hex.EncodeToString(k.Secret), k.CreatedAt, k.RevokedAt, k.AppVersion, k.OSType); err != nil {
+		return fmt.Errorf("키 저장 실패: %v", err)
+	}
+	return nil
+}
+
+// RevokeInstallKey 설치 키 폐기 시각 기록
+func (sl *SQLite) RevokeInstallKey(keyID string, revokedAt int64) error {
+	if _, err := sl.db.Exec("UPDATE install_keys SET revoked_at=? WHERE key_id=?", revokedAt, keyID); err != nil {
+		return fmt.Errorf("키 폐기 저장 실패: %v", err)
+	}
+	return nil
+}
+
+// QuarantinePayload 의심 페이로드 격리 (페이로드 원문을 JSON으로 보관)
+func (sl *SQLite) QuarantinePayload(q QuarantineEntry) error {
+	data, err := json.Marshal(q.Payload)
+	if err != nil {
+		return fmt.Errorf("격리 페이로드 직렬화 실패: %v", err)
+	}
+
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	if seq := q.Payload.Seq; seq > 0 && sl.quarantined[q.SessionID][seq] {
+		return ErrDuplicate
+	}
+	if _, err := sl.db.Exec(`INSERT INTO quarantine (session_id, period, score, reason, payload, received_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		q.SessionID, q.Period, q.Score, q.Reason, string(data), q.ReceivedAt); err != nil {
+		return fmt.Errorf("격리 저장 실패: %v", err)
+	}
+	sl.markQuarantined(q.SessionID, q.Payload.Seq)
+	return nil
+}
+
+// Quarantined 격리 중인 페이로드
+func (sl *SQLite) Quarantined(sessionID string) ([]QuarantineEntry, error) {
+	query := "SELECT id, session_id, period, score, reason, payload, received_at FROM quarantine"
+	var args []interface{}
+	if sessionID != "" {
+		query += " WHERE session_id=?"
+		args = append(args, sessionID)
+	}
+	rows, err := sl.db.Query(query+" ORDER BY id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("quarantine 로드 실패: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []QuarantineEntry
+	for rows.Next() {
+		var q QuarantineEntry
+		var data string
+		if err := rows.Scan(&q.ID, &q.SessionID, &q.Period, &q.Score, &q.Reason, &data, &q.ReceivedAt); err != nil {
+			return nil, fmt.Errorf("quarantine 로드 실패: %v", err)
+		}
+		if err := json.Unmarshal([]byte(data), &q.Payload); err != nil {
+			log.Printf("⚠️ 격리 페이로드 파싱 실패 (id=%d): %v", q.ID, err)
+			continue
+		}
+		entries = append(entries, q)
+	}
+	return entries, rows.Err()
+}
+
+// RemoveQuarantine 세션의 격리 페이로드 삭제
+func (sl *SQLite) RemoveQuarantine(sessionID string) (int, error) {
+	res, err := sl.db.Exec("DELETE FROM quarantine WHERE session_id=?", sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("격리 삭제 실패: %v", err)
+	}
+	sl.mu.Lock()
+	delete(sl.quarantined, sessionID)
+	sl.mu.Unlock()
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// Close 저장 대기열을 비우고 DB 닫기
+func (sl *SQLite) Close() error {
+	flushErr := sl.stopPersister()
+	if err := sl.db.Close(); err != nil {
+		return err
+	}
+	if flushErr != nil {
+		return fmt.Errorf("종료 전 저장 실패: %v", flushErr)
+	}
+	return nil
+}
+
+// load 전체 누적 + 일별 통계 로드
+func (sl *SQLite) load() error {
+	b := sl.total
+
+	// global_stats 로드 (v3 컬럼 포함)
+	row := sl.db.QueryRow("SELECT enhance_attempts, enhance_success, enhance_fail, enhance_destroy, battle_count, battle_wins, upset_attempts, upset_wins, battle_gold, farming_attempts, special_found, sales_count, sales_total_gold, COALESCE(enhance_cost_total,0), COALESCE(cycle_time_total,0), COALESCE(battle_gold_lost,0) FROM global_stats WHERE id=1")
+	if err := row.Scan(
+		&b.EnhanceAttempts, &b.EnhanceSuccess, &b.EnhanceFail, &b.EnhanceDestroy,
+		&b.BattleCount, &b.BattleWins, &b.UpsetAttempts, &b.UpsetWins, &b.BattleGold,
+		&b.FarmingAttempts, &b.SpecialFound, &b.SalesCount, &b.SalesTotalGold,
+		&b.EnhanceCostTotal, &b.CycleTimeTotal, &b.BattleGoldLost,
+	); err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("global_stats 로드 실패: %v", err)
+	}
+
+	// enhance_by_level 로드
+	rows, err := sl.db.Query("SELECT level, count FROM enhance_by_level")
+	if err != nil {
+		return fmt.Errorf("enhance_by_level 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var level, count int
+		if err := rows.Scan(&level, &count); err == nil {
+			b.EnhanceByLevel[level] = count
+		}
+	}
+
+	// sword_battle_stats 로드
+	rows, err = sl.db.Query("SELECT name, battle_count, battle_wins, upset_attempts, upset_wins FROM sword_battle_stats")
+	if err != nil {
+		return fmt.Errorf("sword_battle_stats 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		s := &SwordBattleStat{}
+		if err := rows.Scan(&name, &s.BattleCount, &s.BattleWins, &s.UpsetAttempts, &s.UpsetWins); err == nil {
+			b.SwordBattleStats[name] = s
+		}
+	}
+
+	// special_found_by_name 로드
+	rows, err = sl.db.Query("SELECT name, count FROM special_found_by_name")
+	if err != nil {
+		return fmt.Errorf("special_found_by_name 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var count int
+		if err := rows.Scan(&name, &count); err == nil {
+			b.SpecialFoundByName[name] = count
+		}
+	}
+
+	// upset_stats_by_diff 로드
+	rows, err = sl.db.Query("SELECT level_diff, attempts, wins, gold_earned FROM upset_stats_by_diff")
+	if err != nil {
+		return fmt.Errorf("upset_stats_by_diff 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var diff int
+		s := &UpsetStat{}
+		if err := rows.Scan(&diff, &s.Attempts, &s.Wins, &s.GoldEarned); err == nil {
+			b.UpsetStatsByDiff[diff] = s
+		}
+	}
+
+	// sword_sale_stats 로드
+	rows, err = sl.db.Query("SELECT key, total_price, count FROM sword_sale_stats")
+	if err != nil {
+		return fmt.Errorf("sword_sale_stats 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		s := &SwordSaleStat{}
+		if err := rows.Scan(&key, &s.TotalPrice, &s.Count); err == nil {
+			b.SwordSaleStats[key] = s
+		}
+	}
+
+	// sword_enhance_stats 로드
+	rows, err = sl.db.Query("SELECT name, attempts, success, fail, destroy FROM sword_enhance_stats")
+	if err != nil {
+		return fmt.Errorf("sword_enhance_stats 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		s := &SwordEnhanceStat{}
+		if err := rows.Scan(&name, &s.Attempts, &s.Success, &s.Fail, &s.Destroy); err == nil {
+			b.SwordEnhanceStats[name] = s
+		}
+	}
+
+	// item_farming_stats 로드
+	rows, err = sl.db.Query("SELECT name, total_count, special_count, normal_count, COALESCE(trash_count,0) FROM item_farming_stats")
+	if err != nil {
+		return fmt.Errorf("item_farming_stats 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		s := &ItemFarmingStat{}
+		if err := rows.Scan(&name, &s.TotalCount, &s.SpecialCount, &s.NormalCount, &s.TrashCount); err == nil {
+			b.ItemFarmingStats[name] = s
+		}
+	}
+
+	// v3: enhance_level_detail 로드
+	rows, err = sl.db.Query("SELECT level, attempts, success, fail, destroy FROM enhance_level_detail")
+	if err != nil {
+		return fmt.Errorf("enhance_level_detail 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var level int
+		s := &EnhanceLevelStat{}
+		if err := rows.Scan(&level, &s.Attempts, &s.Success, &s.Fail, &s.Destroy); err == nil {
+			b.EnhanceLevelDetail[level] = s
+		}
+	}
+
+	// v4: battle_matchups 로드
+	rows, err = sl.db.Query("SELECT key, battles, wins, gold_earned, gold_lost FROM battle_matchups")
+	if err != nil {
+		return fmt.Errorf("battle_matchups 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		s := &MatchupStat{}
+		if err := rows.Scan(&key, &s.Battles, &s.Wins, &s.GoldEarned, &s.GoldLost); err == nil {
+			b.BattleMatchups[key] = s
+		}
+	}
+
+	if err := sl.loadDaily(); err != nil {
+		return err
+	}
+	if err := sl.loadSegments(); err != nil {
+		return err
+	}
+
+	// 일별 중복 거부 횟수 로드
+	rows, err = sl.db.Query("SELECT period, count FROM duplicate_payloads")
+	if err != nil {
+		return fmt.Errorf("duplicate_payloads 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period string
+		var count int
+		if err := rows.Scan(&period, &count); err == nil {
+			sl.dups[period] = count
+		}
+	}
+
+	// 중복 판별용 최근 순번
+	seqs, err := loadRecentSeqs(sl.db, time.Now())
+	if err != nil {
+		return err
+	}
+	sl.seqs = seqs
+
+	// 격리 중인 세션 / 순번
+	rows, err = sl.db.Query("SELECT session_id, COALESCE(json_extract(payload, '$.seq'), 0) FROM quarantine")
+	if err != nil {
+		return fmt.Errorf("quarantine 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sessionID string
+		var seq int64
+		if err := rows.Scan(&sessionID, &seq); err == nil {
+			sl.markQuarantined(sessionID, seq)
+		}
+	}
+
+	log.Printf("📦 DB에서 통계 로드 완료 (일별 %d일)", len(sl.daily))
+	return nil
+}
+
+// writeSnapshot 스냅샷 전체를 DB에 기록 (재구성 등 전체 교체용, 트랜잭션 내)
+func writeSnapshot(tx *sql.Tx, snap Snapshot) error {
+	b := snap.Total
+
+	// 첫 오류만 기록하고 이후 문장은 건너뜀
+	var execErr error
+	exec := func(query string, args ...interface{}) {
+		if execErr == nil {
+			_, execErr = tx.Exec(query, args...)
+		}
+	}
+
+	// global_stats 저장 (v3 컬럼 포함)
+	exec(`UPDATE global_stats SET
+		enhance_attempts=?, enhance_success=?, enhance_fail=?, enhance_destroy=?,
+		battle_count=?, battle_wins=?, upset_attempts=?, upset_wins=?, battle_gold=?,
+		farming_attempts=?, special_found=?, sales_count=?, sales_total_gold=?,
+		enhance_cost_total=?, cycle_time_total=?, battle_gold_lost=?
+		WHERE id=1`,
+		b.EnhanceAttempts, b.EnhanceSuccess, b.EnhanceFail, b.EnhanceDestroy,
+		b.BattleCount, b.BattleWins, b.UpsetAttempts, b.UpsetWins, b.BattleGold,
+		b.FarmingAttempts, b.SpecialFound, b.SalesCount, b.SalesTotalGold,
+		b.EnhanceCostTotal, b.CycleTimeTotal, b.BattleGoldLost,
+	)
+
+	// enhance_by_level 저장
+	for level, count := range b.EnhanceByLevel {
+		exec("INSERT OR REPLACE INTO enhance_by_level (level, count) VALUES (?, ?)", level, count)
+	}
+
+	// sword_battle_stats 저장
+	for name, s := range b.SwordBattleStats {
+		exec("INSERT OR REPLACE INTO sword_battle_stats (name, battle_count, battle_wins, upset_attempts, upset_wins) VALUES (?, ?, ?, ?, ?)",
+			name, s.BattleCount, s.BattleWins, s.UpsetAttempts, s.UpsetWins)
+	}
+
+	// special_found_by_name 저장
+	for name, count := range b.SpecialFoundByName {
+		exec("INSERT OR REPLACE INTO special_found_by_name (name, count) VALUES (?, ?)", name, count)
+	}
+
+	// upset_stats_by_diff 저장
+	for diff, s := range b.UpsetStatsByDiff {
+		exec("INSERT OR REPLACE INTO upset_stats_by_diff (level_diff, attempts, wins, gold_earned) VALUES (?, ?, ?, ?)",
+			diff, s.Attempts, s.Wins, s.GoldEarned)
+	}
+
+	// sword_sale_stats 저장
+	for key, s := range b.SwordSaleStats {
+		exec("INSERT OR REPLACE INTO sword_sale_stats (key, total_price, count) VALUES (?, ?, ?)",
+			key, s.TotalPrice, s.Count)
+	}
+
+	// sword_enhance_stats 저장
+	for name, s := range b.SwordEnhanceStats {
+		exec("INSERT OR REPLACE INTO sword_enhance_stats (name, attempts, success, fail, destroy) VALUES (?, ?, ?, ?, ?)",
+			name, s.Attempts, s.Success, s.Fail, s.Destroy)
+	}
+
+	// item_farming_stats 저장
+	for name, s := range b.ItemFarmingStats {
+		exec("INSERT OR REPLACE INTO item_farming_stats (name, total_count, special_count, normal_count, trash_count) VALUES (?, ?, ?, ?, ?)",
+			name, s.TotalCount, s.SpecialCount, s.NormalCount, s.TrashCount)
+	}
+
+	// v3: enhance_level_detail 저장
+	for lvl, s := range b.EnhanceLevelDetail {
+		exec("INSERT OR REPLACE INTO enhance_level_detail (level, attempts, success, fail, destroy) VALUES (?, ?, ?, ?, ?)",
+			lvl, s.Attempts, s.Success, s.Fail, s.Destroy)
+	}
+
+	// v4: battle_matchups 저장
+	for key, s := range b.BattleMatchups {
+		exec("INSERT OR REPLACE INTO battle_matchups (key, battles, wins, gold_earned, gold_lost) VALUES (?, ?, ?, ?, ?)",
+			key, s.Battles, s.Wins, s.GoldEarned, s.GoldLost)
+	}
+
+	if execErr != nil {
+		return fmt.Errorf("누적 통계 저장 실패: %v", execErr)
+	}
+
+	// 일별 통계 저장
+	for period, b := range snap.Daily {
+		if err := saveDailyBucket(tx, period, b); err != nil {
+			return fmt.Errorf("일별 통계 저장 실패 (%s): %v", period, err)
+		}
+	}
+
+	// 세그먼트 통계 저장
+	for _, sb := range snap.Segments {
+		if err := saveSegment(tx, sb.Period, sb.Segment, sb.Bucket); err != nil {
+			return fmt.Errorf("세그먼트 통계 저장 실패: %v", err)
+		}
+	}
+	return nil
+}
+
+// loadDaily 일별 통계 로드
+func (sl *SQLite) loadDaily() error {
+	// daily_global_stats 로드
+	rows, err := sl.db.Query("SELECT period, enhance_attempts, enhance_success, enhance_fail, enhance_destroy, battle_count, battle_wins, upset_attempts, upset_wins, battle_gold, farming_attempts, special_found, sales_count, sales_total_gold, enhance_cost_total, cycle_time_total, battle_gold_lost FROM daily_global_stats")
+	if err != nil {
+		return fmt.Errorf("daily_global_stats 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period string
+		var g Bucket
+		if err := rows.Scan(&period,
+			&g.EnhanceAttempts, &g.EnhanceSuccess, &g.EnhanceFail, &g.EnhanceDestroy,
+			&g.BattleCount, &g.BattleWins, &g.UpsetAttempts, &g.UpsetWins, &g.BattleGold,
+			&g.FarmingAttempts, &g.SpecialFound, &g.SalesCount, &g.SalesTotalGold,
+			&g.EnhanceCostTotal, &g.CycleTimeTotal, &g.BattleGoldLost,
+		); err == nil {
+			sl.dailyBucket(period).Merge(&g)
+		}
+	}
+
+	// daily_enhance_by_level 로드
+	rows, err = sl.db.Query("SELECT period, level, count FROM daily_enhance_by_level")
+	if err != nil {
+		return fmt.Errorf("daily_enhance_by_level 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period string
+		var level, count int
+		if err := rows.Scan(&period, &level, &count); err == nil {
+			sl.dailyBucket(period).EnhanceByLevel[level] = count
+		}
+	}
+
+	// daily_sword_battle_stats 로드
+	rows, err = sl.db.Query("SELECT period, name, battle_count, battle_wins, upset_attempts, upset_wins FROM daily_sword_battle_stats")
+	if err != nil {
+		return fmt.Errorf("daily_sword_battle_stats 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period, name string
+		s := &SwordBattleStat{}
+		if err := rows.Scan(&period, &name, &s.BattleCount, &s.BattleWins, &s.UpsetAttempts, &s.UpsetWins); err == nil {
+			sl.dailyBucket(period).SwordBattleStats[name] = s
+		}
+	}
+
+	// daily_special_found_by_name 로드
+	rows, err = sl.db.Query("SELECT period, name, count FROM daily_special_found_by_name")
+	if err != nil {
+		return fmt.Errorf("daily_special_found_by_name 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period, name string
+		var count int
+		if err := rows.Scan(&period, &name, &count); err == nil {
+			sl.dailyBucket(period).SpecialFoundByName[name] = count
+		}
+	}
+
+	// daily_upset_stats_by_diff 로드
+	rows, err = sl.db.Query("SELECT period, level_diff, attempts, wins, gold_earned FROM daily_upset_stats_by_diff")
+	if err != nil {
+		return fmt.Errorf("daily_upset_stats_by_diff 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period string
+		var diff int
+		s := &UpsetStat{}
+		if err := rows.Scan(&period, &diff, &s.Attempts, &s.Wins, &s.GoldEarned); err == nil {
+			sl.dailyBucket(period).UpsetStatsByDiff[diff] = s
+		}
+	}
+
+	// daily_sword_sale_stats 로드
+	rows, err = sl.db.Query("SELECT period, key, total_price, count FROM daily_sword_sale_stats")
+	if err != nil {
+		return fmt.Errorf("daily_sword_sale_stats 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period, key string
+		s := &SwordSaleStat{}
+		if err := rows.Scan(&period, &key, &s.TotalPrice, &s.Count); err == nil {
+			sl.dailyBucket(period).SwordSaleStats[key] = s
+		}
+	}
+
+	// daily_sword_enhance_stats 로드
+	rows, err = sl.db.Query("SELECT period, name, attempts, success, fail, destroy FROM daily_sword_enhance_stats")
+	if err != nil {
+		return fmt.Errorf("daily_sword_enhance_stats 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period, name string
+		s := &SwordEnhanceStat{}
+		if err := rows.Scan(&period, &name, &s.Attempts, &s.Success, &s.Fail, &s.Destroy); err == nil {
+			sl.dailyBucket(period).SwordEnhanceStats[name] = s
+		}
+	}
+
+	// daily_item_farming_stats 로드
+	rows, err = sl.db.Query("SELECT period, name, total_count, special_count, normal_count, trash_count FROM daily_item_farming_stats")
+	if err != nil {
+		return fmt.Errorf("daily_item_farming_stats 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period, name string
+		s := &ItemFarmingStat{}
+		if err := rows.Scan(&period, &name, &s.TotalCount, &s.SpecialCount, &s.NormalCount, &s.TrashCount); err == nil {
+			sl.dailyBucket(period).ItemFarmingStats[name] = s
+		}
+	}
+
+	// daily_enhance_level_detail 로드
+	rows, err = sl.db.Query("SELECT period, level, attempts, success, fail, destroy FROM daily_enhance_level_detail")
+	if err != nil {
+		return fmt.Errorf("daily_enhance_level_detail 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period string
+		var level int
+		s := &EnhanceLevelStat{}
+		if err := rows.Scan(&period, &level, &s.Attempts, &s.Success, &s.Fail, &s.Destroy); err == nil {
+			sl.dailyBucket(period).EnhanceLevelDetail[level] = s
+		}
+	}
+
+	// daily_battle_matchups 로드
+	rows, err = sl.db.Query("SELECT period, key, battles, wins, gold_earned, gold_lost FROM daily_battle_matchups")
+	if err != nil {
+		return fmt.Errorf("daily_battle_matchups 로드 실패: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period, key string
+		s := &MatchupStat{}
+		if err := rows.Scan(&period, &key, &s.Battles, &s.Wins, &s.GoldEarned, &s.GoldLost); err == nil {
+			sl.dailyBucket(period).BattleMatchups[key] = s
+		}
+	}
+
+	return nil
+}
+
+// saveDailyBucket 일별 통계 한 건 저장 (트랜잭션 내)
+func saveDailyBucket(tx *sql.Tx, period string, b *Bucket) error {
+	var execErr error
+	exec := func(query string, args ...interface{}) {
+		if execErr == nil {
+			_, execErr = tx.Exec(query, args...)
+		}
+	}
+
+	exec(`INSERT OR REPLACE INTO daily_global_stats (
+		period, enhance_attempts, enhance_success, enhance_fail, enhance_destroy,
+		battle_count, battle_wins, upset_attempts, upset_wins, battle_gold,
+		farming_attempts, special_found, sales_count, sales_total_gold,
+		enhance_cost_total, cycle_time_total, battle_gold_lost
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		period, b.EnhanceAttempts, b.EnhanceSuccess, b.EnhanceFail, b.EnhanceDestroy,
+		b.BattleCount, b.BattleWins, b.UpsetAttempts, b.UpsetWins, b.BattleGold,
+		b.FarmingAttempts, b.SpecialFound, b.SalesCount, b.SalesTotalGold,
+		b.EnhanceCostTotal, b.CycleTimeTotal, b.BattleGoldLost,
+	)
+
+	for level, count := range b.EnhanceByLevel {
+		exec("INSERT OR REPLACE INTO daily_enhance_by_level (period, level, count) VALUES (?, ?, ?)", period, level, count)
+	}
+	for name, s := range b.SwordBattleStats {
+		exec("INSERT OR REPLACE INTO daily_sword_battle_stats (period, name, battle_count, battle_wins, upset_attempts, upset_wins) VALUES (?, ?, ?, ?, ?, ?)",
+			period, name, s.BattleCount, s.BattleWins, s.UpsetAttempts, s.UpsetWins)
+	}
+	for name, count := range b.SpecialFoundByName {
+		exec("INSERT OR REPLACE INTO daily_special_found_by_name (period, name, count) VALUES (?, ?, ?)", period, name, count)
+	}
+	for diff, s := range b.UpsetStatsByDiff {
+		exec("INSERT OR REPLACE INTO daily_upset_stats_by_diff (period, level_diff, attempts, wins, gold_earned) VALUES (?, ?, ?, ?, ?)",
+			period, diff, s.Attempts, s.Wins, s.GoldEarned)
+	}
+	for key, s := range b.SwordSaleStats {
+		exec("INSERT OR REPLACE INTO daily_sword_sale_stats (period, key, total_price, count) VALUES (?, ?, ?, ?)",
+			period, key, s.TotalPrice, s.Count)
+	}
+	for name, s := range b.SwordEnhanceStats {
+		exec("INSERT OR REPLACE INTO daily_sword_enhance_stats (period, name, attempts, success, fail, destroy) VALUES (?, ?, ?, ?, ?, ?)",
+			period, name, s.Attempts, s.Success, s.Fail, s.Destroy)
+	}
+	for name, s := range b.ItemFarmingStats {
+		exec("INSERT OR REPLACE INTO daily_item_farming_stats (period, name, total_count, special_count, normal_count, trash_count) VALUES (?, ?, ?, ?, ?, ?)",
+			period, name, s.TotalCount, s.SpecialCount, s.NormalCount, s.TrashCount)
+	}
+	for lvl, s := range b.EnhanceLevelDetail {
+		exec("INSERT OR REPLACE INTO daily_enhance_level_detail (period, level, attempts, success, fail, destroy) VALUES (?, ?, ?, ?, ?, ?)",
+			period, lvl, s.Attempts, s.Success, s.Fail, s.Destroy)
+	}
+	for key, s := range b.BattleMatchups {
+		exec("INSERT OR REPLACE INTO daily_battle_matchups (period, key, battles, wins, gold_earned, gold_lost) VALUES (?, ?, ?, ?, ?, ?)",
+			period, key, s.Battles, s.Wins, s.GoldEarned, s.GoldLost)
+	}
+	return execErr
+}
@@ -0,0 +1,403 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PeriodLayout 일별 통계 키 형식
+const PeriodLayout = "2006-01-02"
+
+// ErrDuplicate 이미 반영된 (session_id, seq) 페이로드
+var ErrDuplicate = errors.New("중복 페이로드")
+
+// ErrLegacyPayloads 이벤트가 없는 v1-v3 페이로드가 남아 있어 이벤트 재구성 시 통계가 사라짐
+var ErrLegacyPayloads = errors.New("이벤트가 없는 v1-v3 페이로드가 있음")
+
+// Store sword-api 통계 저장소
+type Store interface {
+	// Ingest 페이로드를 전체 누적과 해당 일자 통계에 반영
+	// 이미 반영된 (session_id, seq)면 반영하지 않고 중복 횟수만 늘린 뒤 ErrDuplicate 반환
+	Ingest(p *TelemetryPayload) error
+	// Seen 이미 반영된 (session_id, seq)인지 (메모리만 확인, seq가 0이면 false)
+	Seen(sessionID string, seq int64) bool
+	// Aggregate 조회 기간 통계 복사본 (since가 비어있으면 전체 누적, 아니면 since 이후 일별 합산)
+	Aggregate(since string) *Bucket
+	// Daily since 이후 일별 통계 복사본 (period 오름차순)
+	Daily(since string) []DailyBucket
+	// Snapshot 전체 통계 복사본 (일관된 시점)
+	Snapshot() Snapshot
+	// Duplicates since 이후 일별 중복 거부 횟수 (period → 횟수)
+	Duplicates(since string) map[string]int
+	// Segments since 이후 일자 + 앱 버전/OS/모드별 통계 복사본
+	Segments(since string) []SegmentBucket
+
+	// QuarantinePayload 의심 페이로드를 집계 대신 격리 보관 (이미 격리된 (session_id, seq)면 ErrDuplicate)
+	QuarantinePayload(q QuarantineEntry) error
+	// IsQuarantined 검토 대기 중인 격리 페이로드가 있는 세션인지 (메모리만 확인)
+	IsQuarantined(sessionID string) bool
+	// Quarantined 격리 중인 페이로드 (sessionID가 비어있으면 전체, 수신 순)
+	Quarantined(sessionID string) ([]QuarantineEntry, error)
+	// RemoveQuarantine 세션의 격리 페이로드 삭제 (반환: 삭제 건수)
+	RemoveQuarantine(sessionID string) (int, error)
+
+	// RebuildFromEvents 저장된 개별 이벤트로 집계 재구성 (반환: 이벤트 수, 일수)
+	// force가 아니면 payload_log에 v1-v3 페이로드가 있을 때 ErrLegacyPayloads 반환
+	RebuildFromEvents(force bool) (int, int, error)
+
+	// Sessions 최근 수신 세션 (last_seen 내림차순, limit <= 0이면 전체)
+	Sessions(limit int) ([]SessionInfo, error)
+	// DeleteSession 세션이 반영한 통계를 집계에서 빼고 세션 기록 삭제 (반환: 삭제된 페이로드 수)
+	DeleteSession(sessionID string) (int, error)
+	// ResetTable 통계 테이블 초기화 (전체 누적 + 일별, 이름은 StatTables 참고)
+	ResetTable(name string) error
+	// Recompute 반영된 페이로드 원문으로 집계 전체 재계산 (반환: 페이로드 수)
+	Recompute() (int, error)
+
+	// Overrides 관리자 기본값 오버라이드 (이름 → JSON)
+	Overrides() (map[string][]byte, error)
+	// SetOverride 기본값 오버라이드 저장 (value가 nil이면 삭제)
+	SetOverride(name string, value []byte) error
+
+	// AppendAudit 관리자 작업 기록
+	AppendAudit(e AuditEntry) error
+	// AuditLog 최근 관리자 작업 (최신순, limit <= 0이면 전체)
+	AuditLog(limit int) ([]AuditEntry, error)
+
+	// InstallKeys 저장된 설치 키 전체
+	InstallKeys() ([]InstallKey, error)
+	// SaveInstallKey 설치 키 저장
+	SaveInstallKey(k InstallKey) error
+	// RevokeInstallKey 설치 키 폐기 시각 기록
+	RevokeInstallKey(keyID string, revokedAt int64) error
+
+	// Close 저장소 닫기
+	Close() error
+}
+
+// DailyBucket 일자별 통계
+type DailyBucket struct {
+	Period string
+	*Bucket
+}
+
+// Snapshot 전체 누적 + 일별 + 세그먼트 통계
+type Snapshot struct {
+	Total    *Bucket
+	Daily    map[string]*Bucket
+	Segments []SegmentBucket
+}
+
+// InstallKey 설치별 키
+type InstallKey struct {
+	ID         string
+	Secret     []byte
+	CreatedAt  int64
+	RevokedAt  int64 // 0 = 유효
+	AppVersion string
+	OSType     string
+}
+
+// QuarantineEntry 격리된 페이로드
+type QuarantineEntry struct {
+	ID         int64            `json:"id"`
+	SessionID  string           `json:"session_id"`
+	Period     string           `json:"period"`
+	Score      float64          `json:"score"`
+	Reason     string           `json:"reason"`
+	ReceivedAt int64            `json:"received_at"`
+	Payload    TelemetryPayload `json:"payload"`
+}
+
+// NormalizePeriod 페이로드 period를 일 단위 키로 정규화
+// 형식이 잘못된 경우 서버 기준 오늘 날짜 사용
+func NormalizePeriod(period string) string {
+	if t, err := time.Parse(PeriodLayout, period); err == nil {
+		return t.Format(PeriodLayout)
+	}
+	return time.Now().Format(PeriodLayout)
+}
+
+// ========================
+// 인메모리 저장소
+// ========================
+
+// Memory 인메모리 저장소 (테스트용, DB 없이 동작할 때 사용)
+type Memory struct {
+	mu    sync.RWMutex
+	total *Bucket
+	daily map[string]*Bucket // period(YYYY-MM-DD) → 일별 통계
+	keys  map[string]InstallKey
+	seqs  *recentSeqs    // 최근 반영된 (session_id, seq)
+	dups  map[string]int // period → 중복 거부 횟수
+
+	segments map[string]map[Segment]*Bucket // period → 세그먼트 → 일별 통계
+
+	quarantine  []QuarantineEntry
+	nextQID     int64
+	quarantined map[string]map[int64]bool // 격리 중인 세션 → 격리된 순번 (DB 조회 없이 격리 여부 / 중복 판별)
+
+	payloadLog []loggedPayload // 반영된 페이로드 원문 (수신 순)
+	sessions   map[string]*SessionInfo
+	overrides  map[string][]byte
+	audit      []AuditEntry
+}
+
+// seqKey 페이로드 중복 판별 키
+type seqKey struct {
+	sessionID string
+	seq       int64
+}
+
+// NewMemory 빈 인메모리 저장소 생성
+func NewMemory() *Memory {
+	return &Memory{
+		total: NewBucket(),
+		daily: make(map[string]*Bucket),
+		keys:  make(map[string]InstallKey),
+		seqs:  newRecentSeqs(),
+		dups:  make(map[string]int),
+
+		segments: make(map[string]map[Segment]*Bucket),
+
+		quarantined: make(map[string]map[int64]bool),
+
+		sessions:  make(map[string]*SessionInfo),
+		overrides: make(map[string][]byte),
+	}
+}
+
+// Ingest 페이로드 반영 (seq가 있으면 중복 검사)
+func (m *Memory) Ingest(p *TelemetryPayload) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().Unix()
+	if p.Seq > 0 {
+		k := seqKey{p.SessionID, p.Seq}
+		if m.seqs.has(k) {
+			m.dups[NormalizePeriod(p.Period)]++
+			return ErrDuplicate
+		}
+		m.seqs.add(k, now)
+	}
+	m.record(p)
+	m.logPayload(p, now)
+	return nil
+}
+
+// Seen 이미 반영된 (session_id, seq)인지
+func (m *Memory) Seen(sessionID string, seq int64) bool {
+	if seq <= 0 {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.seqs.has(seqKey{sessionID, seq})
+}
+
+// record 페이로드를 전체 누적, 해당 일자, 세그먼트 버킷에 반영 (호출자가 Lock 보유)
+func (m *Memory) record(p *TelemetryPayload) {
+	period := NormalizePeriod(p.Period)
+	m.total.Add(p)
+	m.dailyBucket(period).Add(p)
+	m.segmentBucket(period, SegmentOf(p)).Add(p)
+}
+
+// dailyBucket 일별 버킷 조회 (없으면 생성, 호출자가 Lock 보유)
+func (m *Memory) dailyBucket(period string) *Bucket {
+	b := m.daily[period]
+	if b == nil {
+		b = NewBucket()
+		m.daily[period] = b
+	}
+	return b
+}
+
+// Aggregate 조회 기간 통계 복사본
+func (m *Memory) Aggregate(since string) *Bucket {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if since == "" {
+		return m.total.Clone()
+	}
+	merged := NewBucket()
+	for period, b := range m.daily {
+		if period >= since {
+			merged.Merge(b)
+		}
+	}
+	return merged
+}
+
+// Daily since 이후 일별 통계 복사본
+func (m *Memory) Daily(since string) []DailyBucket {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	periods := make([]string, 0, len(m.daily))
+	for period := range m.daily {
+		if period >= since {
+			periods = append(periods, period)
+		}
+	}
+	sort.Strings(periods)
+
+	days := make([]DailyBucket, 0, len(periods))
+	for _, period := range periods {
+		days = append(days, DailyBucket{Period: period, Bucket: m.daily[period].Clone()})
+	}
+	return days
+}
+
+// Snapshot 전체 통계 복사본
+func (m *Memory) Snapshot() Snapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snap := Snapshot{
+		Total:    m.total.Clone(),
+		Daily:    make(map[string]*Bucket, len(m.daily)),
+		Segments: m.segmentsSince(""),
+	}
+	for period, b := range m.daily {
+		snap.Daily[period] = b.Clone()
+	}
+	return snap
+}
+
+// Duplicates since 이후 일별 중복 거부 횟수
+func (m *Memory) Duplicates(since string) map[string]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dups := make(map[string]int)
+	for period, n := range m.dups {
+		if period >= since {
+			dups[period] = n
+		}
+	}
+	return dups
+}
+
+// replace 전체 통계를 새로 계산한 통계로 교체 (재구성용)
+func (m *Memory) replace(fresh *Memory) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.total = fresh.total
+	m.daily = fresh.daily
+	m.segments = fresh.segments
+}
+
+// QuarantinePayload 의심 페이로드 격리
+func (m *Memory) QuarantinePayload(q QuarantineEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.markQuarantined(q.SessionID, q.Payload.Seq) {
+		return ErrDuplicate
+	}
+	m.nextQID++
+	q.ID = m.nextQID
+	m.quarantine = append(m.quarantine, q)
+	return nil
+}
+
+// Quarantined 격리 중인 페이로드
+func (m *Memory) Quarantined(sessionID string) ([]QuarantineEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []QuarantineEntry
+	for _, q := range m.quarantine {
+		if sessionID == "" || q.SessionID == sessionID {
+			entries = append(entries, q)
+		}
+	}
+	return entries, nil
+}
+
+// IsQuarantined 검토 대기 중인 격리 페이로드가 있는 세션인지
+func (m *Memory) IsQuarantined(sessionID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.quarantined[sessionID]) > 0
+}
+
+// markQuarantined 격리된 순번 기록 (호출자가 Lock 보유, 반환: 이미 격리된 순번이면 false)
+// 순번 없는 구 클라이언트 페이로드는 중복 검사 없이 세션만 기록
+func (m *Memory) markQuarantined(sessionID string, seq int64) bool {
+	seqs := m.quarantined[sessionID]
+	if seqs == nil {
+		seqs = make(map[int64]bool)
+		m.quarantined[sessionID] = seqs
+	}
+	if seq > 0 && seqs[seq] {
+		return false
+	}
+	seqs[seq] = true
+	return true
+}
+
+// RemoveQuarantine 세션의 격리 페이로드 삭제
+func (m *Memory) RemoveQuarantine(sessionID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.quarantined, sessionID)
+
+	kept := m.quarantine[:0]
+	for _, q := range m.quarantine {
+		if q.SessionID != sessionID {
+			kept = append(kept, q)
+		}
+	}
+	removed := len(m.quarantine) - len(kept)
+	m.quarantine = kept
+	return removed, nil
+}
+
+// RebuildFromEvents 인메모리 저장소는 개별 이벤트를 보관하지 않음
+func (m *Memory) RebuildFromEvents(force bool) (int, int, error) {
+	return 0, 0, fmt.Errorf("인메모리 저장소는 이벤트를 보관하지 않음")
+}
+
+// InstallKeys 저장된 설치 키 전체
+func (m *Memory) InstallKeys() ([]InstallKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]InstallKey, 0, len(m.keys))
+	for _, k := range m.keys {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// SaveInstallKey 설치 키 저장
+func (m *Memory) SaveInstallKey(k InstallKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[k.ID] = k
+	return nil
+}
+
+// RevokeInstallKey 설치 키 폐기 시각 기록
+func (m *Memory) RevokeInstallKey(keyID string, revokedAt int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k, ok := m.keys[keyID]
+	if !ok {
+		return fmt.Errorf("키 없음: %s", keyID)
+	}
+	k.RevokedAt = revokedAt
+	m.keys[keyID] = k
+	return nil
+}
+
+// Close 인메모리 저장소는 정리할 것이 없음
+func (m *Memory) Close() error {
+	return nil
+}
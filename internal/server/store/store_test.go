@@ -0,0 +1,552 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testPayloads() []*TelemetryPayload {
+	return []*TelemetryPayload{
+		{
+			SchemaVersion: 3,
+			SessionID:     "session-a",
+			Period:        "2026-01-01",
+			AppVersion:    "2.5.0",
+			OSType:        "windows",
+			Stats: TelemetryStats{
+				EnhanceAttempts: 4, EnhanceSuccess: 2, EnhanceFail: 1, EnhanceDestroy: 1,
+				EnhanceByLevel:     map[int]int{3: 2},
+				BattleCount:        2,
+				BattleWins:         1,
+				SpecialFoundByName: map[string]int{"용검": 1},
+				UpsetStatsByDiff:   map[int]*UpsetStat{2: {Attempts: 2, Wins: 1, GoldEarned: 500}},
+				SwordSaleStats:     map[string]*SwordSaleStat{"normal_10": {TotalPrice: 300000, Count: 1}},
+				EnhanceLevelDetail: map[int]*EnhanceLevelStat{3: {Attempts: 4, Success: 2, Fail: 1, Destroy: 1}},
+				CycleTimeTotal:     12.5,
+			},
+		},
+		{
+			SchemaVersion: 4,
+			SessionID:     "session-b",
+			Period:        "2026-01-02",
+			AppVersion:    "2.5.1",
+			OSType:        "darwin",
+			Mode:          "enhance",
+			Stats: TelemetryStats{
+				EnhanceAttempts:    2,
+				EnhanceSuccess:     2,
+				EnhanceByLevel:     map[int]int{3: 1, 4: 1},
+				SpecialFoundByName: map[string]int{"용검": 2, "불꽃검": 1},
+				ItemFarmingStats:   map[string]*ItemFarmingStat{"불꽃검": {TotalCount: 3, SpecialCount: 1, NormalCount: 2}},
+				EnhanceLevelDetail: map[int]*EnhanceLevelStat{3: {Attempts: 1, Success: 1}, 4: {Attempts: 1, Success: 1}},
+				BattleMatchups:     map[string]*MatchupStat{"special_10_12": {Battles: 2, Wins: 1, GoldEarned: 4000, GoldLost: 1500}},
+			},
+		},
+		{
+			// v1 페이로드: 맵 통계는 집계하지 않음
+			SchemaVersion: 1,
+			SessionID:     "session-c",
+			Period:        "2026-01-02",
+			Stats: TelemetryStats{
+				SalesCount:         1,
+				SalesTotalGold:     1000,
+				SpecialFoundByName: map[string]int{"무시됨": 5},
+			},
+		},
+	}
+}
+
+func TestSQLiteIncrementalMatchesMemory(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "stats.db")
+
+	mem := NewMemory()
+	sl, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range testPayloads() {
+		if err := mem.Ingest(p); err != nil {
+			t.Fatal(err)
+		}
+		if err := sl.Ingest(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(sl.Snapshot(), mem.Snapshot()) {
+		t.Fatal("SQLite in-memory view differs from Memory store")
+	}
+	if err := sl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 다시 열었을 때 증분 저장된 값이 그대로 복원되어야 함
+	reopened, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	got, want := reopened.Snapshot(), mem.Snapshot()
+	if !reflect.DeepEqual(got.Total, want.Total) {
+		t.Errorf("reopened total = %+v, want %+v", got.Total, want.Total)
+	}
+	if !reflect.DeepEqual(got.Daily, want.Daily) {
+		t.Errorf("reopened daily differs: got %d days, want %d", len(got.Daily), len(want.Daily))
+	}
+	if !reflect.DeepEqual(got.Segments, want.Segments) {
+		t.Errorf("reopened segments differ: got %d, want %d", len(got.Segments), len(want.Segments))
+	}
+	if segs := reopened.Segments("2026-01-02"); len(segs) != 2 || segs[1].AppVersion != "2.5.1" || segs[1].EnhanceAttempts != 2 {
+		t.Errorf("segments since 2026-01-02 = %+v, want session-c and 2.5.1 buckets", segs)
+	}
+	if m := got.Total.BattleMatchups["special_10_12"]; m == nil || m.Battles != 2 || got.Daily["2026-01-02"].BattleMatchups["special_10_12"] == nil {
+		t.Errorf("reopened battle matchups = %+v, want special_10_12 in total and daily", got.Total.BattleMatchups)
+	}
+	if got.Total.SpecialFoundByName["무시됨"] != 0 {
+		t.Error("v1 payload map stats should not be stored")
+	}
+}
+
+func TestSQLiteRejectsDuplicateSeq(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "seq.db")
+	sl, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &TelemetryPayload{SchemaVersion: 3, SessionID: "session-a", Period: "2026-01-01", Seq: 1,
+		Stats: TelemetryStats{EnhanceAttempts: 1, EnhanceSuccess: 1}}
+	if err := sl.Ingest(p); err != nil {
+		t.Fatal(err)
+	}
+	sl.Close()
+
+	// 다시 연 뒤에도 같은 순번은 중복
+	reopened, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if err := reopened.Ingest(p); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("second ingest err = %v, want ErrDuplicate", err)
+	}
+	if got := reopened.Aggregate("").EnhanceAttempts; got != 1 {
+		t.Errorf("enhance attempts = %d, want 1", got)
+	}
+	if got := reopened.Duplicates("")["2026-01-01"]; got != 1 {
+		t.Errorf("duplicates = %d, want 1", got)
+	}
+
+	// 순번 없는 구 클라이언트 페이로드는 검사하지 않음
+	legacy := *p
+	legacy.Seq = 0
+	if err := reopened.Ingest(&legacy); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Ingest(&legacy); err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Aggregate("").EnhanceAttempts; got != 3 {
+		t.Errorf("enhance attempts with legacy payloads = %d, want 3", got)
+	}
+}
+
+func TestSQLiteBatchedFlush(t *testing.T) {
+	sl, err := OpenSQLite(filepath.Join(t.TempDir(), "batch.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sl.Close()
+
+	p := &TelemetryPayload{SchemaVersion: 3, SessionID: "session-a", Period: "2026-01-01", Seq: 1,
+		Stats: TelemetryStats{BattleCount: 2}}
+	if err := sl.Ingest(p); err != nil {
+		t.Fatal(err)
+	}
+	// 아직 저장 전이어도 대기열에 있는 순번은 중복
+	if err := sl.Ingest(p); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("second ingest err = %v, want ErrDuplicate", err)
+	}
+	if got := sl.Aggregate("").BattleCount; got != 2 {
+		t.Errorf("in-memory battle count = %d, want 2", got)
+	}
+
+	rows := func() int {
+		var n int
+		if err := sl.db.QueryRow("SELECT COUNT(*) FROM payload_log").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := rows(); n != 0 {
+		t.Fatalf("payload_log before flush = %d, want 0", n)
+	}
+	if err := sl.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := rows(); n != 1 {
+		t.Errorf("payload_log after flush = %d, want 1", n)
+	}
+	var battles, dups int
+	sl.db.QueryRow("SELECT battle_count FROM global_stats WHERE id=1").Scan(&battles)
+	sl.db.QueryRow("SELECT count FROM duplicate_payloads WHERE period='2026-01-01'").Scan(&dups)
+	if battles != 2 || dups != 1 {
+		t.Errorf("stored battles = %d, duplicates = %d; want 2, 1", battles, dups)
+	}
+}
+
+func TestAggregateSince(t *testing.T) {
+	m := NewMemory()
+	for _, p := range testPayloads() {
+		m.Ingest(p)
+	}
+
+	if got := m.Aggregate("").EnhanceAttempts; got != 6 {
+		t.Errorf("total enhance attempts = %d, want 6", got)
+	}
+	since := m.Aggregate("2026-01-02")
+	if since.EnhanceAttempts != 2 || since.SalesCount != 1 {
+		t.Errorf("since 2026-01-02 = %d attempts / %d sales, want 2 / 1", since.EnhanceAttempts, since.SalesCount)
+	}
+
+	// 반환값은 복사본이어야 함
+	since.SpecialFoundByName["용검"] = 100
+	if m.Aggregate("2026-01-02").SpecialFoundByName["용검"] != 2 {
+		t.Error("Aggregate result aliases store state")
+	}
+
+	days := m.Daily("2026-01-01")
+	if len(days) != 2 || days[0].Period != "2026-01-01" || days[1].Period != "2026-01-02" {
+		t.Errorf("daily periods = %v", days)
+	}
+}
+
+func TestInstallKeysRoundTrip(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "keys.db")
+	sl, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := InstallKey{ID: "k_test", Secret: []byte{1, 2, 3}, CreatedAt: 100, AppVersion: "test", OSType: "linux"}
+	if err := sl.SaveInstallKey(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := sl.RevokeInstallKey("k_test", 200); err != nil {
+		t.Fatal(err)
+	}
+	sl.Close()
+
+	reopened, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	keys, err := reopened.InstallKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.RevokedAt = 200
+	if len(keys) != 1 || !reflect.DeepEqual(keys[0], key) {
+		t.Errorf("keys = %+v, want [%+v]", keys, key)
+	}
+}
+
+func TestMigrateAdoptsLegacyDB(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "legacy.db")
+
+	// schema_migrations 도입 전 스키마: 기본 테이블 + v3 컬럼 일부만 있음
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := append([]string{}, migrations[0].Statements...)
+	legacy = append(legacy,
+		`ALTER TABLE global_stats ADD COLUMN enhance_cost_total INTEGER DEFAULT 0`,
+		`UPDATE global_stats SET enhance_attempts = 7 WHERE id = 1`,
+	)
+	for _, stmt := range legacy {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	plan, err := PlanMigrations(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != len(migrations) {
+		t.Fatalf("planned %d migrations, want %d", len(plan), len(migrations))
+	}
+	if !plan[1].Skipped[0] {
+		t.Error("existing enhance_cost_total column should be skipped")
+	}
+
+	applied, err := MigrateSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrations) {
+		t.Errorf("applied %d migrations, want %d", len(applied), len(migrations))
+	}
+	if again, err := MigrateSQLite(dbPath); err != nil || len(again) != 0 {
+		t.Errorf("second migrate = %d steps, %v; want none", len(again), err)
+	}
+
+	sl, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sl.Close()
+	if got := sl.Aggregate("").EnhanceAttempts; got != 7 {
+		t.Errorf("enhance attempts = %d, want 7", got)
+	}
+}
+
+func TestSQLiteQuarantineRoundTrip(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "quarantine.db")
+	sl, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := testPayloads()[0]
+	p.Seq = 3
+	if err := sl.QuarantinePayload(QuarantineEntry{SessionID: p.SessionID, Period: p.Period, Score: 42, Reason: "test", ReceivedAt: 100, Payload: *p}); err != nil {
+		t.Fatal(err)
+	}
+	sl.Close()
+
+	reopened, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	entries, err := reopened.Quarantined(p.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Score != 42 || !reflect.DeepEqual(entries[0].Payload, *p) {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if got := reopened.Aggregate("").EnhanceAttempts; got != 0 {
+		t.Errorf("quarantined payload aggregated: %d", got)
+	}
+
+	// 격리 세션 / 순번은 다시 연 뒤에도 DB 조회 없이 확인
+	if !reopened.IsQuarantined(p.SessionID) || reopened.IsQuarantined("session-b") {
+		t.Error("quarantined sessions not restored")
+	}
+	if err := reopened.QuarantinePayload(QuarantineEntry{SessionID: p.SessionID, Payload: *p}); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("re-quarantine err = %v, want ErrDuplicate", err)
+	}
+	if n, err := reopened.RemoveQuarantine(p.SessionID); err != nil || n != 1 {
+		t.Errorf("remove = %d, %v; want 1", n, err)
+	}
+	if reopened.IsQuarantined(p.SessionID) {
+		t.Error("session still quarantined after remove")
+	}
+}
+
+func TestDeleteSessionAndRecompute(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "admin.db")
+	sl, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewMemory()
+	for _, p := range testPayloads() {
+		if err := sl.Ingest(p); err != nil {
+			t.Fatal(err)
+		}
+		if p.SessionID != "session-b" {
+			want.Ingest(p)
+		}
+	}
+
+	n, err := sl.DeleteSession("session-b")
+	if err != nil || n != 1 {
+		t.Fatalf("delete = %d, %v; want 1", n, err)
+	}
+	got := sl.Aggregate("")
+	if got.EnhanceAttempts != 4 || got.SpecialFoundByName["용검"] != 1 || got.SpecialFoundByName["불꽃검"] != 0 {
+		t.Errorf("after delete: %d attempts, 용검 %d, 불꽃검 %d; want 4, 1, 0",
+			got.EnhanceAttempts, got.SpecialFoundByName["용검"], got.SpecialFoundByName["불꽃검"])
+	}
+	if sessions, _ := sl.Sessions(0); len(sessions) != 2 {
+		t.Errorf("sessions after delete = %d, want 2", len(sessions))
+	}
+	sl.Close()
+
+	// 차감도 DB에 증분 반영되고, 재계산 결과는 남은 페이로드만 반영한 것과 같아야 함
+	reopened, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if got := reopened.Aggregate("").EnhanceAttempts; got != 4 {
+		t.Errorf("reopened attempts = %d, want 4", got)
+	}
+	if n, err := reopened.Recompute(); err != nil || n != 2 {
+		t.Fatalf("recompute = %d, %v; want 2", n, err)
+	}
+	if !reflect.DeepEqual(reopened.Snapshot(), want.Snapshot()) {
+		t.Error("recomputed aggregates differ from remaining payloads")
+	}
+}
+
+func TestRebuildFromEvents(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "rebuild.db")
+	sl, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []TelemetryEvent{
+		{Type: "enhance", Level: 3, ItemType: "normal", Result: "success"},
+		{Type: "enhance", Level: 4, ItemType: "normal", Result: "destroy"},
+		{Type: "farm", ItemType: "special", Result: "found"},
+		{Type: "sale", Level: 10, ItemType: "normal", Result: "sold", GoldDelta: 300000},
+		{Type: "battle", Level: 9, TargetLevel: 11, ItemType: "normal", Result: "win", GoldDelta: 5000},
+	}
+	v4 := &TelemetryPayload{SchemaVersion: 4, SessionID: "session-v4", Period: "2026-01-03", AppVersion: "2.6.0",
+		OSType: "windows", Mode: "enhance", Stats: EventsToStats(events)}
+	v4.Stats.Events = events
+	for _, p := range append(testPayloads(), v4) {
+		if err := sl.Ingest(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := sl.Snapshot()
+
+	// v1-v3 페이로드가 남아 있으면 --force 없이는 거부하고 집계 유지
+	if _, _, err := sl.RebuildFromEvents(false); !errors.Is(err, ErrLegacyPayloads) {
+		t.Fatalf("rebuild without force err = %v, want ErrLegacyPayloads", err)
+	}
+	if !reflect.DeepEqual(sl.Snapshot(), before) {
+		t.Error("refused rebuild changed aggregates")
+	}
+
+	// 초기화 도중 실패하면 트랜잭션 전체 롤백
+	if _, err := sl.db.Exec("DROP TABLE daily_battle_matchups"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := sl.RebuildFromEvents(true); err == nil {
+		t.Fatal("rebuild with a missing table succeeded")
+	}
+	var levels int
+	if err := sl.db.QueryRow("SELECT COUNT(*) FROM enhance_by_level").Scan(&levels); err != nil || levels == 0 {
+		t.Errorf("enhance_by_level rows after failed rebuild = %d (%v), want kept", levels, err)
+	}
+	if !reflect.DeepEqual(sl.Snapshot(), before) {
+		t.Error("failed rebuild changed in-memory aggregates")
+	}
+	if _, err := sl.db.Exec("CREATE TABLE daily_battle_matchups (period TEXT, key TEXT, battles INTEGER DEFAULT 0, wins INTEGER DEFAULT 0, gold_earned INTEGER DEFAULT 0, gold_lost INTEGER DEFAULT 0, PRIMARY KEY (period, key))"); err != nil {
+		t.Fatal(err)
+	}
+
+	n, days, err := sl.RebuildFromEvents(true)
+	if err != nil || n != len(events) || days != 1 {
+		t.Fatalf("rebuild = %d events, %d days, %v; want %d, 1", n, days, err, len(events))
+	}
+	got := sl.Aggregate("")
+	if got.EnhanceAttempts != 2 || got.EnhanceDestroy != 1 || got.SpecialFound != 1 || got.SalesCount != 1 || got.BattleCount != 1 {
+		t.Errorf("rebuilt totals = %+v; want only the v4 events", got)
+	}
+	if m := got.BattleMatchups[MatchupKey("normal", 9, 11)]; m == nil || m.Battles != 1 || m.Wins != 1 || m.GoldEarned != 5000 {
+		t.Errorf("rebuilt matchup = %+v, want 1 win for +5000G", m)
+	}
+	want := sl.Snapshot()
+	sl.Close()
+
+	reopened, err := OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if !reflect.DeepEqual(reopened.Snapshot(), want) {
+		t.Error("reopened aggregates differ from rebuilt aggregates")
+	}
+}
+
+func TestRecentSeqsPrune(t *testing.T) {
+	r := newRecentSeqs()
+	day := int64(24 * 60 * 60)
+	old, recent := seqKey{"session-a", 1}, seqKey{"session-a", 2}
+	r.add(old, 0)
+	r.add(recent, 5*day)
+	if !r.has(old) || !r.has(recent) {
+		t.Fatal("seqs inside the window were dropped")
+	}
+
+	// 기간(8일)이 지난 순번만 제거
+	r.prune(9 * day)
+	if r.has(old) {
+		t.Error("expired seq kept")
+	}
+	if !r.has(recent) || len(r.order) != 1 {
+		t.Errorf("recent seq dropped (order=%v)", r.order)
+	}
+}
+
+func TestPayloadRetention(t *testing.T) {
+	sl, err := OpenSQLite(filepath.Join(t.TempDir(), "retention.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sl.Close()
+
+	old := time.Now().Add(-PayloadRetention - time.Hour).Unix()
+	if _, err := sl.db.Exec("INSERT INTO payload_log (session_id, period, payload, received_at) VALUES ('session-old', '2025-01-01', '{}', ?)", old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sl.db.Exec("INSERT INTO payload_seqs (session_id, seq, received_at) VALUES ('session-old', 1, ?)", old); err != nil {
+		t.Fatal(err)
+	}
+	p := &TelemetryPayload{SchemaVersion: 3, SessionID: "session-new", Period: "2026-01-01", Seq: 1,
+		Stats: TelemetryStats{BattleCount: 1}}
+	if err := sl.Ingest(p); err != nil {
+		t.Fatal(err)
+	}
+
+	sl.persist.flushMu.Lock()
+	sl.persist.lastPrune = time.Time{}
+	sl.persist.flushMu.Unlock()
+	if err := sl.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []string{"payload_log", "payload_seqs"} {
+		var sessions []string
+		rows, err := sl.db.Query("SELECT session_id FROM " + table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+			var s string
+			rows.Scan(&s)
+			sessions = append(sessions, s)
+		}
+		rows.Close()
+		if !reflect.DeepEqual(sessions, []string{"session-new"}) {
+			t.Errorf("%s sessions = %v, want only session-new", table, sessions)
+		}
+	}
+
+	// 인메모리 원문은 보관 기간 / 최대 개수로 제한
+	m := NewMemory()
+	m.logPayload(&TelemetryPayload{SessionID: "session-old"}, old)
+	now := time.Now().Unix()
+	for i := 0; i < maxMemoryPayloads+1; i++ {
+		m.logPayload(&TelemetryPayload{SessionID: "session-new"}, now)
+	}
+	if len(m.payloadLog) != maxMemoryPayloads || m.payloadLog[0].payload.SessionID != "session-new" {
+		t.Errorf("memory payload log = %d entries (first %s), want %d new entries",
+			len(m.payloadLog), m.payloadLog[0].payload.SessionID, maxMemoryPayloads)
+	}
+}
@@ -0,0 +1,162 @@
+// Package store sword-api 통계 저장소 (인메모리 / SQLite)
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ========================
+// 텔레메트리 구조체
+// ========================
+
+// TelemetryStats 페이로드 통계 (v1~v4)
+type TelemetryStats struct {
+	TotalCycles      int         `json:"total_cycles"`
+	SuccessfulCycles int         `json:"successful_cycles"`
+	FailedCycles     int         `json:"failed_cycles"`
+	TotalGoldMined   int         `json:"total_gold_mined"`
+	TotalSwordsFound int         `json:"total_swords_found"`
+	SessionDuration  int         `json:"session_duration_sec"`
+	EnhanceAttempts  int         `json:"enhance_attempts"`
+	EnhanceSuccess   int         `json:"enhance_success"`
+	EnhanceFail      int         `json:"enhance_fail"`
+	EnhanceDestroy   int         `json:"enhance_destroy"`
+	EnhanceByLevel   map[int]int `json:"enhance_by_level,omitempty"`
+	BattleCount      int         `json:"battle_count"`
+	BattleWins       int         `json:"battle_wins"`
+	BattleLosses     int         `json:"battle_losses"`
+	BattleGoldEarned int         `json:"battle_gold_earned"`
+	UpsetWins        int         `json:"upset_wins"`
+	UpsetAttempts    int         `json:"upset_attempts"`
+	SalesCount       int         `json:"sales_count"`
+	SalesTotalGold   int         `json:"sales_total_gold"`
+	SalesMaxPrice    int         `json:"sales_max_price"`
+	FarmingAttempts  int         `json:"farming_attempts"`
+	SpecialFound     int         `json:"special_found"`
+	TrashFound       int         `json:"trash_found"`
+
+	// === v2 새로 추가 ===
+	SwordBattleStats   map[string]*SwordBattleStat  `json:"sword_battle_stats,omitempty"`
+	SpecialFoundByName map[string]int               `json:"special_found_by_name,omitempty"`
+	UpsetStatsByDiff   map[int]*UpsetStat           `json:"upset_stats_by_diff,omitempty"`
+	SwordSaleStats     map[string]*SwordSaleStat    `json:"sword_sale_stats,omitempty"`
+	SwordEnhanceStats  map[string]*SwordEnhanceStat `json:"sword_enhance_stats,omitempty"`
+	ItemFarmingStats   map[string]*ItemFarmingStat  `json:"item_farming_stats,omitempty"`
+
+	// === v3 새로 추가 ===
+	EnhanceLevelDetail map[int]*EnhanceLevelStat `json:"enhance_level_detail,omitempty"`
+	EnhanceCostTotal   int                       `json:"enhance_cost_total"`
+	CycleTimeTotal     float64                   `json:"cycle_time_total"`
+	BattleGoldLost     int                       `json:"battle_gold_lost"`
+
+	// === v4 새로 추가 ===
+	Events         []TelemetryEvent        `json:"events,omitempty"`
+	EventsDropped  int                     `json:"events_dropped,omitempty"`
+	BattleMatchups map[string]*MatchupStat `json:"battle_matchups,omitempty"`
+	StateResyncs   int                     `json:"state_resyncs,omitempty"`
+	StateDrifts    int                     `json:"state_drifts,omitempty"`
+}
+
+// === v2 구조체들 ===
+
+// SwordBattleStat 검 종류별 배틀 통계
+type SwordBattleStat struct {
+	BattleCount   int `json:"battle_count"`
+	BattleWins    int `json:"battle_wins"`
+	UpsetAttempts int `json:"upset_attempts"`
+	UpsetWins     int `json:"upset_wins"`
+}
+
+// UpsetStat 레벨차별 역배 통계
+type UpsetStat struct {
+	Attempts   int `json:"attempts"`
+	Wins       int `json:"wins"`
+	GoldEarned int `json:"gold_earned"`
+}
+
+// SwordSaleStat 검 종류별 판매 통계
+type SwordSaleStat struct {
+	TotalPrice int `json:"total_price"`
+	Count      int `json:"count"`
+}
+
+// SwordEnhanceStat 검 종류별 강화 통계
+type SwordEnhanceStat struct {
+	Attempts int `json:"attempts"`
+	Success  int `json:"success"`
+	Fail     int `json:"fail"`
+	Destroy  int `json:"destroy"`
+}
+
+// ItemFarmingStat 아이템별 파밍 통계
+type ItemFarmingStat struct {
+	TotalCount   int `json:"total_count"`
+	SpecialCount int `json:"special_count"`
+	NormalCount  int `json:"normal_count"`
+	TrashCount   int `json:"trash_count"`
+}
+
+// === v3 구조체들 ===
+
+// EnhanceLevelStat 레벨별 강화 상세 통계
+type EnhanceLevelStat struct {
+	Attempts int `json:"attempts"`
+	Success  int `json:"success"`
+	Fail     int `json:"fail"`
+	Destroy  int `json:"destroy"`
+}
+
+// === v4 구조체들 ===
+
+// MatchupStat (검 타입, 내 레벨, 상대 레벨)별 배틀 통계
+type MatchupStat struct {
+	Battles    int `json:"battles"`
+	Wins       int `json:"wins"`
+	GoldEarned int `json:"gold_earned"` // 승리 보상 합계
+	GoldLost   int `json:"gold_lost"`   // 패배 손실 합계 (양수)
+}
+
+// MatchupKey 배틀 조합 키 ("normal_10_12")
+func MatchupKey(itemType string, myLevel, oppLevel int) string {
+	return fmt.Sprintf("%s_%d_%d", itemType, myLevel, oppLevel)
+}
+
+// ParseMatchupKey 배틀 조합 키 분해
+func ParseMatchupKey(key string) (itemType string, myLevel, oppLevel int, ok bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 {
+		return "", 0, 0, false
+	}
+	my, err1 := strconv.Atoi(parts[1])
+	opp, err2 := strconv.Atoi(parts[2])
+	if parts[0] == "" || err1 != nil || err2 != nil {
+		return "", 0, 0, false
+	}
+	return parts[0], my, opp, true
+}
+
+// TelemetryPayload 클라이언트 전송 페이로드
+type TelemetryPayload struct {
+	SchemaVersion int            `json:"schema_version"`
+	AppVersion    string         `json:"app_version"`
+	OSType        string         `json:"os_type"`
+	SessionID     string         `json:"session_id"`
+	Period        string         `json:"period"`
+	Mode          string         `json:"mode,omitempty"` // v3: 현재 모드
+	Seq           int64          `json:"seq,omitempty"`  // 세션 내 페이로드 순번 (0 = 구 클라이언트, 중복 검사 안 함)
+	Stats         TelemetryStats `json:"stats"`
+}
+
+// TelemetryEvent 개별 이벤트 (v4)
+type TelemetryEvent struct {
+	Type        string `json:"type"`                   // enhance, battle, farm, sale
+	Level       int    `json:"level"`                  // 강화 전 레벨 / 내 레벨 / 판매 레벨
+	TargetLevel int    `json:"target_level,omitempty"` // 배틀 상대 레벨
+	ItemType    string `json:"item_type,omitempty"`    // normal, special, trash
+	Result      string `json:"result"`                 // success/hold/fail/destroy, win/lose, found, sold
+	GoldDelta   int    `json:"gold_delta"`             // 골드 변화량
+	DurationMs  int64  `json:"duration_ms"`            // 직전 이벤트 이후 경과 시간
+	OffsetMs    int64  `json:"offset_ms"`              // 세션 시작 기준 발생 시각
+}
@@ -0,0 +1,111 @@
+package telemetry
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/api"
+	"github.com/StopDragon/sword-macro-ai/internal/logger"
+)
+
+const registerPath = "/api/register"
+
+// registerResponse 설치 등록 응답
+type registerResponse struct {
+	KeyID string `json:"key_id"`
+	Key   string `json:"key"`
+}
+
+// credentials 설치 키 조회 (없으면 서버에 등록해서 발급받음)
+// drainOutbox에서만 호출 (drainMu 보유, t.mu 미보유)
+func (t *Telemetry) credentials() (string, []byte, error) {
+	t.mu.Lock()
+	keyID, key := t.keyID, t.key
+	t.mu.Unlock()
+	if keyID != "" {
+		return keyID, key, nil
+	}
+
+	keyID, key, err := t.register()
+	if err != nil {
+		return "", nil, err
+	}
+
+	t.mu.Lock()
+	t.keyID, t.key = keyID, key
+	t.saveState()
+	t.mu.Unlock()
+
+	logger.Debug("[텔레메트리] 설치 등록 완료: %s", keyID)
+	return keyID, key, nil
+}
+
+// register 설치 등록 요청
+func (t *Telemetry) register() (string, []byte, error) {
+	data, _ := json.Marshal(map[string]string{
+		"app_version": t.appVersion,
+		"os_type":     runtime.GOOS,
+	})
+
+	resp, err := api.Client(sendTimeout).Post(api.URL(registerPath), "application/json", bytes.NewReader(data))
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("설치 등록 실패: %d", resp.StatusCode)
+	}
+
+	var reg registerResponse
+	if err := json.NewDecoder(resp.Body).Decode(&reg); err != nil {
+		return "", nil, fmt.Errorf("설치 등록 응답 파싱 실패: %v", err)
+	}
+	key, err := hex.DecodeString(reg.Key)
+	if err != nil || reg.KeyID == "" || len(key) == 0 {
+		return "", nil, fmt.Errorf("설치 등록 응답 오류")
+	}
+	return reg.KeyID, key, nil
+}
+
+// forgetCredentials 서버가 모르는/폐기한 키 삭제 (다음 전송 때 재등록)
+func (t *Telemetry) forgetCredentials(keyID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.keyID != keyID {
+		return
+	}
+	t.keyID = ""
+	t.key = nil
+	t.saveState()
+}
+
+// signRequest 요청 본문에 HMAC-SHA256 서명 헤더 추가
+// 서명 = hex(HMAC-SHA256(key, timestamp + "\n" + nonce + "\n" + body))
+func signRequest(req *http.Request, keyID string, key, body []byte) error {
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return fmt.Errorf("nonce 생성 실패: %v", err)
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp + "\n" + nonce + "\n"))
+	mac.Write(body)
+
+	req.Header.Set("X-Key-ID", keyID)
+	req.Header.Set("X-Timestamp", timestamp)
+	req.Header.Set("X-Nonce", nonce)
+	req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
@@ -0,0 +1,239 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/api"
+	"github.com/StopDragon/sword-macro-ai/internal/logger"
+	"github.com/google/uuid"
+)
+
+// legacyOutboxFile 이전 버전의 별도 아웃박스 파일 (상태 파일로 옮긴 뒤 삭제)
+const legacyOutboxFile = ".telemetry_outbox.json"
+
+const (
+	maxOutboxEntries = 100                // 아웃박스 최대 보관 개수 (초과 시 오래된 것부터 버림)
+	maxOutboxAge     = 7 * 24 * time.Hour // 아웃박스 최대 보관 기간
+	retryBaseDelay   = 30 * time.Second   // 재시도 기본 대기 시간
+	retryMaxDelay    = 6 * time.Hour      // 재시도 최대 대기 시간
+)
+
+// outboxEntry 전송 대기 중인 페이로드
+type outboxEntry struct {
+	ID          string  `json:"id"`
+	Payload     Payload `json:"payload"`
+	CreatedAt   int64   `json:"created_at"`
+	Attempts    int     `json:"attempts"`
+	NextAttempt int64   `json:"next_attempt"` // 다음 전송 가능 시각 (unix)
+}
+
+// errPermanent 재시도해도 소용없는 실패 (서버 거부 등)
+type errPermanent struct {
+	reason string
+}
+
+func (e errPermanent) Error() string {
+	return e.reason
+}
+
+// enqueue 페이로드를 아웃박스에 추가 (호출자가 Lock 보유, 순번 / 통계 리셋과 함께 saveState로 기록)
+// 서명은 전송 시점에 계산 (타임스탬프/nonce가 매 시도마다 새로 필요)
+func (t *Telemetry) enqueue(payload Payload) {
+	now := time.Now()
+	t.outbox = append(t.outbox, outboxEntry{
+		ID:          uuid.New().String(),
+		Payload:     payload,
+		CreatedAt:   now.Unix(),
+		NextAttempt: now.Unix(),
+	})
+	t.pruneOutbox(now)
+}
+
+// pruneOutbox 보관 기간/개수 제한 적용 (호출자가 Lock 보유)
+func (t *Telemetry) pruneOutbox(now time.Time) {
+	cutoff := now.Add(-maxOutboxAge).Unix()
+	kept := t.outbox[:0]
+	dropped := 0
+	for _, entry := range t.outbox {
+		if entry.CreatedAt < cutoff {
+			dropped++
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	if over := len(kept) - maxOutboxEntries; over > 0 {
+		dropped += over
+		kept = kept[over:]
+	}
+	t.outbox = kept
+
+	if dropped > 0 {
+		logger.Debug("[텔레메트리] 아웃박스 제한 초과로 %d건 폐기", dropped)
+	}
+}
+
+// drainOutbox 전송 가능한 아웃박스 항목 전송
+// wait=false면 다른 전송이 진행 중일 때 바로 반환, true면 끝날 때까지 기다린 뒤 전송
+// 네트워크 오류가 나면 남은 항목은 다음 기회로 미룸
+func (t *Telemetry) drainOutbox(wait bool) {
+	if wait {
+		t.drainMu.Lock()
+	} else if !t.drainMu.TryLock() {
+		return
+	}
+	defer t.drainMu.Unlock()
+
+	t.mu.Lock()
+	now := time.Now().Unix()
+	var due []outboxEntry
+	for _, entry := range t.outbox {
+		if entry.NextAttempt <= now {
+			due = append(due, entry)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, entry := range due {
+		err := t.deliver(entry.Payload)
+
+		t.mu.Lock()
+		switch err.(type) {
+		case nil:
+			t.removeOutboxEntry(entry.ID)
+			logger.Debug("[텔레메트리] 전송 성공")
+		case errPermanent:
+			t.removeOutboxEntry(entry.ID)
+			logger.Debug("[텔레메트리] %v - 페이로드 폐기", err)
+		default:
+			t.scheduleRetry(entry.ID)
+			logger.Debug("[텔레메트리] 전송 실패: %v", err)
+		}
+		t.saveState()
+		t.mu.Unlock()
+
+		if _, ok := err.(errPermanent); err != nil && !ok {
+			return
+		}
+	}
+}
+
+// removeOutboxEntry 아웃박스 항목 삭제 (호출자가 Lock 보유)
+func (t *Telemetry) removeOutboxEntry(id string) {
+	for i, entry := range t.outbox {
+		if entry.ID == id {
+			t.outbox = append(t.outbox[:i], t.outbox[i+1:]...)
+			return
+		}
+	}
+}
+
+// scheduleRetry 지수 백오프로 다음 전송 시각 설정 (호출자가 Lock 보유)
+func (t *Telemetry) scheduleRetry(id string) {
+	for i := range t.outbox {
+		if t.outbox[i].ID != id {
+			continue
+		}
+		t.outbox[i].Attempts++
+		t.outbox[i].NextAttempt = time.Now().Add(retryDelay(t.outbox[i].Attempts)).Unix()
+		return
+	}
+}
+
+// retryDelay 실패 횟수별 재시도 대기 시간 (30초부터 두 배씩, 최대 6시간)
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for n := 1; n < attempts && delay < retryMaxDelay; n++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// deliver 서명 후 HTTP 전송 (HTTP 200일 때만 nil, 로컬 모드면 로컬 저장소에 반영)
+func (t *Telemetry) deliver(payload Payload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errPermanent{reason: fmt.Sprintf("JSON 직렬화 실패: %v", err)}
+	}
+
+	// 로컬 모드: 서명 없이 로컬 저장소에 바로 반영 (실패는 재시도해도 같으므로 버림)
+	if local := api.Local(); local != nil {
+		if err := local.Ingest(data); err != nil {
+			return errPermanent{reason: err.Error()}
+		}
+		return nil
+	}
+
+	keyID, key, err := t.credentials()
+	if err != nil {
+		return err
+	}
+
+	client := api.Client(sendTimeout)
+	req, err := http.NewRequest("POST", api.URL(telemetryPath), bytes.NewBuffer(data))
+	if err != nil {
+		return fmt.Errorf("요청 생성 실패: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := signRequest(req, keyID, key, data); err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized:
+		// 키가 없어졌거나 폐기됨 → 재등록 후 재시도, 그 외(시각 오차 등)는 백오프 후 재시도
+		switch reason := resp.Header.Get("X-Auth-Error"); reason {
+		case "unknown_key", "revoked_key":
+			t.forgetCredentials(keyID)
+			return fmt.Errorf("설치 키 거부됨: %s", reason)
+		default:
+			return fmt.Errorf("서명 거부됨: %s", reason)
+		}
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("서버 응답 오류: %d", resp.StatusCode)
+	default:
+		return errPermanent{reason: fmt.Sprintf("서버 거부: %d", resp.StatusCode)}
+	}
+}
+
+// migrateLegacyOutbox 이전 버전의 별도 아웃박스 파일을 상태 파일로 옮김 (호출자가 Lock 보유)
+func (t *Telemetry) migrateLegacyOutbox() {
+	path := filepath.Join(filepath.Dir(t.statePath), legacyOutboxFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var entries []outboxEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		logger.Error("[텔레메트리] 아웃박스 파일 손상: %v", err)
+	}
+	known := make(map[string]bool, len(t.outbox))
+	for _, entry := range t.outbox {
+		known[entry.ID] = true
+	}
+	for _, entry := range entries {
+		if !known[entry.ID] {
+			t.outbox = append(t.outbox, entry)
+		}
+	}
+	t.pruneOutbox(time.Now())
+	if t.saveState() {
+		_ = os.Remove(path)
+	}
+}
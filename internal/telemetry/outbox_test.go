@@ -0,0 +1,197 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/StopDragon/sword-macro-ai/internal/api"
+)
+
+func newTestTelemetry(t *testing.T, dir string) *Telemetry {
+	t.Helper()
+	tel := &Telemetry{appVersion: "test", sessionStart: time.Now(), statePath: filepath.Join(dir, stateFile)}
+	tel.loadState()
+	return tel
+}
+
+func queueBattles(tel *Telemetry, battles int) {
+	tel.mu.Lock()
+	defer tel.mu.Unlock()
+	tel.stats.BattleCount = battles
+	tel.queueStats()
+}
+
+func outboxSeqs(tel *Telemetry) []int64 {
+	var seqs []int64
+	for _, entry := range tel.outbox {
+		seqs = append(seqs, entry.Payload.Seq)
+	}
+	return seqs
+}
+
+func TestOutboxPersistsWithSeq(t *testing.T) {
+	dir := t.TempDir()
+	tel := newTestTelemetry(t, dir)
+	queueBattles(tel, 2)
+	queueBattles(tel, 3)
+	if got := outboxSeqs(tel); fmt.Sprint(got) != "[1 2]" {
+		t.Fatalf("outbox seqs = %v, want [1 2]", got)
+	}
+	if tel.stats.BattleCount != 0 {
+		t.Errorf("stats not reset after queue: %d battles", tel.stats.BattleCount)
+	}
+	if _, err := os.Stat(tel.statePath + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary state file left behind: %v", err)
+	}
+
+	// 재시작: 아웃박스와 순번이 같은 파일에서 함께 복원
+	reloaded := newTestTelemetry(t, dir)
+	if reloaded.sessionID != tel.sessionID || reloaded.seq != 2 {
+		t.Errorf("reloaded session / seq = %s / %d, want %s / 2", reloaded.sessionID, reloaded.seq, tel.sessionID)
+	}
+	if got := outboxSeqs(reloaded); fmt.Sprint(got) != "[1 2]" {
+		t.Fatalf("reloaded outbox seqs = %v, want [1 2]", got)
+	}
+	if reloaded.outbox[1].Payload.Stats.BattleCount != 3 || reloaded.stats.BattleCount != 0 {
+		t.Errorf("reloaded payload battles = %d, pending battles = %d; want 3, 0",
+			reloaded.outbox[1].Payload.Stats.BattleCount, reloaded.stats.BattleCount)
+	}
+
+	queueBattles(reloaded, 1)
+	if got := outboxSeqs(reloaded); fmt.Sprint(got) != "[1 2 3]" {
+		t.Errorf("seqs after restart = %v, want [1 2 3]", got)
+	}
+}
+
+func TestLegacyOutboxMigration(t *testing.T) {
+	dir := t.TempDir()
+	legacy := []outboxEntry{{ID: "legacy-1", Payload: Payload{Seq: 7}, CreatedAt: time.Now().Unix()}}
+	data, _ := json.Marshal(legacy)
+	legacyPath := filepath.Join(dir, legacyOutboxFile)
+	if err := os.WriteFile(legacyPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tel := newTestTelemetry(t, dir)
+	if len(tel.outbox) != 1 || tel.outbox[0].ID != "legacy-1" {
+		t.Fatalf("outbox after migration = %+v", tel.outbox)
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Errorf("legacy outbox file not removed: %v", err)
+	}
+	if reloaded := newTestTelemetry(t, dir); len(reloaded.outbox) != 1 {
+		t.Errorf("migrated outbox not persisted: %d entries", len(reloaded.outbox))
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, retryMaxDelay},
+		{50, retryMaxDelay},
+	}
+	for _, c := range cases {
+		if got := retryDelay(c.attempts); got != c.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", c.attempts, got, c.want)
+		}
+	}
+
+	tel := &Telemetry{outbox: []outboxEntry{{ID: "a"}}}
+	tel.scheduleRetry("a")
+	tel.scheduleRetry("a")
+	entry := tel.outbox[0]
+	if wait := time.Until(time.Unix(entry.NextAttempt, 0)); entry.Attempts != 2 || wait < 58*time.Second || wait > time.Minute {
+		t.Errorf("after two failures: attempts = %d, next attempt in %v; want 2, 1m", entry.Attempts, wait)
+	}
+}
+
+func TestPruneOutbox(t *testing.T) {
+	now := time.Now()
+	tel := &Telemetry{}
+	tel.outbox = append(tel.outbox, outboxEntry{ID: "expired", CreatedAt: now.Add(-maxOutboxAge - time.Minute).Unix()})
+	for i := 0; i < maxOutboxEntries+5; i++ {
+		tel.outbox = append(tel.outbox, outboxEntry{ID: fmt.Sprintf("entry-%d", i), CreatedAt: now.Unix()})
+	}
+
+	tel.pruneOutbox(now)
+	if len(tel.outbox) != maxOutboxEntries {
+		t.Fatalf("outbox size = %d, want %d", len(tel.outbox), maxOutboxEntries)
+	}
+	// 기간이 지난 항목을 먼저 버리고, 개수 초과분은 오래된 것부터 버림
+	if first, last := tel.outbox[0].ID, tel.outbox[len(tel.outbox)-1].ID; first != "entry-5" || last != fmt.Sprintf("entry-%d", maxOutboxEntries+4) {
+		t.Errorf("kept entries %s..%s, want entry-5..entry-%d", first, last, maxOutboxEntries+4)
+	}
+}
+
+// recordingBackend 받은 페이로드를 기록하는 로컬 모드 저장소
+type recordingBackend struct {
+	payloads []Payload
+}
+
+func (b *recordingBackend) Ingest(data []byte) error {
+	var p Payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	b.payloads = append(b.payloads, p)
+	return nil
+}
+
+func (b *recordingBackend) Query(string, url.Values) ([]byte, error) { return nil, nil }
+func (b *recordingBackend) Close() error                             { return nil }
+
+func TestDrainOutbox(t *testing.T) {
+	dir := t.TempDir()
+	backend := &recordingBackend{}
+	api.UseLocal(backend)
+	t.Cleanup(func() { api.UseLocal(nil) })
+
+	tel := newTestTelemetry(t, dir)
+	queueBattles(tel, 2)
+	tel.drainOutbox(true)
+	if len(backend.payloads) != 1 || backend.payloads[0].Seq != 1 || backend.payloads[0].Stats.BattleCount != 2 {
+		t.Fatalf("delivered = %+v", backend.payloads)
+	}
+	if reloaded := newTestTelemetry(t, dir); len(reloaded.outbox) != 0 || reloaded.seq != 1 {
+		t.Errorf("after delivery: %d pending, seq %d; want 0, 1", len(reloaded.outbox), reloaded.seq)
+	}
+}
+
+func TestDrainOutboxRetriesServerErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == registerPath {
+			json.NewEncoder(w).Encode(registerResponse{KeyID: "key-1", Key: "00112233"})
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	api.SetBaseURL(srv.URL)
+	t.Cleanup(func() { api.SetBaseURL("") })
+
+	dir := t.TempDir()
+	tel := newTestTelemetry(t, dir)
+	queueBattles(tel, 2)
+	tel.drainOutbox(true)
+
+	reloaded := newTestTelemetry(t, dir)
+	if len(reloaded.outbox) != 1 || reloaded.keyID != "key-1" {
+		t.Fatalf("after 503: %d pending, key %q; want 1, key-1", len(reloaded.outbox), reloaded.keyID)
+	}
+	if entry := reloaded.outbox[0]; entry.Attempts != 1 || entry.NextAttempt <= time.Now().Unix() {
+		t.Errorf("retry not scheduled: %+v", entry)
+	}
+}
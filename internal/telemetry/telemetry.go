@@ -1,12 +1,9 @@
 package telemetry
 
 import (
-	"bytes"
-	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -18,22 +15,13 @@ import (
 )
 
 const (
-	endpoint         = "https://sword-ai.stopdragon.kr/api/telemetry"
-	stateFile        = ".telemetry_state.json"
-	schemaVer        = 3 // v3: 레벨별 강화 상세, 모드, 강화비용, 사이클시간, 배틀손실 추가
-	sendTimeout = 5 * time.Second
-	defaultAppSecret = "sw0rd-m4cr0-2026-s3cr3t-k3y" // 환경변수 없을 때 기본값
-	appSecretEnvVar  = "SWORD_APP_SECRET"
+	telemetryPath = "/api/telemetry"
+	stateFile     = ".telemetry_state.json"
+	schemaVer     = 4 // v4: 개별 이벤트 목록 추가
+	sendTimeout   = 5 * time.Second
+	maxEvents     = 500 // 전송 1회당 최대 이벤트 수 (초과분은 버림)
 )
 
-// getAppSecret 앱 시크릿 조회 (환경변수 우선)
-func getAppSecret() string {
-	if secret := os.Getenv(appSecretEnvVar); secret != "" {
-		return secret
-	}
-	return defaultAppSecret
-}
-
 // SwordBattleStat 검 종류별 배틀 통계
 type SwordBattleStat struct {
 	BattleCount   int `json:"battle_count"`
@@ -87,6 +75,26 @@ type EnhanceLevelStat struct {
 	Destroy  int `json:"destroy"`  // 파괴
 }
 
+// MatchupStat (검 타입, 내 레벨, 상대 레벨)별 배틀 통계 (v4)
+type MatchupStat struct {
+	Battles    int `json:"battles"`
+	Wins       int `json:"wins"`
+	GoldEarned int `json:"gold_earned"` // 승리 보상 합계
+	GoldLost   int `json:"gold_lost"`   // 패배 손실 합계 (양수)
+}
+
+// Event 개별 이벤트 (v4)
+type Event struct {
+	Type        string `json:"type"`                   // enhance, battle, farm, sale
+	Level       int    `json:"level"`                  // 강화 전 레벨 / 내 레벨 / 판매 레벨
+	TargetLevel int    `json:"target_level,omitempty"` // 배틀 상대 레벨
+	ItemType    string `json:"item_type,omitempty"`    // normal, special, trash
+	Result      string `json:"result"`                 // success/hold/destroy, win/lose, found, sold
+	GoldDelta   int    `json:"gold_delta"`             // 골드 변화량
+	DurationMs  int64  `json:"duration_ms"`            // 직전 이벤트 이후 경과 시간
+	OffsetMs    int64  `json:"offset_ms"`              // 세션 시작 기준 발생 시각
+}
+
 // Stats 수집 통계
 type Stats struct {
 	// 기본 통계
@@ -158,6 +166,21 @@ type Stats struct {
 
 	// 배틀 패배 시 잃은 골드
 	BattleGoldLost int `json:"battle_gold_lost"`
+
+	// === v4 새로 추가 ===
+
+	// 개별 이벤트 목록 (최대 maxEvents개)
+	Events []Event `json:"events,omitempty"`
+
+	// maxEvents 초과로 버린 이벤트 수
+	EventsDropped int `json:"events_dropped,omitempty"`
+
+	// 검 타입+레벨 조합별 배틀 통계: "normal_10_12" -> MatchupStat
+	BattleMatchups map[string]*MatchupStat `json:"battle_matchups,omitempty"`
+
+	// /프로필 재동기화 횟수와 그중 내부 상태가 실제와 달랐던 횟수
+	StateResyncs int `json:"state_resyncs,omitempty"`
+	StateDrifts  int `json:"state_drifts,omitempty"`
 }
 
 // Payload 서버 전송 데이터
@@ -168,6 +191,7 @@ type Payload struct {
 	SessionID     string `json:"session_id"`
 	Period        string `json:"period"`
 	Mode          string `json:"mode,omitempty"` // v3: 현재 모드 (enhance/special/goldmine/battle)
+	Seq           int64  `json:"seq"`            // 세션 내 페이로드 순번 (서버 중복 제거용, 재전송 시 그대로 유지)
 	Stats         Stats  `json:"stats"`
 }
 
@@ -175,9 +199,15 @@ type Payload struct {
 type state struct {
 	Enabled      bool   `json:"enabled"`
 	SessionID    string `json:"session_id"`
+	Seq          int64  `json:"seq"` // 마지막으로 부여한 페이로드 순번
 	LastSentTime int64  `json:"last_sent_time"`
 	Stats        Stats  `json:"stats"`
 	SessionStart int64  `json:"session_start"`
+	KeyID        string `json:"key_id,omitempty"` // 설치 키 ID (/api/register 발급)
+	Key          string `json:"key,omitempty"`    // 설치 키 (hex)
+
+	// 전송 대기 페이로드 (순번 / 통계 리셋과 한 번에 기록해야 재시작 후 같은 통계가 새 순번으로 다시 전송되지 않음)
+	Outbox []outboxEntry `json:"outbox,omitempty"`
 }
 
 // Telemetry 텔레메트리 클라이언트
@@ -185,12 +215,18 @@ type Telemetry struct {
 	mu           sync.Mutex
 	enabled      bool
 	sessionID    string
+	seq          int64 // 마지막으로 부여한 페이로드 순번
 	appVersion   string
 	mode         string // 현재 모드 (enhance/special/goldmine/battle)
 	stats        Stats
 	sessionStart time.Time
 	lastSentTime time.Time
+	lastEventAt  time.Time // 직전 이벤트 시각 (이벤트 소요 시간 계산용)
 	statePath    string
+	keyID        string        // 설치 키 ID (없으면 전송 전에 등록)
+	key          []byte        // 설치 키 (본문 HMAC 서명용)
+	outbox       []outboxEntry // 전송 대기 페이로드 (디스크와 동기화)
+	drainMu      sync.Mutex    // 아웃박스 전송은 한 번에 하나만
 }
 
 // New 텔레메트리 인스턴스 생성
@@ -201,6 +237,11 @@ func New(appVersion string) *Telemetry {
 		statePath:    getStatePath(),
 	}
 	t.loadState()
+
+	// 이전 실행에서 못 보낸 페이로드 재전송
+	if t.enabled && len(t.outbox) > 0 {
+		go t.drainOutbox(false)
+	}
 	return t
 }
 
@@ -226,6 +267,27 @@ func (t *Telemetry) SetMode(mode string) {
 	t.mode = mode
 }
 
+// recordEvent 개별 이벤트 추가 (호출자가 Lock 보유)
+// 모니터링 모드는 다른 유저의 행동이므로 이벤트로 남기지 않음
+func (t *Telemetry) recordEvent(ev Event) {
+	if t.mode == "monitor" {
+		return
+	}
+
+	now := time.Now()
+	if !t.lastEventAt.IsZero() {
+		ev.DurationMs = now.Sub(t.lastEventAt).Milliseconds()
+	}
+	ev.OffsetMs = now.Sub(t.sessionStart).Milliseconds()
+	t.lastEventAt = now
+
+	if len(t.stats.Events) >= maxEvents {
+		t.stats.EventsDropped++
+		return
+	}
+	t.stats.Events = append(t.stats.Events, ev)
+}
+
 // RecordCycle 사이클 기록
 func (t *Telemetry) RecordCycle(success bool) {
 	t.mu.Lock()
@@ -241,6 +303,19 @@ func (t *Telemetry) RecordCycle(success bool) {
 	}
 }
 
+// RecordResync /프로필 재동기화 기록 (drift: 추정 레벨 / 검 이름이 실제와 달랐는지)
+func (t *Telemetry) RecordResync(drift bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.enabled {
+		return
+	}
+	t.stats.StateResyncs++
+	if drift {
+		t.stats.StateDrifts++
+	}
+}
+
 // RecordGold 금광 채굴 기록
 func (t *Telemetry) RecordGold(amount int) {
 	t.mu.Lock()
@@ -360,8 +435,9 @@ func (t *Telemetry) RecordFarming(isSpecial bool) {
 // === v2 새로운 Record 함수들 ===
 
 // RecordBattleWithSword 검 종류 포함 배틀 기록
+// itemType: 내 검 타입 (normal/special, 모르면 빈 문자열)
 // goldChange: 승리 시 양수(보상), 패배 시 음수(손실) 또는 0
-func (t *Telemetry) RecordBattleWithSword(swordName string, myLevel, oppLevel int, won bool, goldChange int) {
+func (t *Telemetry) RecordBattleWithSword(swordName, itemType string, myLevel, oppLevel int, won bool, goldChange int) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	if !t.enabled {
@@ -411,6 +487,38 @@ func (t *Telemetry) RecordBattleWithSword(swordName string, myLevel, oppLevel in
 		}
 	}
 
+	result := "lose"
+	if won {
+		result = "win"
+	}
+	t.recordEvent(Event{
+		Type:        "battle",
+		Level:       myLevel,
+		TargetLevel: oppLevel,
+		ItemType:    itemType,
+		Result:      result,
+		GoldDelta:   goldChange,
+	})
+
+	// 검 타입+레벨 조합별 배틀 통계 (v4) - 서버 승률 모델 학습용
+	if itemType != "" {
+		if t.stats.BattleMatchups == nil {
+			t.stats.BattleMatchups = make(map[string]*MatchupStat)
+		}
+		key := fmt.Sprintf("%s_%d_%d", itemType, myLevel, oppLevel)
+		if t.stats.BattleMatchups[key] == nil {
+			t.stats.BattleMatchups[key] = &MatchupStat{}
+		}
+		stat := t.stats.BattleMatchups[key]
+		stat.Battles++
+		if won {
+			stat.Wins++
+			stat.GoldEarned += goldChange
+		} else if goldChange < 0 {
+			stat.GoldLost += -goldChange
+		}
+	}
+
 	// 레벨차별 역배 통계 (v2) - 1-20 레벨 차이 지원
 	if isUpset && levelDiff <= 20 {
 		if t.stats.UpsetStatsByDiff == nil {
@@ -486,6 +594,12 @@ func (t *Telemetry) RecordFarmingWithItem(itemName string, itemType string) {
 		t.stats.TrashFound++
 	}
 
+	t.recordEvent(Event{
+		Type:     "farm",
+		ItemType: itemType,
+		Result:   "found",
+	})
+
 	// 아이템별 파밍 통계 (v2)
 	if itemName != "" {
 		// 특수 이름별 통계
@@ -560,6 +674,13 @@ func (t *Telemetry) RecordEnhanceWithType(itemType string, level int, result str
 		lvlStat.Destroy++
 	}
 
+	t.recordEvent(Event{
+		Type:     "enhance",
+		Level:    level,
+		ItemType: itemType,
+		Result:   result,
+	})
+
 	// 타입+레벨별 강화 통계 (v3)
 	// 키 형식: "{type}_{level}" (예: "normal_10", "special_10")
 	if itemType != "" {
@@ -600,6 +721,14 @@ func (t *Telemetry) RecordSaleWithType(itemType string, level int, price int) {
 		t.stats.SalesMaxPrice = price
 	}
 
+	t.recordEvent(Event{
+		Type:      "sale",
+		Level:     level,
+		ItemType:  itemType,
+		Result:    "sold",
+		GoldDelta: price,
+	})
+
 	// 타입+레벨별 판매 통계 (v3)
 	// 키 형식: "{type}_{level}" (예: "normal_10", "special_10")
 	if itemType != "" {
@@ -735,33 +864,32 @@ func (t *Telemetry) TrySend() {
 		return
 	}
 
-	// 전송할 데이터 준비 (복사본 생성 - 레이스 컨디션 방지)
-	t.stats.SessionDuration = int(time.Since(t.sessionStart).Seconds())
-	period := time.Now().Format("2006-01-02")
+	// 마지막 전송 시간 업데이트 (lock 내에서)
+	t.lastSentTime = time.Now()
+	t.queueStats()
+	t.mu.Unlock()
+
+	// 비동기 전송 (메인 스레드 블로킹 방지)
+	go t.drainOutbox(false)
+}
 
-	payload := Payload{
+// queueStats 현재 통계를 새 순번으로 아웃박스에 넣고 통계 리셋 (호출자가 Lock 보유)
+// 아웃박스 / 순번 / 리셋된 통계를 상태 파일에 한 번에 기록 (전송 실패나 중간 종료에도 유실 / 중복 없음)
+func (t *Telemetry) queueStats() {
+	t.stats.SessionDuration = int(time.Since(t.sessionStart).Seconds())
+	t.seq++
+	t.enqueue(Payload{
 		SchemaVersion: schemaVer,
 		AppVersion:    t.appVersion,
 		OSType:        runtime.GOOS,
 		SessionID:     t.sessionID,
-		Period:        period,
+		Period:        time.Now().Format("2006-01-02"),
 		Mode:          t.mode,
-		Stats:         t.copyStats(), // 복사본 사용
-	}
-
-	// 서명 생성
-	signature := generateSignature(t.sessionID, period)
-
-	// 마지막 전송 시간 업데이트 (lock 내에서)
-	t.lastSentTime = time.Now()
-
-	// 통계 리셋 (비동기 전송 전에 리셋하여 중복 전송 방지)
+		Seq:           t.seq,
+		Stats:         t.copyStats(), // 복사본 사용 (레이스 컨디션 방지)
+	})
 	t.stats = Stats{}
 	t.saveState()
-	t.mu.Unlock()
-
-	// 비동기 전송 (메인 스레드 블로킹 방지)
-	go t.sendAsync(payload, signature)
 }
 
 // copyStats 통계 복사본 생성 (맵 deep copy)
@@ -827,128 +955,42 @@ func (t *Telemetry) copyStats() Stats {
 			copied.EnhanceLevelDetail[k] = &vc
 		}
 	}
-
-	return copied
-}
-
-// sendAsync 비동기 전송 (복사된 payload 사용)
-func (t *Telemetry) sendAsync(payload Payload, signature string) {
-	data, err := json.Marshal(payload)
-	if err != nil {
-		logger.Error("[텔레메트리] JSON 직렬화 실패: %v", err)
-		return
-	}
-
-	client := &http.Client{Timeout: sendTimeout}
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(data))
-	if err != nil {
-		logger.Error("[텔레메트리] 요청 생성 실패: %v", err)
-		return
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-App-Signature", signature)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		logger.Debug("[텔레메트리] 전송 실패: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		logger.Debug("[텔레메트리] 전송 성공")
-	} else {
-		logger.Debug("[텔레메트리] 서버 응답 오류: %d", resp.StatusCode)
-	}
-}
-
-// generateSignature 서명 생성
-func generateSignature(sessionID, period string) string {
-	h := sha256.Sum256([]byte(sessionID + period + getAppSecret()))
-	return hex.EncodeToString(h[:])[:16]
-}
-
-// send HTTP 전송 (에러 로깅 포함)
-func (t *Telemetry) send(payload Payload, signature string) {
-	data, err := json.Marshal(payload)
-	if err != nil {
-		logger.Error("[텔레메트리] JSON 직렬화 실패: %v", err)
-		return
+	if t.stats.Events != nil {
+		copied.Events = make([]Event, len(t.stats.Events))
+		copy(copied.Events, t.stats.Events)
 	}
-
-	client := &http.Client{Timeout: sendTimeout}
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(data))
-	if err != nil {
-		logger.Error("[텔레메트리] 요청 생성 실패: %v", err)
-		return
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-App-Signature", signature)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		logger.Debug("[텔레메트리] 전송 실패: %v", err)
-		return
+	if t.stats.BattleMatchups != nil {
+		copied.BattleMatchups = make(map[string]*MatchupStat)
+		for k, v := range t.stats.BattleMatchups {
+			vc := *v
+			copied.BattleMatchups[k] = &vc
+		}
 	}
-	defer resp.Body.Close()
 
-	// 성공 시 상태 업데이트
-	if resp.StatusCode == http.StatusOK {
-		t.mu.Lock()
-		t.stats = Stats{} // 통계 리셋
-		t.saveState()
-		t.mu.Unlock()
-		logger.Debug("[텔레메트리] 전송 성공")
-	} else {
-		logger.Debug("[텔레메트리] 서버 응답 오류: %d", resp.StatusCode)
-	}
+	return copied
 }
 
-// Flush 종료 시 강제 전송
+// Flush 종료 시 강제 전송 (남은 통계를 아웃박스에 넣고 동기 전송)
 func (t *Telemetry) Flush() {
 	t.mu.Lock()
-	defer t.mu.Unlock()
 
 	if !t.enabled {
+		t.mu.Unlock()
 		return
 	}
 
-	// 전송할 데이터가 있으면 전송
+	// 전송할 데이터가 있으면 아웃박스에 추가
 	if t.stats.TotalCycles > 0 || t.stats.TotalSwordsFound > 0 ||
 		t.stats.BattleCount > 0 || t.stats.SalesCount > 0 || t.stats.FarmingAttempts > 0 ||
 		t.stats.EnhanceAttempts > 0 {
-		t.stats.SessionDuration = int(time.Since(t.sessionStart).Seconds())
-		period := time.Now().Format("2006-01-02")
-
-		payload := Payload{
-			SchemaVersion: schemaVer,
-			AppVersion:    t.appVersion,
-			OSType:        runtime.GOOS,
-			SessionID:     t.sessionID,
-			Period:        period,
-			Mode:          t.mode,
-			Stats:         t.stats,
-		}
-
-		signature := generateSignature(t.sessionID, period)
-
-		// 동기 전송 (종료 전 확실히 전송)
-		data, err := json.Marshal(payload)
-		if err == nil {
-			client := &http.Client{Timeout: sendTimeout}
-			req, _ := http.NewRequest("POST", endpoint, bytes.NewBuffer(data))
-			if req != nil {
-				req.Header.Set("Content-Type", "application/json")
-				req.Header.Set("X-App-Signature", signature)
-				resp, err := client.Do(req)
-				if err == nil {
-					resp.Body.Close()
-				}
-			}
-		}
+		t.queueStats()
+	} else {
+		t.saveState()
 	}
+	t.mu.Unlock()
 
-	t.saveState()
+	// 동기 전송 (종료 전 최대한 전송, 실패분은 다음 실행 때 재시도)
+	t.drainOutbox(true)
 }
 
 func (t *Telemetry) loadState() {
@@ -958,12 +1000,24 @@ func (t *Telemetry) loadState() {
 	st := t.loadStateUnlocked()
 	t.enabled = st.Enabled
 	t.sessionID = st.SessionID
+	t.seq = st.Seq
 	t.stats = st.Stats
 	t.lastSentTime = time.Unix(st.LastSentTime, 0)
+	t.keyID = st.KeyID
+	if key, err := hex.DecodeString(st.Key); err == nil && st.KeyID != "" {
+		t.key = key
+	} else {
+		t.keyID = ""
+	}
 
 	if t.sessionID == "" {
 		t.sessionID = uuid.New().String()
+		t.seq = 0
 	}
+
+	t.outbox = st.Outbox
+	t.pruneOutbox(time.Now())
+	t.migrateLegacyOutbox()
 }
 
 func (t *Telemetry) loadStateUnlocked() state {
@@ -979,23 +1033,38 @@ func (t *Telemetry) loadStateUnlocked() state {
 	return st
 }
 
-func (t *Telemetry) saveState() {
+// saveState 상태 파일 기록 (호출자가 Lock 보유, 반환: 저장 성공 여부)
+func (t *Telemetry) saveState() bool {
 	st := state{
 		Enabled:      t.enabled,
 		SessionID:    t.sessionID,
+		Seq:          t.seq,
 		LastSentTime: t.lastSentTime.Unix(),
 		Stats:        t.stats,
 		SessionStart: t.sessionStart.Unix(),
+		KeyID:        t.keyID,
+		Key:          hex.EncodeToString(t.key),
+		Outbox:       t.outbox,
 	}
-	t.saveStateUnlocked(st)
+	return t.saveStateUnlocked(st)
 }
 
-func (t *Telemetry) saveStateUnlocked(st state) {
+// saveStateUnlocked 상태 파일 기록 (임시 파일 → rename)
+func (t *Telemetry) saveStateUnlocked(st state) bool {
 	data, err := json.MarshalIndent(st, "", "  ")
 	if err != nil {
-		return
+		return false
+	}
+	tmp := t.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil { // 설치 키 포함
+		logger.Error("[텔레메트리] 상태 저장 실패: %v", err)
+		return false
+	}
+	if err := os.Rename(tmp, t.statePath); err != nil {
+		logger.Error("[텔레메트리] 상태 저장 실패: %v", err)
+		return false
 	}
-	_ = os.WriteFile(t.statePath, data, 0644)
+	return true
 }
 
 func getStatePath() string {